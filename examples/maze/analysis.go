@@ -0,0 +1,195 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// MazeReport is the report about solvability and deceptiveness of the maze
+type MazeReport struct {
+	// The number of maze walls
+	Walls int `json:"walls"`
+	// The initial location of the agent
+	Start Point `json:"start"`
+	// The location of the maze exit
+	Exit Point `json:"exit"`
+	// The radius of agent body used to build the configuration space grid
+	AgentRadius float64 `json:"agent_radius"`
+	// The size of the configuration space grid cell
+	CellSize float64 `json:"cell_size"`
+
+	// The flag to indicate whether the maze exit is reachable by the agent from the start location
+	Reachable bool `json:"reachable"`
+	// The straight line distance from the start location to the maze exit
+	EuclideanDistance float64 `json:"euclidean_distance"`
+	// The length of the shortest path from the start location to the maze exit, zero if exit is not reachable
+	ShortestPathLength float64 `json:"shortest_path_length"`
+	// The ratio of the shortest path length to the Euclidean distance, zero if exit is not reachable
+	Deceptiveness float64 `json:"deceptiveness"`
+
+	// The number of configuration space cells reachable from the maze exit
+	ReachableCells int `json:"reachable_cells"`
+	// The basins of local optima of the Euclidean fitness, i.e., the areas where greedy descent of the distance to
+	// the maze exit ends anywhere but at the exit. Sorted by size in descending order.
+	LocalOptima []OptimumBasin `json:"local_optima"`
+	// The fraction of reachable cells which belong to the basins of local optima
+	DeceptiveArea float64 `json:"deceptive_area"`
+}
+
+// OptimumBasin is the basin of attraction of the local optimum of the Euclidean fitness
+type OptimumBasin struct {
+	// The location of the local optimum
+	Location Point `json:"location"`
+	// The straight line distance from the local optimum to the maze exit
+	EuclideanDistance float64 `json:"euclidean_distance"`
+	// The length of the shortest path from the local optimum to the maze exit
+	PathDistance float64 `json:"path_distance"`
+	// The number of cells in the basin
+	Cells int `json:"cells"`
+	// The fraction of reachable cells which belong to the basin
+	Area float64 `json:"area"`
+}
+
+// AnalyzeMaze is to analyze solvability and deceptiveness of the maze environment using configuration space grid with
+// given cell size. The configuration space takes into account the radius of the agent's body.
+func AnalyzeMaze(env *Environment, cellSize float64) (*MazeReport, error) {
+	field, err := NewDistanceField(env.Lines, env.MazeExit, cellSize, env.Hero.Radius)
+	if err != nil && !errors.Is(err, ErrTargetNotReachable) {
+		return nil, err
+	}
+	report := &MazeReport{
+		Walls:             len(env.Lines),
+		Start:             env.Hero.Location,
+		Exit:              env.MazeExit,
+		AgentRadius:       env.Hero.Radius,
+		CellSize:          cellSize,
+		EuclideanDistance: env.Hero.Location.Distance(env.MazeExit),
+		LocalOptima:       make([]OptimumBasin, 0),
+	}
+	if field == nil {
+		// the maze exit is too close to the walls to be reached by the agent
+		return report, nil
+	}
+	if pathLength := field.Distance(env.Hero.Location); !math.IsInf(pathLength, 1) {
+		report.Reachable = true
+		report.ShortestPathLength = pathLength
+		if report.EuclideanDistance > 0 {
+			report.Deceptiveness = pathLength / report.EuclideanDistance
+		}
+	}
+
+	// the optima close to the exit are considered as the global one
+	exitRange := math.Max(env.ExitFoundRange, cellSize*math.Sqrt2)
+	findOptimaBasins(field, exitRange, report)
+
+	return report, nil
+}
+
+// findOptimaBasins is to find the basins of attraction of the Euclidean fitness local optima over reachable cells of
+// the distance field using the steepest descent. The optima within exitRange from the maze exit are ignored.
+func findOptimaBasins(field *DistanceField, exitRange float64, report *MazeReport) {
+	size := field.Cols * field.Rows
+	euclidean := make([]float64, size)
+	descent := make([]int, size)
+	for i := range descent {
+		descent[i] = -1
+		c, r := i%field.Cols, i/field.Cols
+		if !math.IsInf(field.CellDistance(c, r), 1) {
+			report.ReachableCells++
+			euclidean[i] = field.CellCenter(c, r).Distance(field.Target)
+		}
+	}
+	if report.ReachableCells == 0 {
+		return
+	}
+
+	// find the steepest descent direction for each reachable cell
+	for i := range descent {
+		c, r := i%field.Cols, i/field.Cols
+		if math.IsInf(field.CellDistance(c, r), 1) {
+			continue
+		}
+		descent[i] = i
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				nc, nr := c+dc, r+dr
+				if (dc == 0 && dr == 0) || !field.Connected(c, r, nc, nr) {
+					continue
+				}
+				if n := nr*field.Cols + nc; euclidean[n] < euclidean[descent[i]] {
+					descent[i] = n
+				}
+			}
+		}
+	}
+
+	// find optimum of each cell by following descent directions
+	optima := make(map[int]int)
+	for i := range descent {
+		if descent[i] < 0 {
+			continue
+		}
+		optimum := i
+		for descent[optimum] != optimum {
+			optimum = descent[optimum]
+		}
+		optima[optimum]++
+	}
+
+	deceptiveCells := 0
+	for optimum, cells := range optima {
+		if euclidean[optimum] <= exitRange {
+			continue
+		}
+		c, r := optimum%field.Cols, optimum/field.Cols
+		report.LocalOptima = append(report.LocalOptima, OptimumBasin{
+			Location:          field.CellCenter(c, r),
+			EuclideanDistance: euclidean[optimum],
+			PathDistance:      field.CellDistance(c, r),
+			Cells:             cells,
+			Area:              float64(cells) / float64(report.ReachableCells),
+		})
+		deceptiveCells += cells
+	}
+	sort.Slice(report.LocalOptima, func(i, j int) bool {
+		if report.LocalOptima[i].Cells == report.LocalOptima[j].Cells {
+			return report.LocalOptima[i].EuclideanDistance < report.LocalOptima[j].EuclideanDistance
+		}
+		return report.LocalOptima[i].Cells > report.LocalOptima[j].Cells
+	})
+	report.DeceptiveArea = float64(deceptiveCells) / float64(report.ReachableCells)
+}
+
+// WriteJSON writes report to the provided writer as JSON
+func (r *MazeReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Stringer
+func (r *MazeReport) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("MAZE REPORT\nWalls: %d, agent radius: %.1f, grid cell size: %.1f\n",
+		r.Walls, r.AgentRadius, r.CellSize))
+	sb.WriteString(fmt.Sprintf("Start at: %.1f, %.1f\nExit at: %.1f, %.1f\n", r.Start.X, r.Start.Y, r.Exit.X, r.Exit.Y))
+	if r.Reachable {
+		sb.WriteString("Exit is REACHABLE\n")
+		sb.WriteString(fmt.Sprintf("Shortest path length: %.1f, Euclidean distance: %.1f, deceptiveness: %.2f\n",
+			r.ShortestPathLength, r.EuclideanDistance, r.Deceptiveness))
+	} else {
+		sb.WriteString("Exit is NOT REACHABLE\n")
+	}
+	sb.WriteString(fmt.Sprintf("Reachable cells: %d, local optima: %d, deceptive area: %.1f%%\n",
+		r.ReachableCells, len(r.LocalOptima), r.DeceptiveArea*100))
+	for i, b := range r.LocalOptima {
+		sb.WriteString(fmt.Sprintf("\t%d: at [%.1f, %.1f], distance to exit: %.1f, path to exit: %.1f, area: %.1f%%\n",
+			i, b.Location.X, b.Location.Y, b.EuclideanDistance, b.PathDistance, b.Area*100))
+	}
+	return sb.String()
+}
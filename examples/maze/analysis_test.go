@@ -0,0 +1,99 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"testing"
+)
+
+func TestAnalyzeMaze(t *testing.T) {
+	mazeFile, err := os.Open("../../data/hard_maze.txt")
+	require.NoError(t, err, "failed to open maze file")
+	env, err := ReadEnvironment(mazeFile)
+	require.NoError(t, err, "failed to read environment")
+
+	report, err := AnalyzeMaze(env, 2)
+	require.NoError(t, err)
+
+	assert.True(t, report.Reachable)
+	assert.Equal(t, 11, report.Walls)
+	assert.Equal(t, env.Hero.Radius, report.AgentRadius)
+	assert.Greater(t, report.ShortestPathLength, report.EuclideanDistance)
+	assert.Greater(t, report.Deceptiveness, 1.0)
+	assert.Greater(t, report.ReachableCells, 0)
+
+	// the hard maze has local optima
+	require.NotEmpty(t, report.LocalOptima)
+	assert.Greater(t, report.DeceptiveArea, 0.0)
+	assert.Less(t, report.DeceptiveArea, 1.0)
+	for i := 1; i < len(report.LocalOptima); i++ {
+		assert.GreaterOrEqual(t, report.LocalOptima[i-1].Cells, report.LocalOptima[i].Cells)
+	}
+
+	assert.Contains(t, report.String(), "Exit is REACHABLE")
+}
+
+func TestAnalyzeMaze_openSpace(t *testing.T) {
+	env := &Environment{
+		Hero:     NewAgent(),
+		Lines:    createBoxLines(100, 100),
+		MazeExit: Point{X: 80, Y: 80},
+	}
+	env.Hero.Location = Point{X: 20, Y: 20}
+
+	report, err := AnalyzeMaze(env, 2)
+	require.NoError(t, err)
+
+	// no deception in the open space
+	assert.True(t, report.Reachable)
+	assert.InDelta(t, 1.0, report.Deceptiveness, 0.1)
+	assert.Empty(t, report.LocalOptima)
+	assert.Equal(t, 0.0, report.DeceptiveArea)
+}
+
+func TestAnalyzeMaze_notReachable(t *testing.T) {
+	// the wall splits the box into two parts
+	env := &Environment{
+		Hero:     NewAgent(),
+		Lines:    append(createBoxLines(100, 100), Line{A: Point{X: 50, Y: 0}, B: Point{X: 50, Y: 100}}),
+		MazeExit: Point{X: 80, Y: 80},
+	}
+	env.Hero.Location = Point{X: 20, Y: 20}
+
+	report, err := AnalyzeMaze(env, 2)
+	require.NoError(t, err)
+	assert.False(t, report.Reachable)
+	assert.Equal(t, 0.0, report.ShortestPathLength)
+	assert.Contains(t, report.String(), "Exit is NOT REACHABLE")
+
+	// the exit is among the walls closer to each other than the agent's body
+	for _, x := range []float64{60, 70, 80} {
+		env.Lines = append(env.Lines, Line{A: Point{X: x, Y: 0}, B: Point{X: x, Y: 40}})
+	}
+	env.MazeExit = Point{X: 75, Y: 5}
+	report, err = AnalyzeMaze(env, 2)
+	require.NoError(t, err)
+	assert.False(t, report.Reachable)
+	assert.Equal(t, 0.0, report.ShortestPathLength)
+	assert.Zero(t, report.ReachableCells)
+	assert.Empty(t, report.LocalOptima)
+}
+
+func TestMazeReport_WriteJSON(t *testing.T) {
+	report := &MazeReport{
+		Walls:       4,
+		Reachable:   true,
+		LocalOptima: []OptimumBasin{{Location: Point{X: 1, Y: 2}, Cells: 10, Area: 0.1}},
+	}
+	var buf bytes.Buffer
+	err := report.WriteJSON(&buf)
+	require.NoError(t, err)
+
+	var decoded MazeReport
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, *report, decoded)
+}
@@ -0,0 +1,158 @@
+package maze
+
+import (
+	"errors"
+	"github.com/fogleman/gg"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math"
+)
+
+// AnimationOptions is the options to render animation of the agent's run in the maze
+type AnimationOptions struct {
+	// The number of simulation time steps per animation frame
+	FrameStride int
+	// The scale factor of the frame size
+	Scale float64
+	// The delay between frames in 100ths of a second
+	FrameDelay int
+}
+
+// DefaultAnimationOptions returns the default options to render animation
+func DefaultAnimationOptions() AnimationOptions {
+	return AnimationOptions{
+		FrameStride: 1,
+		Scale:       1.0,
+		FrameDelay:  4,
+	}
+}
+
+// RenderRolloutFrames renders the frames of the recorded rollout taken each FrameStride time steps and at the last
+// step. Each frame shows the maze, the path traveled by the agent so far, the agent's body with its heading, the range
+// finder rays, and the radar sectors with the active ones highlighted. The frames are converted to the Plan 9 palette
+// as they are rendered to keep the memory footprint of long rollouts low.
+func RenderRolloutFrames(r *Rollout, opts AnimationOptions) ([]*image.Paletted, error) {
+	if len(r.Steps) == 0 {
+		return nil, errors.New("rollout has no steps")
+	}
+	env, err := r.Maze.Environment()
+	if err != nil {
+		return nil, err
+	}
+	stride := max(opts.FrameStride, 1)
+	path := make([]Point, 0, len(r.Steps)+1)
+	path = append(path, env.Hero.Location)
+
+	frames := make([]*image.Paletted, 0, len(r.Steps)/stride+1)
+	for i := range r.Steps {
+		step := &r.Steps[i]
+		env.TimeStep = step.TimeStep
+		env.Hero.Location = step.Location
+		env.Hero.Heading = step.Heading
+		env.Hero.RangeFinders = step.RangeFinders
+		env.Hero.Radar = step.Radar
+		// the waypoints are visited along the path
+		env.testWaypointsVisitedByAgent()
+		path = append(path, step.Location)
+
+		if i%stride != 0 && i != len(r.Steps)-1 {
+			continue
+		}
+		dc, err := newRenderContext(env, opts.Scale)
+		if err != nil {
+			return nil, err
+		}
+		drawEnvironment(env, dc)
+		drawPath(path, dc)
+		drawSensors(&env.Hero, dc)
+		drawAgent(&env.Hero, dc)
+
+		img := dc.Image()
+		frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+		draw.Draw(frame, frame.Bounds(), img, img.Bounds().Min, draw.Src)
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// WriteRolloutGIF renders the recorded rollout as animated GIF into the writer
+func WriteRolloutGIF(w io.Writer, r *Rollout, opts AnimationOptions) error {
+	frames, err := RenderRolloutFrames(r, opts)
+	if err != nil {
+		return err
+	}
+	anim := &gif.GIF{
+		Image: frames,
+		Delay: make([]int, len(frames)),
+	}
+	for i := range frames {
+		anim.Delay[i] = opts.FrameDelay
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+// drawPath is to draw the path traveled by the agent
+func drawPath(path []Point, dc *gg.Context) {
+	if len(path) < 2 {
+		return
+	}
+	dc.Push()
+	defer dc.Pop()
+
+	dc.SetColor(color.RGBA{R: 51, G: 153, B: 255, A: 255})
+	dc.SetLineWidth(1.0)
+	dc.MoveTo(path[0].X, path[0].Y)
+	for _, p := range path[1:] {
+		dc.LineTo(p.X, p.Y)
+	}
+	dc.Stroke()
+}
+
+// drawSensors is to draw the range finder rays up to the sensed distances and the radar sectors of the agent. The
+// sectors of radar sensors pointing to the maze exit are highlighted.
+func drawSensors(hero *Agent, dc *gg.Context) {
+	dc.Push()
+	defer dc.Pop()
+
+	// draw radar sectors
+	radius := hero.Radius * 3
+	for i := range hero.RadarAngles1 {
+		if i >= len(hero.RadarAngles2) {
+			break
+		}
+		from := (hero.Heading + hero.RadarAngles1[i]) / 180.0 * math.Pi
+		to := (hero.Heading + hero.RadarAngles2[i]) / 180.0 * math.Pi
+		dc.MoveTo(hero.Location.X, hero.Location.Y)
+		dc.DrawArc(hero.Location.X, hero.Location.Y, radius, from, to)
+		dc.ClosePath()
+		if i < len(hero.Radar) && hero.Radar[i] > 0 {
+			dc.SetColor(color.NRGBA{R: 255, G: 51, A: 96})
+			dc.FillPreserve()
+		}
+		dc.SetColor(color.Gray{Y: 200})
+		dc.SetLineWidth(0.5)
+		dc.Stroke()
+	}
+
+	// draw range finder rays
+	dc.SetColor(color.RGBA{R: 255, G: 153, A: 255})
+	dc.SetLineWidth(1.0)
+	for i, angle := range hero.RangeFinderAngles {
+		if i >= len(hero.RangeFinders) {
+			break
+		}
+		rad := (hero.Heading + angle) / 180.0 * math.Pi
+		end := Point{
+			X: hero.Location.X + math.Cos(rad)*hero.RangeFinders[i],
+			Y: hero.Location.Y + math.Sin(rad)*hero.RangeFinders[i],
+		}
+		dc.DrawLine(hero.Location.X, hero.Location.Y, end.X, end.Y)
+		dc.Stroke()
+		dc.DrawCircle(end.X, end.Y, 1.5)
+		dc.Fill()
+	}
+}
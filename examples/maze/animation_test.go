@@ -0,0 +1,60 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"testing"
+)
+
+func TestRenderRolloutFrames(t *testing.T) {
+	env, org := createRolloutTestEnvironment(t), createRolloutTestOrganism(t)
+	rollout, err := RecordRollout(env, org)
+	require.NoError(t, err)
+	require.Len(t, rollout.Steps, env.TimeSteps)
+
+	opts := DefaultAnimationOptions()
+	opts.FrameStride = 10
+	opts.Scale = 0.5
+	frames, err := RenderRolloutFrames(rollout, opts)
+	require.NoError(t, err)
+	// each 10th step and the last one
+	require.Len(t, frames, 6)
+
+	img, err := RenderEnvironment(env, opts.Scale)
+	require.NoError(t, err)
+	for _, f := range frames {
+		assert.Equal(t, img.Bounds(), f.Bounds())
+		assert.Equal(t, color.Palette(palette.Plan9), f.Palette)
+	}
+	assert.NotEqual(t, frames[0], frames[len(frames)-1])
+
+	// all steps
+	opts.FrameStride = 0
+	frames, err = RenderRolloutFrames(rollout, opts)
+	require.NoError(t, err)
+	assert.Len(t, frames, len(rollout.Steps))
+
+	rollout.Steps = nil
+	_, err = RenderRolloutFrames(rollout, opts)
+	assert.Error(t, err)
+}
+
+func TestWriteRolloutGIF(t *testing.T) {
+	env, org := createRolloutTestEnvironment(t), createRolloutTestOrganism(t)
+	rollout, err := RecordRollout(env, org)
+	require.NoError(t, err)
+
+	opts := DefaultAnimationOptions()
+	opts.FrameStride = 25
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, WriteRolloutGIF(buf, rollout, opts))
+
+	anim, err := gif.DecodeAll(buf)
+	require.NoError(t, err)
+	require.Len(t, anim.Image, 3)
+	assert.Equal(t, []int{opts.FrameDelay, opts.FrameDelay, opts.FrameDelay}, anim.Delay)
+}
@@ -26,6 +26,10 @@ type mazeSimResults struct {
 	records *RecordStore
 	// The novelty archive
 	archive *neatns.NoveltyArchive
+	// The stream to append records of evaluated agents to, if records streaming is enabled
+	stream *RecordStreamWriter
+	// The lineage graph of evaluated agents
+	lineage *Lineage
 
 	// The current trial
 	trialID int
@@ -45,8 +49,9 @@ func histDiff(left, right []float64) float64 {
 }
 
 // To evaluate an individual organism within provided maze environment and to create corresponding novelty point.
-// If maze was solved during simulation the second returned parameter will be true.
-func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *AgentRecord, pathPoints []Point) (*neatns.NoveltyItem, bool, error) {
+// If maze was solved during simulation the second returned parameter will be true. The agent path points are appended
+// to the provided path if it's not nil.
+func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *AgentRecord, path *[]Point) (*neatns.NoveltyItem, bool, error) {
 	nItem := neatns.NewNoveltyItem()
 
 	// get Organism phenotype's network depth
@@ -85,8 +90,8 @@ func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *Ag
 		}
 
 		// store all path points if requested
-		if pathPoints != nil {
-			pathPoints[i] = orgEnv.Hero.Location
+		if path != nil {
+			*path = append(*path, orgEnv.Hero.Location)
 		}
 		steps++
 	}
@@ -95,11 +100,8 @@ func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *Ag
 		neat.InfoLog(fmt.Sprintf("Maze solved in: %d steps\n", steps))
 	}
 
-	// calculate fitness of an organism as closeness to target
-	fitness := orgEnv.AgentDistanceToExit()
-
-	// normalize fitness value in range (0;1] and store it
-	fitness = (env.initialDistance - fitness) / env.initialDistance
+	// calculate fitness of an organism as closeness to target normalized in range (0;1]
+	fitness := orgEnv.normalizedFitness()
 	if fitness <= 0 {
 		fitness = 0.01
 	}
@@ -109,6 +111,8 @@ func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *Ag
 	// store final agent coordinates as organism's novelty characteristics
 	nItem.Data = append(nItem.Data, orgEnv.Hero.Location.X)
 	nItem.Data = append(nItem.Data, orgEnv.Hero.Location.Y)
+	// store progress through the waypoints
+	nItem.Data = append(nItem.Data, waypointsNoveltyData(orgEnv)...)
 
 	if record != nil {
 		record.Fitness = fitness
@@ -120,9 +124,78 @@ func mazeSimulationEvaluate(env *Environment, org *genetics.Organism, record *Ag
 	return nItem, orgEnv.ExitFound, nil
 }
 
+// waypointsNoveltyData returns the agent's progress through the waypoints scaled by the size of the maze. Otherwise,
+// the progress values in range [0, 1] would be negligible in the novelty metric compared to the agent's coordinates.
+func waypointsNoveltyData(env *Environment) []float64 {
+	behavior := env.WaypointsBehavior()
+	if len(behavior) == 0 {
+		return behavior
+	}
+	minX, minY, maxX, maxY := wallsBounds(env.Lines)
+	size := math.Hypot(maxX-minX, maxY-minY)
+	for i := range behavior {
+		behavior[i] *= size
+	}
+	return behavior
+}
+
+// wallsBounds returns the bounding box of the maze walls
+func wallsBounds(lines []Line) (minX, minY, maxX, maxY float64) {
+	minX, minY = math.Inf(1), math.Inf(1)
+	maxX, maxY = math.Inf(-1), math.Inf(-1)
+	for _, l := range lines {
+		minX = math.Min(minX, math.Min(l.A.X, l.B.X))
+		minY = math.Min(minY, math.Min(l.A.Y, l.B.Y))
+		maxX = math.Max(maxX, math.Max(l.A.X, l.B.X))
+		maxY = math.Max(maxY, math.Max(l.A.Y, l.B.Y))
+	}
+	return minX, minY, maxX, maxY
+}
+
+// NoveltyItemPath returns the agent path points sampled into the novelty item data by simulation in the given maze
+// environment. The last point is the final location of the agent, and the sampled points are present only if the sample
+// size of the environment was less than the number of simulation time steps.
+func NoveltyItemPath(item *neatns.NoveltyItem, env *Environment) ([]Point, error) {
+	// the data holds sampled points, the final location, and the progress through the waypoints
+	size := len(item.Data) - len(env.Waypoints)
+	if size < 2 || size%2 != 0 {
+		return nil, fmt.Errorf("novelty item data size %d doesn't match maze with %d waypoints",
+			len(item.Data), len(env.Waypoints))
+	}
+	path := make([]Point, size/2)
+	for i := range path {
+		path[i] = Point{X: item.Data[i*2], Y: item.Data[i*2+1]}
+	}
+	return path, nil
+}
+
+// storeSolverPath is to run simulation of the organism that solved the maze to collect its path and to store it with
+// info about solver agent into the trial records
+func storeSolverPath(env *Environment, org *genetics.Organism, record *AgentRecord) error {
+	path := make([]Point, 0, env.TimeSteps)
+	if _, _, err := mazeSimulationEvaluate(env, org, nil, &path); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Solver's path simulation failed: %s\n", err))
+		return err
+	}
+	trialSim.records.SolverPathPoints = path
+	trialSim.records.Solvers = append(trialSim.records.Solvers, SolverRecord{
+		AgentID:     record.AgentID,
+		GenomeID:    org.Genotype.Id,
+		Generation:  record.Generation,
+		SpeciesID:   record.SpeciesID,
+		SpeciesAge:  record.SpeciesAge,
+		StepsToExit: len(path),
+		Path:        path,
+	})
+	return nil
+}
+
 // To initialize the maze simulation within provided environment copy and for given organism.
 // Returns new environment for simulation against given organism
 func mazeSimulationInit(env Environment, phenotype *network.Network, netDepth int) (*Environment, error) {
+	// reset the goals visited by previous organisms
+	env.resetGoals()
+
 	// flush the neural net
 	if _, err := phenotype.Flush(); err != nil {
 		neat.ErrorLog("Failed to flush phenotype")
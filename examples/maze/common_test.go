@@ -2,6 +2,7 @@ package maze
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/yaricom/goNEAT/v4/neat"
 	"testing"
 )
@@ -51,3 +52,72 @@ func TestCommon_histDiff(t *testing.T) {
 	// (3 + 1 + 1 + 3) / 4 = 2
 	assert.EqualValues(t, 2, diff)
 }
+
+func TestWaypointsNoveltyData(t *testing.T) {
+	env := &Environment{
+		Lines:            createBoxLines(30, 40),
+		Waypoints:        []Point{{X: 10, Y: 10}, {X: 20, Y: 20}},
+		VisitedWaypoints: []WaypointVisit{{Index: 1, TimeStep: 5}},
+		TimeSteps:        10,
+	}
+	// scaled by the diagonal of the maze
+	assert.Equal(t, []float64{50, 25}, waypointsNoveltyData(env))
+
+	env.Waypoints, env.VisitedWaypoints = nil, nil
+	assert.Empty(t, waypointsNoveltyData(env))
+}
+
+func TestCommon_storeSolverPath(t *testing.T) {
+	env, org := createRolloutTestEnvironment(t), createRolloutTestOrganism(t)
+	// extend the exit range to be reached by the agent in 20 steps
+	rollout, err := RecordRollout(env, org)
+	require.NoError(t, err)
+	env.ExitFoundRange = rollout.Steps[19].Location.Distance(env.MazeExit) + 0.1
+	rollout, err = RecordRollout(env, org)
+	require.NoError(t, err)
+	require.True(t, rollout.ExitFound)
+	steps := len(rollout.Steps)
+
+	trialSim = mazeSimResults{records: new(RecordStore)}
+	for i := 0; i < 2; i++ {
+		record := &AgentRecord{AgentID: i, Generation: i + 1, SpeciesID: 2, SpeciesAge: 3}
+		err = storeSolverPath(env, org, record)
+		require.NoError(t, err)
+	}
+
+	// all solvers are stored
+	require.Len(t, trialSim.records.Solvers, 2)
+	for i, s := range trialSim.records.Solvers {
+		assert.Equal(t, i, s.AgentID)
+		assert.Equal(t, i+1, s.Generation)
+		assert.Equal(t, org.Genotype.Id, s.GenomeID)
+		assert.Equal(t, 2, s.SpeciesID)
+		assert.Equal(t, 3, s.SpeciesAge)
+		assert.Equal(t, steps, s.StepsToExit)
+		require.Len(t, s.Path, steps)
+		assert.Equal(t, rollout.Steps[steps-1].Location, s.Path[steps-1])
+	}
+	// the path of the last solver is kept for compatibility
+	assert.Equal(t, trialSim.records.Solvers[1].Path, trialSim.records.SolverPathPoints)
+}
+
+func TestNoveltyItemPath(t *testing.T) {
+	env, org := createRolloutTestEnvironment(t), createRolloutTestOrganism(t)
+	env.SampleSize = 10
+	path := make([]Point, 0)
+	item, _, err := mazeSimulationEvaluate(env, org, nil, &path)
+	require.NoError(t, err)
+
+	points, err := NoveltyItemPath(item, env)
+	require.NoError(t, err)
+	require.Len(t, points, env.TimeSteps/env.SampleSize+1)
+	for i, p := range points[:len(points)-1] {
+		assert.Equal(t, path[i*env.SampleSize+env.TimeSteps%env.SampleSize], p, i)
+	}
+	assert.Equal(t, path[len(path)-1], points[len(points)-1], "the final location")
+
+	// the data doesn't match the maze
+	env.Waypoints = append(env.Waypoints, Point{})
+	_, err = NoveltyItemPath(item, env)
+	assert.Error(t, err)
+}
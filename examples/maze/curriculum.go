@@ -0,0 +1,196 @@
+package maze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"math"
+	"sort"
+	"strings"
+)
+
+// CurriculumCriterion defines when the population is moved on to the next maze of the curriculum
+type CurriculumCriterion int
+
+const (
+	// SolverFoundCriterion the population is moved on when the solver of the current maze is found
+	SolverFoundCriterion CurriculumCriterion = iota
+	// FitnessPercentileCriterion the population is moved on when the given percentile of the population fitness
+	// reaches the threshold or when the solver is found
+	FitnessPercentileCriterion
+)
+
+// CurriculumCriterionFromString parses curriculum criterion from its name
+func CurriculumCriterionFromString(name string) (CurriculumCriterion, error) {
+	switch strings.ToUpper(name) {
+	case "SOLVER":
+		return SolverFoundCriterion, nil
+	case "PERCENTILE":
+		return FitnessPercentileCriterion, nil
+	default:
+		return SolverFoundCriterion, fmt.Errorf("unsupported curriculum criterion: %s", name)
+	}
+}
+
+// Stringer
+func (c CurriculumCriterion) String() string {
+	switch c {
+	case SolverFoundCriterion:
+		return "SOLVER"
+	case FitnessPercentileCriterion:
+		return "PERCENTILE"
+	default:
+		return fmt.Sprintf("CurriculumCriterion(%d)", int(c))
+	}
+}
+
+// CurriculumOptions is the options of the curriculum learning across the sequence of mazes
+type CurriculumOptions struct {
+	// The criterion to move the population on to the next maze
+	Criterion CurriculumCriterion
+	// The percentile of the population fitness [0, 1] to be tested with FitnessPercentileCriterion
+	Percentile float64
+	// The fitness value to be reached by the percentile of the population fitness with FitnessPercentileCriterion
+	FitnessThreshold float64
+	// The flag to indicate whether the novelty archive should be carried over to the next maze or reset
+	CarryOverArchive bool
+}
+
+// CurriculumEvaluatorFactory is to create the maze experiment evaluator using provided maze environment
+type CurriculumEvaluatorFactory func(mazeEnv *Environment) (experiment.GenerationEvaluator, experiment.TrialRunObserver)
+
+// NewCurriculumEvaluator allows creating the evaluator, which runs the maze experiment across the ordered list of
+// mazes starting from the first one. When the success criterion is met within the current maze the population is moved
+// on to the next one, and the experiment is solved only when the solver of the last maze is found. The evaluator of the
+// maze experiment is created by the factory and is evaluating the population within the current maze of the curriculum.
+func NewCurriculumEvaluator(stages []*Environment, opts CurriculumOptions, factory CurriculumEvaluatorFactory) (experiment.GenerationEvaluator, experiment.TrialRunObserver, error) {
+	if len(stages) == 0 {
+		return nil, nil, errors.New("no mazes in the curriculum")
+	}
+	if opts.Criterion == FitnessPercentileCriterion && (opts.Percentile < 0 || opts.Percentile > 1) {
+		return nil, nil, fmt.Errorf("fitness percentile must be in range [0, 1], but was: %f", opts.Percentile)
+	}
+	evaluator := &curriculumEvaluator{
+		stages:  stages,
+		opts:    opts,
+		current: new(Environment),
+	}
+	*evaluator.current = *stages[0]
+	evaluator.evaluator, evaluator.observer = factory(evaluator.current)
+	return evaluator, evaluator, nil
+}
+
+// curriculumEvaluator the maze experiment evaluator moving the population through the sequence of mazes
+type curriculumEvaluator struct {
+	// The mazes of the curriculum in order of difficulty
+	stages []*Environment
+	// The curriculum options
+	opts CurriculumOptions
+
+	// The maze environment used by the wrapped evaluator, holds the copy of the current stage environment
+	current *Environment
+	// The index of the current stage
+	stage int
+
+	// The wrapped evaluator of maze experiment
+	evaluator experiment.GenerationEvaluator
+	// The wrapped trial observer of maze experiment
+	observer experiment.TrialRunObserver
+}
+
+func (e *curriculumEvaluator) TrialRunStarted(trial *experiment.Trial) {
+	// each trial starts from the first maze
+	e.setStage(0)
+	if e.observer != nil {
+		e.observer.TrialRunStarted(trial)
+	}
+}
+
+func (e *curriculumEvaluator) TrialRunFinished(trial *experiment.Trial) {
+	if e.observer != nil {
+		e.observer.TrialRunFinished(trial)
+	}
+}
+
+func (e *curriculumEvaluator) EpochEvaluated(trial *experiment.Trial, epoch *experiment.Generation) {
+	if e.observer != nil {
+		e.observer.EpochEvaluated(trial, epoch)
+	}
+}
+
+// GenerationEvaluate evaluates one epoch for given population within the current maze of the curriculum and moves
+// the population on to the next maze if success criterion is met.
+func (e *curriculumEvaluator) GenerationEvaluate(ctx context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
+	neat.InfoLog(fmt.Sprintf("Curriculum stage: %d of %d, maze: %s\n", e.stage+1, len(e.stages), e.stageName()))
+
+	if err := e.evaluator.GenerationEvaluate(ctx, pop, epoch); err != nil {
+		return err
+	}
+	if e.stage == len(e.stages)-1 || !e.criterionMet(pop, epoch) {
+		return nil
+	}
+
+	neat.InfoLog(fmt.Sprintf("Curriculum stage: %d completed at generation: %d, maze: %s\n",
+		e.stage+1, epoch.Id, e.stageName()))
+	// the experiment is solved only within the last maze
+	epoch.Solved = false
+	epoch.Champion = nil
+	epoch.WinnerNodes = 0
+	epoch.WinnerGenes = 0
+	epoch.WinnerEvals = 0
+
+	e.setStage(e.stage + 1)
+	if !e.opts.CarryOverArchive && trialSim.archive != nil {
+		trialSim.archive.Reset()
+	}
+	return nil
+}
+
+// criterionMet is to check whether the success criterion of the current stage is met by the evaluated population
+func (e *curriculumEvaluator) criterionMet(pop *genetics.Population, epoch *experiment.Generation) bool {
+	if epoch.Solved {
+		return true
+	}
+	if e.opts.Criterion == FitnessPercentileCriterion {
+		return fitnessPercentile(pop, e.opts.Percentile) >= e.opts.FitnessThreshold
+	}
+	return false
+}
+
+// setStage is to switch the environment used by the wrapped evaluator to the maze of the stage with given index
+func (e *curriculumEvaluator) setStage(stage int) {
+	e.stage = stage
+	*e.current = *e.stages[stage]
+}
+
+func (e *curriculumEvaluator) stageName() string {
+	if len(e.current.Name) > 0 {
+		return e.current.Name
+	}
+	return fmt.Sprintf("stage_%d", e.stage)
+}
+
+// fitnessPercentile returns the fitness value at given percentile [0, 1] of the population fitness distribution. The
+// objective fitness stored in the novelty item of organism is used if present, because the novelty search replaces
+// organism's fitness with its novelty.
+func fitnessPercentile(pop *genetics.Population, percentile float64) float64 {
+	if len(pop.Organisms) == 0 {
+		return 0
+	}
+	fitness := make([]float64, len(pop.Organisms))
+	for i, org := range pop.Organisms {
+		fitness[i] = org.Fitness
+		if org.Data != nil {
+			if item, ok := org.Data.Value.(*neatns.NoveltyItem); ok {
+				fitness[i] = item.Fitness
+			}
+		}
+	}
+	sort.Float64s(fitness)
+	index := int(math.Round(percentile * float64(len(fitness)-1)))
+	return fitness[index]
+}
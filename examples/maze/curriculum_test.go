@@ -0,0 +1,152 @@
+package maze
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"testing"
+)
+
+// stageEvaluator is the stub evaluator recording mazes it was evaluating
+type stageEvaluator struct {
+	mazeEnv *Environment
+	// the names of mazes per evaluated epoch
+	evaluated []string
+	// the flag to mark epochs as solved
+	solve bool
+}
+
+func (s *stageEvaluator) TrialRunStarted(trial *experiment.Trial) {
+	trialSim = mazeSimResults{
+		trialID: trial.Id,
+		records: new(RecordStore),
+		archive: neatns.NewNoveltyArchive(archiveThresh, NoveltyMetric, neatns.DefaultNoveltyArchiveOptions()),
+	}
+}
+
+func (s *stageEvaluator) TrialRunFinished(_ *experiment.Trial) {}
+
+func (s *stageEvaluator) EpochEvaluated(_ *experiment.Trial, _ *experiment.Generation) {}
+
+func (s *stageEvaluator) GenerationEvaluate(_ context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
+	s.evaluated = append(s.evaluated, s.mazeEnv.Name)
+	trialSim.archive.NovelItems = append(trialSim.archive.NovelItems, neatns.NewNoveltyItem())
+	if s.solve {
+		epoch.Solved = true
+		epoch.Champion = pop.Organisms[0]
+	}
+	return nil
+}
+
+func TestCurriculumCriterionFromString(t *testing.T) {
+	criterion, err := CurriculumCriterionFromString("PERCENTILE")
+	require.NoError(t, err)
+	assert.Equal(t, FitnessPercentileCriterion, criterion)
+	assert.Equal(t, "SOLVER", SolverFoundCriterion.String())
+	criterion, err = CurriculumCriterionFromString("solver")
+	require.NoError(t, err)
+	assert.Equal(t, SolverFoundCriterion, criterion)
+
+	_, err = CurriculumCriterionFromString("UNKNOWN")
+	assert.Error(t, err)
+}
+
+func TestCurriculumEvaluator_solverFound(t *testing.T) {
+	stages := []*Environment{{Name: "easy"}, {Name: "medium"}, {Name: "hard"}}
+	stub := &stageEvaluator{solve: true}
+	evaluator, observer, err := NewCurriculumEvaluator(stages, CurriculumOptions{}, createStageEvaluatorFactory(stub))
+	require.NoError(t, err)
+
+	pop := createCurriculumTestPopulation(0.5)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+	for i := 0; i < 2; i++ {
+		epoch := &experiment.Generation{Id: i}
+		err = evaluator.GenerationEvaluate(context.Background(), pop, epoch)
+		require.NoError(t, err)
+		assert.False(t, epoch.Solved, "only the last maze can be solved")
+		assert.Nil(t, epoch.Champion)
+		// the archive is reset when moving on
+		assert.Len(t, trialSim.archive.NovelItems, 0)
+	}
+	epoch := &experiment.Generation{Id: 2}
+	err = evaluator.GenerationEvaluate(context.Background(), pop, epoch)
+	require.NoError(t, err)
+	assert.True(t, epoch.Solved)
+	assert.Equal(t, []string{"easy", "medium", "hard"}, stub.evaluated)
+
+	// the new trial starts from the first maze
+	observer.TrialRunStarted(&experiment.Trial{Id: 2})
+	assert.Equal(t, "easy", stub.mazeEnv.Name)
+	// the curriculum mazes are not modified
+	assert.Equal(t, "easy", stages[0].Name)
+}
+
+func TestCurriculumEvaluator_fitnessPercentile(t *testing.T) {
+	stages := []*Environment{{Name: "easy"}, {Name: "hard"}}
+	stub := &stageEvaluator{}
+	opts := CurriculumOptions{
+		Criterion:        FitnessPercentileCriterion,
+		Percentile:       0.9,
+		FitnessThreshold: 0.8,
+		CarryOverArchive: true,
+	}
+	evaluator, observer, err := NewCurriculumEvaluator(stages, opts, createStageEvaluatorFactory(stub))
+	require.NoError(t, err)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+
+	// the percentile is below the threshold
+	err = evaluator.GenerationEvaluate(context.Background(), createCurriculumTestPopulation(0.5), &experiment.Generation{Id: 0})
+	require.NoError(t, err)
+	assert.Equal(t, "easy", stub.mazeEnv.Name)
+
+	// the percentile reached the threshold
+	err = evaluator.GenerationEvaluate(context.Background(), createCurriculumTestPopulation(0.9), &experiment.Generation{Id: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "hard", stub.mazeEnv.Name)
+	// the archive is carried over
+	assert.Len(t, trialSim.archive.NovelItems, 2)
+}
+
+func TestNewCurriculumEvaluator_errors(t *testing.T) {
+	stub := &stageEvaluator{}
+	_, _, err := NewCurriculumEvaluator(nil, CurriculumOptions{}, createStageEvaluatorFactory(stub))
+	assert.Error(t, err)
+
+	opts := CurriculumOptions{Criterion: FitnessPercentileCriterion, Percentile: 1.5}
+	_, _, err = NewCurriculumEvaluator([]*Environment{{}}, opts, createStageEvaluatorFactory(stub))
+	assert.Error(t, err)
+}
+
+func TestFitnessPercentile(t *testing.T) {
+	pop := &genetics.Population{}
+	for _, f := range []float64{0.5, 0.1, 0.3, 0.2, 0.4} {
+		org := &genetics.Organism{Fitness: 1}
+		item := neatns.NewNoveltyItem()
+		item.Fitness = f
+		org.Data = &genetics.OrganismData{Value: item}
+		pop.Organisms = append(pop.Organisms, org)
+	}
+	// the objective fitness from novelty items is used
+	assert.Equal(t, 0.1, fitnessPercentile(pop, 0))
+	assert.Equal(t, 0.3, fitnessPercentile(pop, 0.5))
+	assert.Equal(t, 0.5, fitnessPercentile(pop, 1))
+}
+
+func createStageEvaluatorFactory(stub *stageEvaluator) CurriculumEvaluatorFactory {
+	return func(mazeEnv *Environment) (experiment.GenerationEvaluator, experiment.TrialRunObserver) {
+		stub.mazeEnv = mazeEnv
+		return stub, stub
+	}
+}
+
+// createCurriculumTestPopulation creates population with fitness of organisms evenly spread up to maxFitness
+func createCurriculumTestPopulation(maxFitness float64) *genetics.Population {
+	pop := &genetics.Population{}
+	for i := 1; i <= 10; i++ {
+		pop.Organisms = append(pop.Organisms, &genetics.Organism{Fitness: maxFitness * float64(i) / 10})
+	}
+	return pop
+}
@@ -0,0 +1,315 @@
+package maze
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrTargetNotReachable is returned when there are no free cells of the distance field around the target point
+var ErrTargetNotReachable = errors.New("no free cells found around the target point")
+
+// DistanceMetric defines how the distance between maze agent and the maze exit is measured for fitness calculation
+type DistanceMetric int
+
+const (
+	// EuclideanDistance the straight line distance to the maze exit. This is the default metric which makes the maze
+	// deceptive.
+	EuclideanDistance DistanceMetric = iota
+	// GeodesicDistance the length of the shortest path to the maze exit avoiding maze walls.
+	GeodesicDistance
+)
+
+// DistanceMetricFromString returns distance metric with given name [EUCLIDEAN, GEODESIC]
+func DistanceMetricFromString(name string) (DistanceMetric, error) {
+	switch strings.ToUpper(name) {
+	case "EUCLIDEAN":
+		return EuclideanDistance, nil
+	case "GEODESIC":
+		return GeodesicDistance, nil
+	default:
+		return EuclideanDistance, fmt.Errorf("unsupported distance metric: %s", name)
+	}
+}
+
+// String returns the name of distance metric
+func (m DistanceMetric) String() string {
+	switch m {
+	case EuclideanDistance:
+		return "EUCLIDEAN"
+	case GeodesicDistance:
+		return "GEODESIC"
+	default:
+		return fmt.Sprintf("DistanceMetric(%d)", int(m))
+	}
+}
+
+// DistanceField is the precomputed field of path distances to the target point over the rasterized occupancy grid of
+// the maze. The grid cell is considered occupied if its center is closer to any maze wall than the clearance value.
+// The distances are found using Dijkstra search over 8-connected grid cells.
+type DistanceField struct {
+	// The target point to which distances are calculated
+	Target Point
+	// The size of the grid cell
+	CellSize float64
+	// The minimal distance from cell center to maze walls for the cell to be free
+	Clearance float64
+	// The coordinates of the top left corner of the grid
+	Origin Point
+	// The number of grid columns and rows
+	Cols, Rows int
+
+	// the maze walls
+	lines []Line
+	// the flag to indicate whether wall crossing test is needed to check connectivity of adjacent cells
+	checkCrossing bool
+	// the occupancy flags of grid cells
+	blocked []bool
+	// the path distances from grid cells to the target, +Inf for unreachable
+	distances []float64
+}
+
+// NewDistanceField creates new distance field to the target point for maze with given walls. The cellSize defines the
+// resolution of the occupancy grid and the clearance is the minimal distance from the free cell center to the maze walls.
+func NewDistanceField(lines []Line, target Point, cellSize, clearance float64) (*DistanceField, error) {
+	if cellSize <= 0 {
+		return nil, fmt.Errorf("cell size must be positive, but was: %f", cellSize)
+	}
+	minX, minY, maxX, maxY := target.X, target.Y, target.X, target.Y
+	for _, l := range lines {
+		minX = math.Min(minX, math.Min(l.A.X, l.B.X))
+		minY = math.Min(minY, math.Min(l.A.Y, l.B.Y))
+		maxX = math.Max(maxX, math.Max(l.A.X, l.B.X))
+		maxY = math.Max(maxY, math.Max(l.A.Y, l.B.Y))
+	}
+	field := &DistanceField{
+		Target:    target,
+		CellSize:  cellSize,
+		Clearance: clearance,
+		Origin:    Point{X: minX - cellSize, Y: minY - cellSize},
+		Cols:      int(math.Ceil((maxX-minX)/cellSize)) + 2,
+		Rows:      int(math.Ceil((maxY-minY)/cellSize)) + 2,
+		lines:     lines,
+		// the wall crossing test is needed only when clearance is too small to block cells around walls, i.e., when
+		// the crossed wall can be farther than clearance from both cell centers
+		checkCrossing: clearance <= cellSize*math.Sqrt2/2,
+	}
+	field.rasterize()
+	if err := field.propagate(); err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// Distance returns the path distance from given point to the target or +Inf if target is not reachable from it
+func (f *DistanceField) Distance(p Point) float64 {
+	col, row := f.cellIndex(p)
+	// look for the closest reachable cells around the point, expanding search to cover the clearance zone
+	maxRing := int(math.Ceil(f.Clearance/f.CellSize)) + 1
+	for ring := 1; ring <= maxRing; ring++ {
+		dist := math.Inf(1)
+		for r := row - ring; r <= row+ring; r++ {
+			for c := col - ring; c <= col+ring; c++ {
+				if d := f.CellDistance(c, r); !math.IsInf(d, 1) {
+					dist = math.Min(dist, d+p.Distance(f.CellCenter(c, r)))
+				}
+			}
+		}
+		if !math.IsInf(dist, 1) {
+			return dist
+		}
+	}
+	return math.Inf(1)
+}
+
+// IsReachable returns true if target is reachable from the given point
+func (f *DistanceField) IsReachable(p Point) bool {
+	return !math.IsInf(f.Distance(p), 1)
+}
+
+// IsFree returns true if grid cell at given column and row is not occupied by maze walls
+func (f *DistanceField) IsFree(col, row int) bool {
+	if !f.contains(col, row) {
+		return false
+	}
+	return !f.blocked[row*f.Cols+col]
+}
+
+// CellDistance returns the path distance from the center of grid cell to the target or +Inf if target is not
+// reachable from the cell or cell is outside the grid
+func (f *DistanceField) CellDistance(col, row int) float64 {
+	if !f.contains(col, row) {
+		return math.Inf(1)
+	}
+	return f.distances[row*f.Cols+col]
+}
+
+// CellCenter returns the center point of the grid cell
+func (f *DistanceField) CellCenter(col, row int) Point {
+	return Point{
+		X: f.Origin.X + (float64(col)+0.5)*f.CellSize,
+		Y: f.Origin.Y + (float64(row)+0.5)*f.CellSize,
+	}
+}
+
+// CellAt returns the column and row of the grid cell holding given point. The last returned value is false if point
+// is outside the grid.
+func (f *DistanceField) CellAt(p Point) (int, int, bool) {
+	col, row := f.cellIndex(p)
+	return col, row, f.contains(col, row)
+}
+
+func (f *DistanceField) cellIndex(p Point) (int, int) {
+	col := int(math.Floor((p.X - f.Origin.X) / f.CellSize))
+	row := int(math.Floor((p.Y - f.Origin.Y) / f.CellSize))
+	return col, row
+}
+
+func (f *DistanceField) contains(col, row int) bool {
+	return col >= 0 && col < f.Cols && row >= 0 && row < f.Rows
+}
+
+// Connected returns true if two adjacent grid cells are free and the agent can move between their centers
+func (f *DistanceField) Connected(col, row, nCol, nRow int) bool {
+	if !f.IsFree(col, row) || !f.IsFree(nCol, nRow) {
+		return false
+	}
+	if dc, dr := nCol-col, nRow-row; dc < -1 || dc > 1 || dr < -1 || dr > 1 {
+		return false
+	}
+	return !f.checkCrossing || !crossesWalls(Line{A: f.CellCenter(col, row), B: f.CellCenter(nCol, nRow)}, f.lines)
+}
+
+// rasterize is to build the occupancy grid
+func (f *DistanceField) rasterize() {
+	f.blocked = make([]bool, f.Cols*f.Rows)
+	for row := 0; row < f.Rows; row++ {
+		for col := 0; col < f.Cols; col++ {
+			center := f.CellCenter(col, row)
+			for _, l := range f.lines {
+				if l.Distance(center) < f.Clearance {
+					f.blocked[row*f.Cols+col] = true
+					break
+				}
+			}
+		}
+	}
+}
+
+// propagate is to find path distances from the target to all grid cells
+func (f *DistanceField) propagate() error {
+	f.distances = make([]float64, f.Cols*f.Rows)
+	for i := range f.distances {
+		f.distances[i] = math.Inf(1)
+	}
+
+	// seed search with free cells around the target
+	queue := &cellsQueue{}
+	col, row := f.cellIndex(f.Target)
+	maxRing := int(math.Ceil(f.Clearance/f.CellSize)) + 1
+	for ring := 0; ring <= maxRing && queue.Len() == 0; ring++ {
+		for r := row - ring; r <= row+ring; r++ {
+			for c := col - ring; c <= col+ring; c++ {
+				if !f.IsFree(c, r) {
+					continue
+				}
+				center := f.CellCenter(c, r)
+				if f.checkCrossing && crossesWalls(Line{A: f.Target, B: center}, f.lines) {
+					continue
+				}
+				idx := r*f.Cols + c
+				f.distances[idx] = f.Target.Distance(center)
+				heap.Push(queue, cellItem{index: idx, distance: f.distances[idx]})
+			}
+		}
+	}
+	if queue.Len() == 0 {
+		return ErrTargetNotReachable
+	}
+
+	// run Dijkstra search
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(cellItem)
+		if item.distance > f.distances[item.index] {
+			continue
+		}
+		c, r := item.index%f.Cols, item.index/f.Cols
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				nc, nr := c+dc, r+dr
+				if (dc == 0 && dr == 0) || !f.Connected(c, r, nc, nr) {
+					continue
+				}
+				nIdx := nr*f.Cols + nc
+				dist := item.distance + f.CellSize*math.Sqrt(float64(dc*dc+dr*dr))
+				if dist < f.distances[nIdx] {
+					f.distances[nIdx] = dist
+					heap.Push(queue, cellItem{index: nIdx, distance: dist})
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// crossesWalls returns true if given line segment intersects or touches any of the maze walls
+func crossesWalls(segment Line, lines []Line) bool {
+	for _, l := range lines {
+		if segmentsTouch(l, segment) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsTouch returns true if two line segments have at least one common point, including their end points
+func segmentsTouch(l1, l2 Line) bool {
+	d1 := orientation(l2.A, l2.B, l1.A)
+	d2 := orientation(l2.A, l2.B, l1.B)
+	d3 := orientation(l1.A, l1.B, l2.A)
+	d4 := orientation(l1.A, l1.B, l2.B)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	// check collinear cases when end point lies on another segment
+	return (d1 == 0 && onSegment(l2, l1.A)) || (d2 == 0 && onSegment(l2, l1.B)) ||
+		(d3 == 0 && onSegment(l1, l2.A)) || (d4 == 0 && onSegment(l1, l2.B))
+}
+
+// orientation returns the sign of cross product (b - a) x (c - a)
+func orientation(a, b, c Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// onSegment returns true if point collinear with line segment lies within its bounding box
+func onSegment(l Line, p Point) bool {
+	return math.Min(l.A.X, l.B.X) <= p.X && p.X <= math.Max(l.A.X, l.B.X) &&
+		math.Min(l.A.Y, l.B.Y) <= p.Y && p.Y <= math.Max(l.A.Y, l.B.Y)
+}
+
+// cellItem is the grid cell with tentative distance to be held in the priority queue
+type cellItem struct {
+	index    int
+	distance float64
+}
+
+// cellsQueue is the min-heap of grid cells ordered by distance
+type cellsQueue []cellItem
+
+func (q cellsQueue) Len() int           { return len(q) }
+func (q cellsQueue) Less(i, j int) bool { return q[i].distance < q[j].distance }
+func (q cellsQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *cellsQueue) Push(x interface{}) {
+	*q = append(*q, x.(cellItem))
+}
+
+func (q *cellsQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,132 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"os"
+	"testing"
+)
+
+func TestDistanceMetricFromString(t *testing.T) {
+	metrics := []DistanceMetric{EuclideanDistance, GeodesicDistance}
+	for _, metric := range metrics {
+		parsed, err := DistanceMetricFromString(metric.String())
+		require.NoError(t, err)
+		assert.Equal(t, metric, parsed)
+	}
+
+	_, err := DistanceMetricFromString("unknown")
+	assert.Error(t, err)
+}
+
+func TestNewDistanceField(t *testing.T) {
+	lines := createBoxLines(100, 100)
+	target := Point{X: 90, Y: 50}
+
+	field, err := NewDistanceField(lines, target, 2, 0)
+	require.NoError(t, err)
+
+	// in open space path distance is close to the Euclidean one
+	from := Point{X: 10, Y: 50}
+	assert.InDelta(t, 80.0, field.Distance(from), 3.0)
+	assert.True(t, field.IsReachable(from))
+
+	// outside the box
+	assert.False(t, field.IsReachable(Point{X: 200, Y: 200}))
+	assert.True(t, math.IsInf(field.Distance(Point{X: 200, Y: 200}), 1))
+}
+
+func TestNewDistanceField_wall(t *testing.T) {
+	// the wall in the middle of the box with the passage at the bottom
+	lines := append(createBoxLines(100, 100), Line{A: Point{X: 50, Y: 0}, B: Point{X: 50, Y: 80}})
+	target := Point{X: 90, Y: 10}
+	from := Point{X: 10, Y: 10}
+
+	field, err := NewDistanceField(lines, target, 1, 0)
+	require.NoError(t, err)
+
+	// the path goes around the wall, grid distance is greater than the exact one
+	expected := 2 * math.Sqrt(40*40+70*70)
+	assert.InDelta(t, expected, field.Distance(from), expected*0.1)
+	assert.Greater(t, field.Distance(from), from.Distance(target))
+
+	// close the passage with clearance
+	field, err = NewDistanceField(lines, target, 1, 11)
+	require.NoError(t, err)
+	assert.False(t, field.IsReachable(from))
+}
+
+func TestNewDistanceField_errors(t *testing.T) {
+	lines := createBoxLines(100, 100)
+	_, err := NewDistanceField(lines, Point{X: 50, Y: 50}, 0, 0)
+	assert.Error(t, err, "cell size must be positive")
+
+	_, err = NewDistanceField(lines, Point{X: 50, Y: 50}, 1, 100)
+	assert.ErrorIs(t, err, ErrTargetNotReachable)
+}
+
+func TestDistanceField_cells(t *testing.T) {
+	lines := createBoxLines(10, 10)
+	field, err := NewDistanceField(lines, Point{X: 5, Y: 5}, 1, 0.6)
+	require.NoError(t, err)
+
+	assert.Equal(t, Point{X: -1, Y: -1}, field.Origin)
+	assert.Equal(t, 12, field.Cols)
+	assert.Equal(t, 12, field.Rows)
+
+	col, row, ok := field.CellAt(Point{X: 5.5, Y: 2.2})
+	require.True(t, ok)
+	assert.Equal(t, 6, col)
+	assert.Equal(t, 3, row)
+	assert.Equal(t, Point{X: 5.5, Y: 2.5}, field.CellCenter(col, row))
+	assert.True(t, field.IsFree(col, row))
+
+	// the cell at the wall
+	assert.False(t, field.IsFree(1, 3))
+	assert.True(t, math.IsInf(field.CellDistance(1, 3), 1))
+
+	_, _, ok = field.CellAt(Point{X: 20, Y: 2})
+	assert.False(t, ok)
+}
+
+func TestEnvironment_SetFitnessMetric(t *testing.T) {
+	mazes := []string{"../../data/medium_maze.txt", "../../data/hard_maze.txt"}
+	for _, mazePath := range mazes {
+		t.Run(mazePath, func(t *testing.T) {
+			mazeFile, err := os.Open(mazePath)
+			require.NoError(t, err, "failed to open maze file")
+			env, err := ReadEnvironment(mazeFile)
+			require.NoError(t, err, "failed to read environment")
+
+			euclidean := env.initialDistance
+			assert.Equal(t, env.AgentDistanceToExit(), env.AgentPathDistanceToExit())
+
+			err = env.SetFitnessMetric(GeodesicDistance, 2)
+			require.NoError(t, err)
+			assert.Equal(t, GeodesicDistance, env.FitnessMetric)
+			assert.Greater(t, env.initialDistance, euclidean)
+			assert.Equal(t, env.AgentPathDistanceToExit(), env.fitnessDistanceToExit())
+
+			// the metric is kept when the environment is initialized again
+			geodesic := env.initialDistance
+			require.NoError(t, env.initialize())
+			assert.Equal(t, geodesic, env.initialDistance)
+
+			err = env.SetFitnessMetric(EuclideanDistance, 2)
+			require.NoError(t, err)
+			assert.Equal(t, euclidean, env.initialDistance)
+			assert.Nil(t, env.distanceField)
+		})
+	}
+}
+
+// creates the lines of the box with given width and height
+func createBoxLines(width, height float64) []Line {
+	return []Line{
+		{A: Point{X: 0, Y: 0}, B: Point{X: width, Y: 0}},
+		{A: Point{X: width, Y: 0}, B: Point{X: width, Y: height}},
+		{A: Point{X: width, Y: height}, B: Point{X: 0, Y: height}},
+		{A: Point{X: 0, Y: height}, B: Point{X: 0, Y: 0}},
+	}
+}
@@ -6,15 +6,63 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strconv"
 	"strings"
 )
 
 // ErrOutputIsNaN to be returned if one of the network output values is NaN
 var ErrOutputIsNaN = errors.New("OUTPUT is NAN")
 
-// The maximal allowed speed for maze agent
+// The default maximal allowed speed and angular velocity for maze agent
 const maxAgentSpeed = 3.0
 
+// The motion model to be used when environment has no motion model set
+var defaultMotion MotionModel = NewAccelerationMotion()
+
+// The fraction of speed retained by the agent after bouncing back from the wall
+const bounceRestitution = 0.5
+
+// CollisionMode defines how the maze agent responds to collisions with maze walls
+type CollisionMode int
+
+const (
+	// CollisionStop the agent stays at its current location when collision detected. This is the default mode
+	// used to produce the published results.
+	CollisionStop CollisionMode = iota
+	// CollisionSlide the agent slides along the wall, i.e., its motion is projected onto the wall tangent
+	CollisionSlide
+	// CollisionBounce the agent bounces back from the wall with reversed and damped speed
+	CollisionBounce
+)
+
+// CollisionModeFromString returns collision mode with given name [STOP, SLIDE, BOUNCE]
+func CollisionModeFromString(name string) (CollisionMode, error) {
+	switch strings.ToUpper(name) {
+	case "STOP":
+		return CollisionStop, nil
+	case "SLIDE":
+		return CollisionSlide, nil
+	case "BOUNCE":
+		return CollisionBounce, nil
+	default:
+		return CollisionStop, fmt.Errorf("unsupported collision mode: %s", name)
+	}
+}
+
+// String returns the name of collision mode
+func (m CollisionMode) String() string {
+	switch m {
+	case CollisionStop:
+		return "STOP"
+	case CollisionSlide:
+		return "SLIDE"
+	case CollisionBounce:
+		return "BOUNCE"
+	default:
+		return fmt.Sprintf("CollisionMode(%d)", int(m))
+	}
+}
+
 // Point the simple point class
 type Point struct {
 	X, Y float64
@@ -142,6 +190,22 @@ func (l Line) Distance(p Point) float64 {
 	return point.Distance(p)
 }
 
+// ClosestPoint is to find the point on the line segment which is closest to the given point
+func (l Line) ClosestPoint(p Point) Point {
+	dx, dy := l.B.X-l.A.X, l.B.Y-l.A.Y
+	lenSq := dx*dx + dy*dy
+	if lenSq == 0.0 {
+		return l.A
+	}
+	u := ((p.X-l.A.X)*dx + (p.Y-l.A.Y)*dy) / lenSq
+	if u < 0 {
+		u = 0
+	} else if u > 1 {
+		u = 1
+	}
+	return Point{X: l.A.X + u*dx, Y: l.A.Y + u*dy}
+}
+
 // Length calculates the line segment length
 func (l Line) Length() float64 {
 	return l.A.Distance(l.B)
@@ -173,6 +237,13 @@ type Agent struct {
 	Radar []float64
 	// stores rangefinder outputs
 	RangeFinders []float64
+	// stores outputs of radar sensors pointing to the waypoints, one channel per waypoint
+	WaypointRadar [][]float64
+
+	// The flag to indicate whether agent was in contact with maze walls during the last time step
+	Collided bool
+	// The number of time steps during which agent was in contact with maze walls
+	CollisionsCount int
 }
 
 // NewAgent creates new Agent with default settings
@@ -200,15 +271,27 @@ func NewAgent() Agent {
 
 // Environment the maze environment definition
 type Environment struct {
+	// The name of the maze
+	Name string
 	// The maze navigating agent
 	Hero Agent
 	// The maze line segments
 	Lines []Line
+	// The moving maze obstacles
+	Obstacles []Obstacle
 	// The maze exit - goal
 	MazeExit Point
+	// The intermediate goals to be visited by the agent before the maze exit
+	Waypoints []Point
+	// The flag to indicate whether waypoints must be visited in the order of definition
+	OrderedWaypoints bool
 
 	// The flag to indicate if exit was found
 	ExitFound bool
+	// The waypoints visited by the agent in order of visiting
+	VisitedWaypoints []WaypointVisit
+	// The number of simulation time steps performed
+	TimeStep int
 
 	// The number of time steps to be executed during maze solving simulation
 	TimeSteps int
@@ -218,8 +301,30 @@ type Environment struct {
 	// The range around maze exit point to test if agent coordinates is within to be considered as solved successfully (5.0 is good enough)
 	ExitFoundRange float64
 
+	// The collision response mode of the agent. The CollisionStop is the default.
+	Collision CollisionMode
+	// The motion model of the agent. If not set the AccelerationMotion with default limits is used.
+	Motion MotionModel
+
+	// The metric to measure agent's distance to the maze exit for fitness calculation. The EuclideanDistance is the default.
+	FitnessMetric DistanceMetric
+
 	// The initial distance of agent from exit
 	initialDistance float64
+	// The location of the last reached goal or the start location of the agent
+	lastGoalLocation Point
+	// The other agents in the maze represented as disc obstacles
+	peers []Obstacle
+	// The field of path distances to the maze exit, used by GeodesicDistance metric
+	distanceField *DistanceField
+}
+
+// WaypointVisit is the record about waypoint visited by the agent
+type WaypointVisit struct {
+	// The index of the waypoint
+	Index int
+	// The time step when waypoint was visited
+	TimeStep int
 }
 
 // ReadEnvironment reads maze environment from the reader
@@ -284,23 +389,80 @@ func ReadEnvironment(ir io.Reader) (*Environment, error) {
 		return nil, errors.New(fmt.Sprintf("Expected: %d maze lines, but was read only: %d", numLines, len(env.Lines)))
 	}
 
-	// update sensors
-	err := env.updateRangefinders()
-	if err != nil {
+	if err := env.initialize(); err != nil {
 		return nil, err
 	}
-	env.updateRadar()
+	return &env, nil
+}
 
-	// find initial distance
-	env.initialDistance = env.AgentDistanceToExit()
+// WriteEnvironment writes maze environment to the writer using the same text format as used by ReadEnvironment
+func WriteEnvironment(w io.Writer, env *Environment) error {
+	if len(env.Waypoints) > 0 {
+		return errors.New("waypoints are not supported by the legacy maze format")
+	}
+	if len(env.Obstacles) > 0 {
+		return errors.New("obstacles are not supported by the legacy maze format")
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = fmt.Fprintf(bw, "# Number of maze walls, including boundaries\n%d\n", len(env.Lines))
+	_, _ = fmt.Fprintf(bw, "# The initial position of the agent\n%s %s\n",
+		formatFloat(env.Hero.Location.X), formatFloat(env.Hero.Location.Y))
+	_, _ = fmt.Fprintf(bw, "# The initial heading angle of the agent\n%s\n", formatFloat(env.Hero.Heading))
+	_, _ = fmt.Fprintf(bw, "# The maze exit position\n%s %s\n",
+		formatFloat(env.MazeExit.X), formatFloat(env.MazeExit.Y))
+	_, _ = fmt.Fprint(bw, "\n# Maze walls\n")
+	for _, l := range env.Lines {
+		_, _ = fmt.Fprintf(bw, "%s %s %s %s\n",
+			formatFloat(l.A.X), formatFloat(l.A.Y), formatFloat(l.B.X), formatFloat(l.B.Y))
+	}
+	return bw.Flush()
+}
 
-	return &env, err
+// initialize is to update agent's sensors and to find its initial distance from the maze exit
+func (e *Environment) initialize() error {
+	// update sensors
+	if err := e.updateRangefinders(); err != nil {
+		return err
+	}
+	e.resetGoals()
+	e.updateRadar()
+
+	// find initial distance according to the fitness metric
+	e.initialDistance = e.fitnessDistanceToExit()
+
+	return nil
+}
+
+// Clone returns the copy of the environment with its own agent's sensors and state of the visited goals. The maze
+// walls and obstacles are shared with the original environment, because they are not modified by the simulation.
+func (e *Environment) Clone() *Environment {
+	clone := *e
+	clone.Hero.RangeFinders = append([]float64{}, e.Hero.RangeFinders...)
+	clone.Hero.Radar = append([]float64{}, e.Hero.Radar...)
+	clone.Hero.WaypointRadar = nil
+	for _, channel := range e.Hero.WaypointRadar {
+		clone.Hero.WaypointRadar = append(clone.Hero.WaypointRadar, append([]float64{}, channel...))
+	}
+	clone.VisitedWaypoints = append([]WaypointVisit{}, e.VisitedWaypoints...)
+	return &clone
 }
 
-// GetInputs create neural net inputs from maze agent sensors
+// formatFloat formats float value using the minimal number of digits necessary to represent it
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// resetGoals is to reset the state of goals visited by the agent
+func (e *Environment) resetGoals() {
+	e.ExitFound = false
+	e.VisitedWaypoints = make([]WaypointVisit, 0, len(e.Waypoints))
+	e.lastGoalLocation = e.Hero.Location
+}
+
+// GetInputs create neural net inputs from maze agent sensors. The radar channels of waypoints are appended to the end.
 func (e *Environment) GetInputs() ([]float64, error) {
 	inputsSize := len(e.Hero.RangeFinders) + len(e.Hero.Radar) + 1
-	inputs := make([]float64, inputsSize)
+	inputs := make([]float64, inputsSize, inputsSize+len(e.Hero.WaypointRadar)*len(e.Hero.Radar))
 	// bias
 	inputs[0] = 1.0
 
@@ -321,31 +483,25 @@ func (e *Environment) GetInputs() ([]float64, error) {
 		}
 	}
 
+	// waypoints radar
+	for _, channel := range e.Hero.WaypointRadar {
+		inputs = append(inputs, channel...)
+	}
+
 	return inputs, nil
 }
 
-// ApplyOutputs transform neural net outputs into angular velocity and speed
+// ApplyOutputs transform neural net outputs into angular velocity and speed using motion model of the environment
 func (e *Environment) ApplyOutputs(o1, o2 float64) error {
 	if math.IsNaN(o1) || math.IsNaN(o2) {
 		return ErrOutputIsNaN
 	}
 
-	e.Hero.AngularVelocity += o1 - 0.5
-	e.Hero.Speed += o2 - 0.5
-
-	// constraints of speed & angular velocity
-	if e.Hero.Speed > maxAgentSpeed {
-		e.Hero.Speed = maxAgentSpeed
-	}
-	if e.Hero.Speed < -maxAgentSpeed {
-		e.Hero.Speed = -maxAgentSpeed
-	}
-	if e.Hero.AngularVelocity > maxAgentSpeed {
-		e.Hero.AngularVelocity = maxAgentSpeed
-	}
-	if e.Hero.AngularVelocity < -maxAgentSpeed {
-		e.Hero.AngularVelocity = -maxAgentSpeed
+	motion := e.Motion
+	if motion == nil {
+		motion = defaultMotion
 	}
+	motion.Apply(&e.Hero, o1, o2)
 
 	return nil
 }
@@ -355,6 +511,7 @@ func (e *Environment) Update() error {
 	if e.ExitFound {
 		return nil
 	}
+	e.TimeStep++
 
 	// get horizontal and vertical velocity components
 	vx := math.Cos(e.Hero.Heading/180.0*math.Pi) * e.Hero.Speed
@@ -385,37 +542,169 @@ func (e *Environment) Update() error {
 		X: vx + e.Hero.Location.X,
 		Y: vy + e.Hero.Location.Y,
 	}
-	if !e.testAgentCollision(newLoc) {
+	e.Hero.Collided = e.testAgentCollision(newLoc)
+	if !e.Hero.Collided {
 		e.Hero.Location.X = newLoc.X
 		e.Hero.Location.Y = newLoc.Y
+	} else {
+		e.Hero.CollisionsCount++
+		e.resolveCollision(vx, vy)
 	}
+	// Test if update agent's position solved the maze or visited waypoints before updating radar pointing to them
+	e.ExitFound = e.testExitFoundByAgent()
+
 	err := e.updateRangefinders()
 	if err != nil {
 		return err
 	}
 	e.updateRadar()
 
-	// Test if update agent's position solved the maze
-	e.ExitFound = e.testExitFoundByAgent()
-
 	return nil
 }
 
-// testExitFoundByAgent is to test if agent location is within maze exit range
+// testExitFoundByAgent is to test if agent location is within maze exit range after visiting all waypoints
 func (e *Environment) testExitFoundByAgent() bool {
 	if e.ExitFound {
 		return true
 	}
+	e.testWaypointsVisitedByAgent()
+	if len(e.VisitedWaypoints) < len(e.Waypoints) {
+		return false
+	}
 
 	dist := e.AgentDistanceToExit()
 	return dist < e.ExitFoundRange
 }
 
+// testWaypointsVisitedByAgent is to test if agent location is within range of the waypoints which can be visited next
+func (e *Environment) testWaypointsVisitedByAgent() {
+	for i, w := range e.Waypoints {
+		if e.IsWaypointVisited(i) || e.Hero.Location.Distance(w) >= e.ExitFoundRange {
+			continue
+		}
+		if e.OrderedWaypoints && i != len(e.VisitedWaypoints) {
+			continue
+		}
+		e.VisitedWaypoints = append(e.VisitedWaypoints, WaypointVisit{Index: i, TimeStep: e.TimeStep})
+		e.lastGoalLocation = w
+	}
+}
+
+// IsWaypointVisited is to check whether waypoint with given index was visited by the agent
+func (e *Environment) IsWaypointVisited(index int) bool {
+	for _, v := range e.VisitedWaypoints {
+		if v.Index == index {
+			return true
+		}
+	}
+	return false
+}
+
+// NextGoal returns the goal to be visited next: the next waypoint in order for ordered waypoints, the closest not
+// visited waypoint otherwise, or the maze exit if all waypoints visited.
+func (e *Environment) NextGoal() Point {
+	if len(e.VisitedWaypoints) >= len(e.Waypoints) {
+		return e.MazeExit
+	}
+	if e.OrderedWaypoints {
+		return e.Waypoints[len(e.VisitedWaypoints)]
+	}
+	next, minDistance := e.MazeExit, math.Inf(1)
+	for i, w := range e.Waypoints {
+		if d := e.Hero.Location.Distance(w); !e.IsWaypointVisited(i) && d < minDistance {
+			next, minDistance = w, d
+		}
+	}
+	return next
+}
+
+// ProgressFitness returns agent's progress through the sequence of goals in range [0, 1]. Each visited goal adds the
+// equal share and the share of the next goal is proportional to how closer agent moved to it from the last goal.
+func (e *Environment) ProgressFitness() float64 {
+	if e.ExitFound {
+		return 1
+	}
+	next := e.NextGoal()
+	closeness := 0.0
+	if span := e.lastGoalLocation.Distance(next); span > 0 {
+		closeness = math.Max(0, 1-e.Hero.Location.Distance(next)/span)
+	}
+	return (float64(len(e.VisitedWaypoints)) + closeness) / float64(len(e.Waypoints)+1)
+}
+
+// WaypointsBehavior returns the behavior characteristic of agent's progress through the waypoints: for each waypoint
+// the time step when it was visited normalized by the number of simulation time steps, or one if it was not visited.
+func (e *Environment) WaypointsBehavior() []float64 {
+	behavior := make([]float64, len(e.Waypoints))
+	for i := range behavior {
+		behavior[i] = 1
+	}
+	if e.TimeSteps > 0 {
+		for _, v := range e.VisitedWaypoints {
+			behavior[v.Index] = math.Min(float64(v.TimeStep)/float64(e.TimeSteps), 1)
+		}
+	}
+	return behavior
+}
+
 // AgentDistanceToExit used for fitness calculations based on distance of maze Agent to the target maze exit
 func (e *Environment) AgentDistanceToExit() float64 {
 	return e.Hero.Location.Distance(e.MazeExit)
 }
 
+// AgentPathDistanceToExit returns the length of the shortest path from agent to the maze exit avoiding maze walls.
+// The geodesic fitness must be enabled with SetFitnessMetric before, otherwise Euclidean distance is returned.
+func (e *Environment) AgentPathDistanceToExit() float64 {
+	if e.distanceField == nil {
+		return e.AgentDistanceToExit()
+	}
+	return e.distanceField.Distance(e.Hero.Location)
+}
+
+// SetFitnessMetric is to set the metric to measure agent's distance to the maze exit for fitness calculation. For
+// GeodesicDistance metric the field of path distances is precomputed over the maze grid with given cell size.
+func (e *Environment) SetFitnessMetric(metric DistanceMetric, cellSize float64) error {
+	switch metric {
+	case EuclideanDistance:
+		e.distanceField = nil
+	case GeodesicDistance:
+		// leave enough room for the agent to approach walls which are closer than radius to the cell center
+		clearance := math.Max(e.Hero.Radius-cellSize, 0)
+		field, err := NewDistanceField(e.Lines, e.MazeExit, cellSize, clearance)
+		if err != nil {
+			return err
+		}
+		e.distanceField = field
+	default:
+		return fmt.Errorf("unsupported distance metric: %s", metric)
+	}
+	e.FitnessMetric = metric
+
+	// update initial distance according to the new metric
+	e.initialDistance = e.fitnessDistanceToExit()
+	if math.IsInf(e.initialDistance, 1) {
+		return errors.New("maze exit is not reachable from the agent's initial location")
+	}
+	return nil
+}
+
+// normalizedFitness returns agent's fitness normalized to the range (-inf, 1]: the progress through the waypoints if
+// defined, or the relative closeness to the maze exit otherwise
+func (e *Environment) normalizedFitness() float64 {
+	if len(e.Waypoints) > 0 {
+		return e.ProgressFitness()
+	}
+	return (e.initialDistance - e.fitnessDistanceToExit()) / e.initialDistance
+}
+
+// fitnessDistanceToExit returns agent's distance to the maze exit according to the fitness metric of the environment
+func (e *Environment) fitnessDistanceToExit() float64 {
+	if e.FitnessMetric == GeodesicDistance {
+		return e.AgentPathDistanceToExit()
+	}
+	return e.AgentDistanceToExit()
+}
+
 // update rangefinder sensors
 func (e *Environment) updateRangefinders() error {
 	// iterate through each sensor and find distance to maze lines with agent's range finder sensors
@@ -453,6 +742,15 @@ func (e *Environment) updateRangefinders() error {
 				}
 			}
 		}
+		for _, obstacles := range [][]Obstacle{e.Obstacles, e.peers} {
+			for j := range obstacles {
+				if found, intersection := obstacles[j].Intersection(projectionLine, e.TimeStep); found {
+					if foundRange := intersection.Distance(e.Hero.Location); foundRange < minRange {
+						minRange = foundRange
+					}
+				}
+			}
+		}
 
 		if math.IsNaN(minRange) {
 			return errors.New("RANGE is NAN")
@@ -462,10 +760,29 @@ func (e *Environment) updateRangefinders() error {
 	return nil
 }
 
-// updateRadar is to update radar sensors
+// updateRadar is to update radar sensors pointing to the maze exit and to the not visited waypoints
 func (e *Environment) updateRadar() {
-	target := e.MazeExit
+	e.fireRadar(e.MazeExit, e.Hero.Radar)
+
+	if len(e.Hero.WaypointRadar) != len(e.Waypoints) {
+		e.Hero.WaypointRadar = make([][]float64, len(e.Waypoints))
+		for i := range e.Hero.WaypointRadar {
+			e.Hero.WaypointRadar[i] = make([]float64, len(e.Hero.RadarAngles1))
+		}
+	}
+	for i, w := range e.Waypoints {
+		if e.IsWaypointVisited(i) {
+			for j := range e.Hero.WaypointRadar[i] {
+				e.Hero.WaypointRadar[i][j] = 0
+			}
+		} else {
+			e.fireRadar(w, e.Hero.WaypointRadar[i])
+		}
+	}
+}
 
+// fireRadar is to fire the radar sensors pointing to the target
+func (e *Environment) fireRadar(target Point, radar []float64) {
 	// rotate goal with respect to heading of agent to compensate agent's heading angle relative to zero heading angle
 	target.Rotate(-e.Hero.Heading, e.Hero.Location)
 
@@ -479,11 +796,11 @@ func (e *Environment) updateRadar() {
 
 	// fire the appropriate radar sensor
 	for i := 0; i < len(e.Hero.RadarAngles1); i++ {
-		e.Hero.Radar[i] = 0.0
+		radar[i] = 0.0
 
 		if (angle >= e.Hero.RadarAngles1[i] && angle < e.Hero.RadarAngles2[i]) ||
 			(angle+360.0 >= e.Hero.RadarAngles1[i] && angle+360.0 < e.Hero.RadarAngles2[i]) {
-			e.Hero.Radar[i] = 1.0
+			radar[i] = 1.0
 		}
 	}
 }
@@ -495,18 +812,109 @@ func (e *Environment) testAgentCollision(loc Point) bool {
 			return true
 		}
 	}
+	for _, obstacles := range [][]Obstacle{e.Obstacles, e.peers} {
+		for j := range obstacles {
+			if obstacles[j].Distance(loc, e.TimeStep) < e.Hero.Radius {
+				return true
+			}
+		}
+	}
 	return false
 }
 
+// resolveCollision is to move agent according to the collision mode when its motion with velocity components (vx, vy)
+// results in collision with maze walls
+func (e *Environment) resolveCollision(vx, vy float64) {
+	var newLoc Point
+	switch e.Collision {
+	case CollisionSlide:
+		closest, found := e.closestObstaclePoint(e.Hero.Location)
+		if !found {
+			return
+		}
+		// find the wall normal pointing towards the agent
+		nx, ny := e.Hero.Location.X-closest.X, e.Hero.Location.Y-closest.Y
+		norm := math.Sqrt(nx*nx + ny*ny)
+		if norm == 0 {
+			return
+		}
+		nx, ny = nx/norm, ny/norm
+		// remove the velocity component directed into the wall
+		if dot := vx*nx + vy*ny; dot < 0 {
+			vx -= dot * nx
+			vy -= dot * ny
+		}
+		newLoc = Point{X: e.Hero.Location.X + vx, Y: e.Hero.Location.Y + vy}
+
+	case CollisionBounce:
+		// push agent back along its motion direction and reverse the speed
+		newLoc = Point{
+			X: e.Hero.Location.X - vx*bounceRestitution,
+			Y: e.Hero.Location.Y - vy*bounceRestitution,
+		}
+		e.Hero.Speed = -e.Hero.Speed * bounceRestitution
+
+	default:
+		// agent stays at its current location
+		return
+	}
+
+	if !e.testAgentCollision(newLoc) {
+		e.Hero.Location = newLoc
+	}
+}
+
+// closestObstaclePoint is to find the point of maze walls or obstacles closest to the given location
+func (e *Environment) closestObstaclePoint(loc Point) (Point, bool) {
+	var closest Point
+	found := false
+	minDist := math.MaxFloat64
+	for j := 0; j < len(e.Lines); j++ {
+		if d := e.Lines[j].Distance(loc); d < minDist {
+			minDist = d
+			closest, found = e.Lines[j].ClosestPoint(loc), true
+		}
+	}
+	for _, obstacles := range [][]Obstacle{e.Obstacles, e.peers} {
+		for j := range obstacles {
+			if d := obstacles[j].Distance(loc, e.TimeStep); d < minDist {
+				minDist = d
+				closest, found = obstacles[j].ClosestPoint(loc, e.TimeStep), true
+			}
+		}
+	}
+	return closest, found
+}
+
 // Stringer
 func (e *Environment) String() string {
-	str := fmt.Sprintf("MAZE\nHero at: %.1f, %.1f\n", e.Hero.Location.X, e.Hero.Location.Y)
+	str := fmt.Sprintf("MAZE %s\nHero at: %.1f, %.1f\n", e.Name, e.Hero.Location.X, e.Hero.Location.Y)
 	str += fmt.Sprintf("Exit at: %.1f, %.1f\n", e.MazeExit.X, e.MazeExit.Y)
+	if len(e.Waypoints) > 0 {
+		str += fmt.Sprintf("Waypoints (ordered: %t):\n", e.OrderedWaypoints)
+		for _, w := range e.Waypoints {
+			str += fmt.Sprintf("\t%.1f, %.1f\n", w.X, w.Y)
+		}
+	}
 	str += fmt.Sprintf("Initial distance from exit: %f, # of simulation steps: %d, path sampling size: %d \n",
 		e.initialDistance, e.TimeSteps, e.SampleSize)
+	str += fmt.Sprintf("Collision mode: %s, fitness distance metric: %s\n", e.Collision, e.FitnessMetric)
 	str += "Lines:\n"
 	for _, l := range e.Lines {
 		str += fmt.Sprintf("\t[%.1f, %.1f] -> [%.1f, %.1f]\n", l.A.X, l.A.Y, l.B.X, l.B.Y)
 	}
+	if len(e.Obstacles) > 0 {
+		str += "Obstacles:\n"
+		for _, o := range e.Obstacles {
+			if o.Shape == DiscObstacle {
+				str += fmt.Sprintf("\t%s [%.1f, %.1f] R: %.1f", o.Shape, o.Center.X, o.Center.Y, o.Radius)
+			} else {
+				str += fmt.Sprintf("\t%s [%.1f, %.1f] -> [%.1f, %.1f]", o.Shape, o.Segment.A.X, o.Segment.A.Y,
+					o.Segment.B.X, o.Segment.B.Y)
+			}
+			str += fmt.Sprintf(", motion: %s by [%.1f, %.1f] in %d steps\n", o.Motion, o.Displacement.X,
+				o.Displacement.Y, o.Period)
+		}
+	}
 	return str
 }
@@ -1,6 +1,7 @@
 package maze
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"math"
@@ -197,3 +198,195 @@ func TestReadEnvironment(t *testing.T) {
 	}
 	assert.ElementsMatch(t, lines, env.Lines)
 }
+
+func TestLine_ClosestPoint(t *testing.T) {
+	l := Line{
+		A: Point{1.0, 1.0},
+		B: Point{5.0, 1.0},
+	}
+
+	assert.Equal(t, Point{4.0, 1.0}, l.ClosestPoint(Point{4.0, 3.0}))
+	assert.Equal(t, Point{1.0, 1.0}, l.ClosestPoint(Point{-1.0, 3.0}))
+	assert.Equal(t, Point{5.0, 1.0}, l.ClosestPoint(Point{7.0, -3.0}))
+}
+
+func TestCollisionModeFromString(t *testing.T) {
+	modes := []CollisionMode{CollisionStop, CollisionSlide, CollisionBounce}
+	for _, mode := range modes {
+		parsed, err := CollisionModeFromString(mode.String())
+		require.NoError(t, err)
+		assert.Equal(t, mode, parsed)
+	}
+
+	_, err := CollisionModeFromString("unknown")
+	assert.Error(t, err)
+}
+
+func TestEnvironment_Update_collision(t *testing.T) {
+	cases := []struct {
+		mode          CollisionMode
+		expectedX     float64
+		expectedY     float64
+		expectedSpeed float64
+	}{
+		// agent stays at the same place
+		{mode: CollisionStop, expectedX: 50, expectedY: 50, expectedSpeed: 4},
+		// agent slides along the wall, i.e., only vertical component of motion preserved
+		{mode: CollisionSlide, expectedX: 50, expectedY: 50 + 4*math.Sin(math.Pi/4), expectedSpeed: 4},
+		// agent pushed back with reversed speed
+		{mode: CollisionBounce, expectedX: 50 - 2*math.Cos(math.Pi/4), expectedY: 50 - 2*math.Sin(math.Pi/4), expectedSpeed: -2},
+	}
+	for _, c := range cases {
+		t.Run(c.mode.String(), func(t *testing.T) {
+			env := createCollisionTestEnvironment(c.mode)
+
+			err := env.Update()
+			require.NoError(t, err)
+
+			assert.True(t, env.Hero.Collided, "collision expected")
+			assert.Equal(t, 1, env.Hero.CollisionsCount)
+			assert.InDelta(t, c.expectedX, env.Hero.Location.X, 1e-9)
+			assert.InDelta(t, c.expectedY, env.Hero.Location.Y, 1e-9)
+			assert.Equal(t, c.expectedSpeed, env.Hero.Speed)
+		})
+	}
+}
+
+func TestEnvironment_Update_noCollision(t *testing.T) {
+	env := createCollisionTestEnvironment(CollisionSlide)
+	env.Hero.Heading = 180
+
+	err := env.Update()
+	require.NoError(t, err)
+
+	assert.False(t, env.Hero.Collided, "no collision expected")
+	assert.Equal(t, 0, env.Hero.CollisionsCount)
+	assert.InDelta(t, 46.0, env.Hero.Location.X, 1e-9)
+	assert.InDelta(t, 50.0, env.Hero.Location.Y, 1e-9)
+}
+
+func TestEnvironment_Update_waypoints(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, false)
+
+	for i := 0; i < 6; i++ {
+		assert.False(t, env.ExitFound, "exit found too early at step: %d", i)
+		err := env.Update()
+		require.NoError(t, err)
+	}
+	assert.True(t, env.ExitFound)
+	assert.Equal(t, []WaypointVisit{{Index: 1, TimeStep: 2}, {Index: 0, TimeStep: 4}}, env.VisitedWaypoints)
+	assert.Equal(t, []float64{0.4, 0.2}, env.WaypointsBehavior())
+	assert.Equal(t, 1.0, env.ProgressFitness())
+}
+
+func TestEnvironment_Update_orderedWaypoints(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, true)
+
+	for i := 0; i < 6; i++ {
+		err := env.Update()
+		require.NoError(t, err)
+	}
+	// the second waypoint was passed before the first one and the exit can not be reached without it
+	assert.False(t, env.ExitFound)
+	assert.Equal(t, []WaypointVisit{{Index: 0, TimeStep: 4}}, env.VisitedWaypoints)
+	assert.True(t, env.IsWaypointVisited(0))
+	assert.False(t, env.IsWaypointVisited(1))
+	assert.Equal(t, Point{20, 0}, env.NextGoal())
+	assert.Equal(t, []float64{0.4, 1}, env.WaypointsBehavior())
+	assert.InDelta(t, 1.0/3.0, env.ProgressFitness(), 1e-9)
+}
+
+func TestEnvironment_ProgressFitness(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, false)
+	assert.Equal(t, Point{20, 0}, env.NextGoal())
+	assert.Equal(t, 0.0, env.ProgressFitness())
+
+	err := env.Update()
+	require.NoError(t, err)
+	// halfway to the closest waypoint
+	assert.InDelta(t, 0.5/3.0, env.ProgressFitness(), 1e-9)
+
+	err = env.Update()
+	require.NoError(t, err)
+	assert.Equal(t, Point{40, 0}, env.NextGoal())
+	assert.InDelta(t, 1.0/3.0, env.ProgressFitness(), 1e-9)
+}
+
+func TestEnvironment_Clone(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, false)
+	for i := 0; i < 2; i++ {
+		err := env.Update()
+		require.NoError(t, err)
+	}
+
+	clone := env.Clone()
+	assert.Equal(t, env, clone)
+
+	// the simulation of the clone does not affect the original environment
+	for i := 0; i < 4; i++ {
+		err := clone.Update()
+		require.NoError(t, err)
+	}
+	assert.True(t, clone.ExitFound)
+	assert.False(t, env.ExitFound)
+	assert.Len(t, env.VisitedWaypoints, 1)
+	assert.NotSame(t, &env.Hero.Radar[0], &clone.Hero.Radar[0])
+	assert.NotEqual(t, env.Hero.WaypointRadar, clone.Hero.WaypointRadar)
+}
+
+func TestEnvironment_GetInputs_waypoints(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, false)
+
+	inputs, err := env.GetInputs()
+	require.NoError(t, err)
+	require.Len(t, inputs, 1+len(env.Hero.RangeFinders)+len(env.Hero.Radar)*3)
+	// both waypoints are straight ahead
+	assert.Equal(t, []float64{1, 0, 0, 0, 1, 0, 0, 0}, inputs[11:])
+
+	for i := 0; i < 2; i++ {
+		err = env.Update()
+		require.NoError(t, err)
+	}
+	inputs, err = env.GetInputs()
+	require.NoError(t, err)
+	// the radar channel of visited waypoint is off
+	assert.Equal(t, []float64{1, 0, 0, 0, 0, 0, 0, 0}, inputs[11:])
+}
+
+func TestWriteEnvironment_waypoints(t *testing.T) {
+	env := createWaypointsTestEnvironment(t, false)
+	err := WriteEnvironment(bytes.NewBuffer(nil), env)
+	assert.Error(t, err)
+}
+
+// creates environment without walls with agent moving along X axis by 10 units per time step through the waypoints
+// at 40 and 20 to the exit at 60
+func createWaypointsTestEnvironment(t *testing.T, ordered bool) *Environment {
+	env := &Environment{
+		Hero:             NewAgent(),
+		MazeExit:         Point{60, 0},
+		Waypoints:        []Point{{40, 0}, {20, 0}},
+		OrderedWaypoints: ordered,
+		TimeSteps:        10,
+		ExitFoundRange:   5,
+	}
+	env.Hero.Speed = 10
+	err := env.initialize()
+	require.NoError(t, err)
+	return env
+}
+
+// creates environment with agent moving at 45 degrees towards vertical wall located at the distance of 10 units
+func createCollisionTestEnvironment(mode CollisionMode) *Environment {
+	env := &Environment{
+		Hero:           NewAgent(),
+		Lines:          []Line{{A: Point{60, 0}, B: Point{60, 200}}},
+		MazeExit:       Point{150, 150},
+		ExitFoundRange: 5,
+		Collision:      mode,
+	}
+	env.Hero.Location = Point{50, 50}
+	env.Hero.Heading = 45
+	env.Hero.Speed = 4
+	return env
+}
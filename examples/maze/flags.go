@@ -0,0 +1,13 @@
+package maze
+
+import "flag"
+
+// ExplicitFlags returns the names of flags of parsed flag set which were explicitly set in the command line. The
+// explicitly set flags are to override the values from the maze configuration file.
+func ExplicitFlags(fs *flag.FlagSet) map[string]bool {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
@@ -0,0 +1,20 @@
+package maze
+
+import (
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestExplicitFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("timesteps", 400, "")
+	fs.Float64("exit_range", 5.0, "")
+	require.NoError(t, fs.Parse([]string{"-exit_range", "5"}))
+
+	explicit := ExplicitFlags(fs)
+	assert.Len(t, explicit, 1)
+	assert.True(t, explicit["exit_range"], "set to default value explicitly")
+	assert.False(t, explicit["timesteps"])
+}
@@ -0,0 +1,322 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// The maximal number of attempts to sample random start location of the agent
+const maxStartSamplingAttempts = 10000
+
+// GeneralizationOptions is the options of the generalization test of the maze solving controller
+type GeneralizationOptions struct {
+	// The number of random start poses to test in each maze per seed in addition to the start pose of the maze
+	RandomStarts int
+	// The maximal distance of random start locations from the start location of the maze, zero to sample them over
+	// the whole maze
+	StartRadius float64
+	// The flag to indicate whether random start poses should have random headings
+	RandomHeading bool
+	// The seeds of the random number generator to sample start poses and noise. Each seed produces separate set of trials.
+	Seeds []int64
+	// The standard deviation of the Gaussian noise added to the sensor inputs of the controller
+	SensorNoise float64
+	// The standard deviation of the Gaussian noise added to the outputs of the controller
+	ActuatorNoise float64
+	// The cell size of the grid to find start locations from which the maze exit is reachable
+	CellSize float64
+}
+
+// DefaultGeneralizationOptions returns default generalization test options: ten random start poses with random
+// headings over the whole maze for one seed without noise
+func DefaultGeneralizationOptions() GeneralizationOptions {
+	return GeneralizationOptions{
+		RandomStarts:  10,
+		RandomHeading: true,
+		Seeds:         []int64{1},
+		CellSize:      2,
+	}
+}
+
+// GeneralizationTrial is the result of one simulation run of the controller in the maze
+type GeneralizationTrial struct {
+	// The seed of random number generator used
+	Seed int64 `json:"seed"`
+	// The start location of the agent
+	Start Point `json:"start"`
+	// The start heading of the agent in degrees
+	Heading float64 `json:"heading"`
+	// The flag to indicate whether the agent found the maze exit
+	Solved bool `json:"solved"`
+	// The distance from the agent to the maze exit at the end of simulation relative to the initial distance, both
+	// measured with the fitness metric of the maze
+	NormalizedDistance float64 `json:"normalized_distance"`
+	// The number of time steps taken to find the maze exit, zero if exit was not found or agent started at the exit
+	TimeToExit int `json:"time_to_exit"`
+}
+
+// GeneralizationSummary is the aggregated results of the generalization trials
+type GeneralizationSummary struct {
+	// The number of trials
+	Trials int `json:"trials"`
+	// The number of trials where the maze exit was found
+	Solved int `json:"solved"`
+	// The fraction of trials where the maze exit was found
+	SuccessRate float64 `json:"success_rate"`
+	// The mean normalized distance to the maze exit at the end of simulation
+	MeanNormalizedDistance float64 `json:"mean_normalized_distance"`
+	// The mean number of time steps to find the maze exit over solved trials
+	MeanTimeToExit float64 `json:"mean_time_to_exit"`
+}
+
+// MazeGeneralization is the results of the generalization test within one maze
+type MazeGeneralization struct {
+	// The name of the maze
+	Name string `json:"name"`
+	// The aggregated results of trials
+	GeneralizationSummary
+	// The results of individual trials
+	Trials []GeneralizationTrial `json:"trial_results"`
+}
+
+// GeneralizationReport is the report of the generalization test of the maze solving controller
+type GeneralizationReport struct {
+	// The results per maze
+	Mazes []MazeGeneralization `json:"mazes"`
+	// The aggregated results over all mazes
+	Total GeneralizationSummary `json:"total"`
+}
+
+// EvaluateGenomeGeneralization is to evaluate the generalization of the maze solving controller produced by the genome
+// across provided mazes. See EvaluateGeneralization for details.
+func EvaluateGenomeGeneralization(genome *genetics.Genome, mazes []*Environment, opts GeneralizationOptions) (*GeneralizationReport, error) {
+	phenotype, err := genome.Genesis(genome.Id)
+	if err != nil {
+		return nil, err
+	}
+	return EvaluateGeneralization(phenotype, mazes, opts)
+}
+
+// EvaluateGeneralization is to evaluate the generalization of the maze solving controller across provided mazes. For
+// each maze and seed the controller is tested from the start pose of the maze and from the number of random start
+// poses, from which the maze exit is reachable. The sensor and actuator noise is seeded by the same seed.
+func EvaluateGeneralization(phenotype *network.Network, mazes []*Environment, opts GeneralizationOptions) (*GeneralizationReport, error) {
+	if len(mazes) == 0 {
+		return nil, errors.New("no mazes to test")
+	}
+	seeds := opts.Seeds
+	if len(seeds) == 0 {
+		seeds = []int64{1}
+	}
+	netDepth, err := phenotype.MaxActivationDepthWithCap(1)
+	if err != nil {
+		netDepth = 1
+	}
+
+	report := &GeneralizationReport{Mazes: make([]MazeGeneralization, len(mazes))}
+	allTrials := make([]GeneralizationTrial, 0)
+	for i, env := range mazes {
+		var field *DistanceField
+		if opts.RandomStarts > 0 {
+			if field, err = NewDistanceField(env.Lines, env.MazeExit, opts.CellSize, env.Hero.Radius); err != nil {
+				return nil, err
+			}
+		}
+		trials := make([]GeneralizationTrial, 0, len(seeds)*(opts.RandomStarts+1))
+		for _, seed := range seeds {
+			rng := rand.New(rand.NewSource(seed))
+			for s := 0; s <= opts.RandomStarts; s++ {
+				start, heading := env.Hero.Location, env.Hero.Heading
+				if s > 0 {
+					if start, heading, err = sampleStartPose(env, field, rng, opts); err != nil {
+						return nil, err
+					}
+				}
+				trial, err := runGeneralizationTrial(env, phenotype, netDepth, start, heading, rng, opts)
+				if err != nil {
+					return nil, err
+				}
+				trial.Seed = seed
+				trials = append(trials, *trial)
+			}
+		}
+		name := env.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("maze_%d", i)
+		}
+		report.Mazes[i] = MazeGeneralization{
+			Name:                  name,
+			GeneralizationSummary: summarizeTrials(trials),
+			Trials:                trials,
+		}
+		allTrials = append(allTrials, trials...)
+	}
+	report.Total = summarizeTrials(allTrials)
+	return report, nil
+}
+
+// sampleStartPose is to sample random start pose of the agent, which is not colliding with maze walls, is not within
+// the maze exit range and from which the maze exit is reachable
+func sampleStartPose(env *Environment, field *DistanceField, rng *rand.Rand, opts GeneralizationOptions) (Point, float64, error) {
+	heading := env.Hero.Heading
+	if opts.RandomHeading {
+		heading = rng.Float64() * 360
+	}
+	for attempt := 0; attempt < maxStartSamplingAttempts; attempt++ {
+		var p Point
+		if opts.StartRadius > 0 {
+			r := opts.StartRadius * math.Sqrt(rng.Float64())
+			a := rng.Float64() * 2 * math.Pi
+			p = Point{X: env.Hero.Location.X + r*math.Cos(a), Y: env.Hero.Location.Y + r*math.Sin(a)}
+		} else {
+			p = Point{
+				X: field.Origin.X + rng.Float64()*float64(field.Cols)*field.CellSize,
+				Y: field.Origin.Y + rng.Float64()*float64(field.Rows)*field.CellSize,
+			}
+		}
+		if p.Distance(env.MazeExit) > env.ExitFoundRange && !env.testAgentCollision(p) && field.IsReachable(p) {
+			return p, heading, nil
+		}
+	}
+	return Point{}, 0, fmt.Errorf("failed to sample start location after %d attempts", maxStartSamplingAttempts)
+}
+
+// runGeneralizationTrial is to run simulation of the controller within the copy of maze environment from given start
+// pose
+func runGeneralizationTrial(env *Environment, phenotype *network.Network, netDepth int, start Point, heading float64,
+	rng *rand.Rand, opts GeneralizationOptions) (*GeneralizationTrial, error) {
+	// the trial must have its own sensors
+	trialEnv := env.Clone()
+	trialEnv.Hero.Location = start
+	trialEnv.Hero.Heading = heading
+	trialEnv.TimeStep = 0
+	if err := trialEnv.initialize(); err != nil {
+		return nil, err
+	}
+	if _, err := phenotype.Flush(); err != nil {
+		return nil, err
+	}
+
+	trial := &GeneralizationTrial{Start: start, Heading: heading}
+	if err := trialEnv.Update(); err != nil {
+		return nil, err
+	}
+	if err := activateNoisyAgent(trialEnv, phenotype, netDepth, rng, opts.SensorNoise); err != nil {
+		return nil, err
+	}
+	// the number of simulation steps taken, the initial update of the environment is not counted
+	steps := 0
+	for ; steps < trialEnv.TimeSteps && !trialEnv.ExitFound; steps++ {
+		if err := activateNoisyAgent(trialEnv, phenotype, netDepth, rng, opts.SensorNoise); err != nil {
+			return nil, err
+		}
+		o1, o2 := phenotype.Outputs[0].Activation, phenotype.Outputs[1].Activation
+		if opts.ActuatorNoise > 0 {
+			o1 = math.Min(math.Max(o1+rng.NormFloat64()*opts.ActuatorNoise, 0), 1)
+			o2 = math.Min(math.Max(o2+rng.NormFloat64()*opts.ActuatorNoise, 0), 1)
+		}
+		if err := trialEnv.ApplyOutputs(o1, o2); err != nil {
+			if errors.Is(err, ErrOutputIsNaN) {
+				// corrupted controller fails the trial
+				break
+			}
+			return nil, err
+		}
+		if err := trialEnv.Update(); err != nil {
+			return nil, err
+		}
+	}
+
+	trial.Solved = trialEnv.ExitFound
+	if trial.Solved {
+		trial.TimeToExit = steps
+	}
+	if trialEnv.initialDistance > 0 {
+		trial.NormalizedDistance = trialEnv.fitnessDistanceToExit() / trialEnv.initialDistance
+	}
+	return trial, nil
+}
+
+// activateNoisyAgent is to load agent's sensors with added Gaussian noise into the phenotype and to activate it.
+// The bias input is kept intact.
+func activateNoisyAgent(env *Environment, phenotype *network.Network, netDepth int, rng *rand.Rand, noise float64) error {
+	inputs, err := env.GetInputs()
+	if err != nil {
+		return err
+	}
+	if noise > 0 {
+		for i := 1; i < len(inputs); i++ {
+			inputs[i] += rng.NormFloat64() * noise
+		}
+	}
+	if err = phenotype.LoadSensors(inputs); err != nil {
+		return err
+	}
+	if _, err = phenotype.ForwardSteps(netDepth); err != nil && !errors.Is(err, network.ErrNetExceededMaxActivationAttempts) {
+		return err
+	}
+	return nil
+}
+
+// summarizeTrials is to aggregate results of the generalization trials
+func summarizeTrials(trials []GeneralizationTrial) GeneralizationSummary {
+	summary := GeneralizationSummary{Trials: len(trials)}
+	if len(trials) == 0 {
+		return summary
+	}
+	timeToExit := 0
+	for _, t := range trials {
+		summary.MeanNormalizedDistance += t.NormalizedDistance
+		if t.Solved {
+			summary.Solved++
+			timeToExit += t.TimeToExit
+		}
+	}
+	summary.SuccessRate = float64(summary.Solved) / float64(summary.Trials)
+	summary.MeanNormalizedDistance /= float64(summary.Trials)
+	if summary.Solved > 0 {
+		summary.MeanTimeToExit = float64(timeToExit) / float64(summary.Solved)
+	}
+	return summary
+}
+
+// WriteJSON writes report to the provided writer as JSON
+func (r *GeneralizationReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Stringer
+func (r *GeneralizationReport) String() string {
+	nameWidth := len("TOTAL")
+	for _, m := range r.Mazes {
+		if len(m.Name) > nameWidth {
+			nameWidth = len(m.Name)
+		}
+	}
+	sb := strings.Builder{}
+	sb.WriteString("GENERALIZATION REPORT\n")
+	sb.WriteString(fmt.Sprintf("%-*s %7s %7s %8s %9s %13s\n",
+		nameWidth, "Maze", "Trials", "Solved", "Success", "Distance", "Time to exit"))
+	row := func(name string, s GeneralizationSummary) {
+		timeToExit := "-"
+		if s.Solved > 0 {
+			timeToExit = fmt.Sprintf("%.1f", s.MeanTimeToExit)
+		}
+		sb.WriteString(fmt.Sprintf("%-*s %7d %7d %7.1f%% %9.3f %13s\n",
+			nameWidth, name, s.Trials, s.Solved, s.SuccessRate*100, s.MeanNormalizedDistance, timeToExit))
+	}
+	for _, m := range r.Mazes {
+		row(m.Name, m.GeneralizationSummary)
+	}
+	row("TOTAL", r.Total)
+	return sb.String()
+}
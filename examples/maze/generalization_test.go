@@ -0,0 +1,167 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateGenomeGeneralization(t *testing.T) {
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+
+	mazes := make([]*Environment, 0)
+	for _, path := range []string{"../../data/medium_maze.txt", "../../data/hard_maze.txt"} {
+		env, err := ReadEnvironmentFromFile(path)
+		require.NoError(t, err)
+		env.TimeSteps = 50
+		env.ExitFoundRange = 5
+		mazes = append(mazes, env)
+	}
+
+	opts := DefaultGeneralizationOptions()
+	opts.RandomStarts = 3
+	opts.Seeds = []int64{1, 2}
+	opts.SensorNoise = 0.05
+	opts.ActuatorNoise = 0.05
+	report, err := EvaluateGenomeGeneralization(genome, mazes, opts)
+	require.NoError(t, err)
+	require.Len(t, report.Mazes, 2)
+	assert.Equal(t, "medium_maze", report.Mazes[0].Name)
+	assert.Equal(t, 16, report.Total.Trials)
+
+	for i, m := range report.Mazes {
+		require.Len(t, m.Trials, 8)
+		field, err := NewDistanceField(mazes[i].Lines, mazes[i].MazeExit, opts.CellSize, mazes[i].Hero.Radius)
+		require.NoError(t, err)
+		for j, trial := range m.Trials {
+			if j%4 == 0 {
+				// the first trial per seed is from the start pose of the maze
+				assert.Equal(t, mazes[i].Hero.Location, trial.Start)
+			} else {
+				assert.True(t, field.IsReachable(trial.Start), "exit is not reachable from: %v", trial.Start)
+				assert.False(t, mazes[i].testAgentCollision(trial.Start))
+			}
+			assert.True(t, trial.NormalizedDistance >= 0)
+		}
+	}
+
+	// the results are reproducible with the same seeds
+	again, err := EvaluateGenomeGeneralization(genome, mazes, opts)
+	require.NoError(t, err)
+	assert.Equal(t, report, again)
+}
+
+func TestEvaluateGeneralization_solved(t *testing.T) {
+	env := &Environment{
+		Hero:           NewAgent(),
+		MazeExit:       Point{X: 3, Y: 0},
+		ExitFoundRange: 5,
+		TimeSteps:      10,
+		Lines:          []Line{NewLine(Point{X: -20, Y: -20}, Point{X: 20, Y: -20})},
+	}
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+
+	opts := GeneralizationOptions{Seeds: []int64{1, 2, 3}}
+	report, err := EvaluateGenomeGeneralization(genome, []*Environment{env}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "maze_0", report.Mazes[0].Name)
+	assert.Equal(t, GeneralizationSummary{
+		Trials:                 3,
+		Solved:                 3,
+		SuccessRate:            1,
+		MeanNormalizedDistance: 1,
+		MeanTimeToExit:         0,
+	}, report.Total)
+}
+
+func TestEvaluateGeneralization_timeToExit(t *testing.T) {
+	// the seed genome moves the agent along the known trajectory, which reaches the exit in 13 steps
+	env := &Environment{
+		Hero:           NewAgent(),
+		MazeExit:       Point{X: -30, Y: 8},
+		ExitFoundRange: 2,
+		TimeSteps:      40,
+		Lines:          []Line{NewLine(Point{X: -20, Y: -20}, Point{X: 20, Y: -20})},
+	}
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+
+	report, err := EvaluateGenomeGeneralization(genome, []*Environment{env}, GeneralizationOptions{Seeds: []int64{1}})
+	require.NoError(t, err)
+	trial := report.Mazes[0].Trials[0]
+	require.True(t, trial.Solved)
+	assert.Equal(t, 13, trial.TimeToExit)
+}
+
+func TestEvaluateGeneralization_geodesic(t *testing.T) {
+	// the wall between the start and the exit makes the path much longer than the straight line
+	env := &Environment{
+		Hero:           NewAgent(),
+		MazeExit:       Point{X: 80, Y: 20},
+		ExitFoundRange: 5,
+		TimeSteps:      1,
+		Lines:          append(createBoxLines(100, 100), Line{A: Point{X: 50, Y: 0}, B: Point{X: 50, Y: 80}}),
+	}
+	env.Hero.Location = Point{X: 20, Y: 20}
+	require.NoError(t, env.SetFitnessMetric(GeodesicDistance, 2))
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+
+	report, err := EvaluateGenomeGeneralization(genome, []*Environment{env}, GeneralizationOptions{Seeds: []int64{1}})
+	require.NoError(t, err)
+	// the agent barely moves, thus the normalized path distance stays close to one
+	assert.InDelta(t, 1, report.Total.MeanNormalizedDistance, 0.1)
+}
+
+func TestEvaluateGeneralization_noMazes(t *testing.T) {
+	_, err := EvaluateGeneralization(nil, nil, DefaultGeneralizationOptions())
+	assert.Error(t, err)
+}
+
+func TestGeneralizationReport_output(t *testing.T) {
+	trials := []GeneralizationTrial{
+		{Seed: 1, Solved: true, NormalizedDistance: 0.1, TimeToExit: 100},
+		{Seed: 1, Solved: true, NormalizedDistance: 0.1, TimeToExit: 200},
+		{Seed: 1, Solved: false, NormalizedDistance: 0.4},
+		{Seed: 1, Solved: false, NormalizedDistance: 0.6},
+	}
+	summary := summarizeTrials(trials)
+	assert.Equal(t, 4, summary.Trials)
+	assert.Equal(t, 2, summary.Solved)
+	assert.Equal(t, 0.5, summary.SuccessRate)
+	assert.InDelta(t, 0.3, summary.MeanNormalizedDistance, 1e-9)
+	assert.Equal(t, 150.0, summary.MeanTimeToExit)
+
+	report := &GeneralizationReport{
+		Mazes: []MazeGeneralization{{Name: "test_maze", GeneralizationSummary: summary, Trials: trials}},
+		Total: summary,
+	}
+	str := report.String()
+	assert.Contains(t, str, "test_maze")
+	assert.Contains(t, str, "TOTAL")
+	assert.Contains(t, str, "50.0%")
+	assert.Len(t, strings.Split(strings.TrimSpace(str), "\n"), 4)
+
+	var buf bytes.Buffer
+	err := report.WriteJSON(&buf)
+	require.NoError(t, err)
+	var decoded GeneralizationReport
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, *report, decoded)
+	assert.Contains(t, buf.String(), `"success_rate": 0.5`)
+}
@@ -0,0 +1,431 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// MazeAlgorithm is the algorithm to generate perfect mazes, i.e., mazes with exactly one path between any two cells
+type MazeAlgorithm int
+
+const (
+	// RecursiveBacktracker generates mazes with long winding corridors and few dead ends
+	RecursiveBacktracker MazeAlgorithm = iota
+	// Kruskal generates mazes with many short dead ends
+	Kruskal
+)
+
+// MazeAlgorithmFromString returns maze generation algorithm with given name [BACKTRACKER, KRUSKAL]
+func MazeAlgorithmFromString(name string) (MazeAlgorithm, error) {
+	switch strings.ToUpper(name) {
+	case "BACKTRACKER":
+		return RecursiveBacktracker, nil
+	case "KRUSKAL":
+		return Kruskal, nil
+	default:
+		return RecursiveBacktracker, fmt.Errorf("unsupported maze generation algorithm: %s", name)
+	}
+}
+
+// String returns the name of maze generation algorithm
+func (a MazeAlgorithm) String() string {
+	switch a {
+	case RecursiveBacktracker:
+		return "BACKTRACKER"
+	case Kruskal:
+		return "KRUSKAL"
+	default:
+		return fmt.Sprintf("MazeAlgorithm(%d)", int(a))
+	}
+}
+
+// GridCell is the cell of the maze grid defined by column and row
+type GridCell struct {
+	Col, Row int
+}
+
+// GeneratorOptions defines the parameters of procedural maze generation
+type GeneratorOptions struct {
+	// The number of columns and rows of the maze grid
+	Cols, Rows int
+	// The width of maze corridors, i.e., the size of grid cell
+	CorridorWidth float64
+	// The offset of the maze from the origin of coordinates
+	Offset float64
+	// The algorithm to generate perfect maze
+	Algorithm MazeAlgorithm
+	// The fraction of internal walls of the perfect maze to be removed in order to create loops [0, 1]
+	LoopFactor float64
+	// The seed of the random numbers generator
+	Seed int64
+
+	// The grid cell to place the agent into
+	StartCell GridCell
+	// The grid cell to place the maze exit into
+	ExitCell GridCell
+	// If set the maze exit is placed into the grid cell with the longest path from the start cell, ExitCell is ignored
+	FarthestExit bool
+
+	// The radius of agent body, used to check that maze is solvable
+	Radius float64
+}
+
+// DefaultGeneratorOptions returns generator options producing maze of size similar to the medium maze
+func DefaultGeneratorOptions() GeneratorOptions {
+	return GeneratorOptions{
+		Cols:          10,
+		Rows:          5,
+		CorridorWidth: 28,
+		Offset:        5,
+		Algorithm:     RecursiveBacktracker,
+		LoopFactor:    0.1,
+		Seed:          42,
+		StartCell:     GridCell{Col: 0, Row: 0},
+		FarthestExit:  true,
+		Radius:        NewAgent().Radius,
+	}
+}
+
+// BenchmarkMaze is the named maze generation options of the benchmark suite
+type BenchmarkMaze struct {
+	// The name of maze
+	Name string
+	// The maze generation options
+	Options GeneratorOptions
+}
+
+// BenchmarkMazes returns the suite of maze generation options with gradually increasing difficulty. The difficulty
+// is increased by growing maze size and by removing loops which provide shortcuts to the exit.
+func BenchmarkMazes(seed int64) []BenchmarkMaze {
+	levels := []struct {
+		cols, rows int
+		algorithm  MazeAlgorithm
+		loops      float64
+	}{
+		{cols: 4, rows: 3, algorithm: Kruskal, loops: 0.4},
+		{cols: 6, rows: 4, algorithm: Kruskal, loops: 0.2},
+		{cols: 8, rows: 5, algorithm: RecursiveBacktracker, loops: 0.1},
+		{cols: 10, rows: 7, algorithm: RecursiveBacktracker, loops: 0.05},
+		{cols: 14, rows: 10, algorithm: RecursiveBacktracker, loops: 0.0},
+	}
+	mazes := make([]BenchmarkMaze, len(levels))
+	for i, l := range levels {
+		opts := DefaultGeneratorOptions()
+		opts.Cols, opts.Rows = l.cols, l.rows
+		opts.Algorithm = l.algorithm
+		opts.LoopFactor = l.loops
+		opts.Seed = seed + int64(i)
+		mazes[i] = BenchmarkMaze{
+			Name:    fmt.Sprintf("level_%d_%dx%d", i+1, l.cols, l.rows),
+			Options: opts,
+		}
+	}
+	return mazes
+}
+
+// GenerateMaze creates new maze environment with provided options. The generated maze is guaranteed to be solvable by
+// an agent with radius specified in options, otherwise error is returned.
+func GenerateMaze(opts GeneratorOptions) (*Environment, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	grid := newMazeGrid(opts.Cols, opts.Rows)
+
+	// carve perfect maze
+	switch opts.Algorithm {
+	case RecursiveBacktracker:
+		grid.carveBacktracker(rng, opts.StartCell)
+	case Kruskal:
+		grid.carveKruskal(rng)
+	default:
+		return nil, fmt.Errorf("unsupported maze generation algorithm: %s", opts.Algorithm)
+	}
+
+	// thin walls to create loops
+	grid.removeWalls(rng, opts.LoopFactor)
+
+	exitCell := opts.ExitCell
+	if opts.FarthestExit {
+		exitCell = grid.farthestCell(opts.StartCell)
+	}
+
+	env := &Environment{
+		Hero:  NewAgent(),
+		Lines: grid.walls(opts.CorridorWidth, opts.Offset),
+	}
+	env.Hero.Radius = opts.Radius
+	env.Hero.Location = opts.cellCenter(opts.StartCell)
+	env.Hero.Heading = grid.openHeading(opts.StartCell)
+	env.MazeExit = opts.cellCenter(exitCell)
+	if err := env.initialize(); err != nil {
+		return nil, err
+	}
+
+	// check that maze is solvable for agent with given radius
+	field, err := NewDistanceField(env.Lines, env.MazeExit, opts.CorridorWidth/10, opts.Radius)
+	if err != nil {
+		return nil, err
+	}
+	if !field.IsReachable(env.Hero.Location) {
+		return nil, errors.New("generated maze is not solvable for agent with given radius")
+	}
+	return env, nil
+}
+
+func (o GeneratorOptions) validate() error {
+	if o.Cols < 1 || o.Rows < 1 {
+		return fmt.Errorf("invalid maze size: %d x %d", o.Cols, o.Rows)
+	}
+	if o.CorridorWidth <= 2*o.Radius {
+		return fmt.Errorf("corridor width: %f is too narrow for agent with radius: %f", o.CorridorWidth, o.Radius)
+	}
+	if o.LoopFactor < 0 || o.LoopFactor > 1 {
+		return fmt.Errorf("loop factor must be in range [0, 1], but was: %f", o.LoopFactor)
+	}
+	if !o.contains(o.StartCell) {
+		return fmt.Errorf("start cell: %v is outside the maze", o.StartCell)
+	}
+	if !o.FarthestExit && !o.contains(o.ExitCell) {
+		return fmt.Errorf("exit cell: %v is outside the maze", o.ExitCell)
+	}
+	return nil
+}
+
+func (o GeneratorOptions) contains(c GridCell) bool {
+	return c.Col >= 0 && c.Col < o.Cols && c.Row >= 0 && c.Row < o.Rows
+}
+
+func (o GeneratorOptions) cellCenter(c GridCell) Point {
+	return Point{
+		X: o.Offset + (float64(c.Col)+0.5)*o.CorridorWidth,
+		Y: o.Offset + (float64(c.Row)+0.5)*o.CorridorWidth,
+	}
+}
+
+// mazeGrid is the grid of maze cells with walls between them
+type mazeGrid struct {
+	cols, rows int
+	// eastWalls[r][c] is the wall between cell (c, r) and (c+1, r)
+	eastWalls [][]bool
+	// southWalls[r][c] is the wall between cell (c, r) and (c, r+1)
+	southWalls [][]bool
+}
+
+// gridWall is the internal wall of the maze grid between cell and its east or south neighbour
+type gridWall struct {
+	cell  GridCell
+	south bool
+}
+
+func newMazeGrid(cols, rows int) *mazeGrid {
+	g := &mazeGrid{cols: cols, rows: rows}
+	g.eastWalls = make([][]bool, rows)
+	g.southWalls = make([][]bool, rows)
+	for r := 0; r < rows; r++ {
+		g.eastWalls[r] = make([]bool, cols)
+		g.southWalls[r] = make([]bool, cols)
+		for c := 0; c < cols; c++ {
+			g.eastWalls[r][c] = true
+			g.southWalls[r][c] = true
+		}
+	}
+	return g
+}
+
+// internalWalls returns the list of existing walls between grid cells
+func (g *mazeGrid) internalWalls() []gridWall {
+	walls := make([]gridWall, 0)
+	for r := 0; r < g.rows; r++ {
+		for c := 0; c < g.cols; c++ {
+			if c < g.cols-1 && g.eastWalls[r][c] {
+				walls = append(walls, gridWall{cell: GridCell{Col: c, Row: r}})
+			}
+			if r < g.rows-1 && g.southWalls[r][c] {
+				walls = append(walls, gridWall{cell: GridCell{Col: c, Row: r}, south: true})
+			}
+		}
+	}
+	return walls
+}
+
+func (g *mazeGrid) removeWall(w gridWall) {
+	if w.south {
+		g.southWalls[w.cell.Row][w.cell.Col] = false
+	} else {
+		g.eastWalls[w.cell.Row][w.cell.Col] = false
+	}
+}
+
+// neighbours returns the adjacent cells of the given cell and the walls separating them
+func (g *mazeGrid) neighbours(c GridCell) ([]GridCell, []gridWall) {
+	cells := make([]GridCell, 0, 4)
+	walls := make([]gridWall, 0, 4)
+	if c.Col > 0 {
+		cells = append(cells, GridCell{Col: c.Col - 1, Row: c.Row})
+		walls = append(walls, gridWall{cell: GridCell{Col: c.Col - 1, Row: c.Row}})
+	}
+	if c.Col < g.cols-1 {
+		cells = append(cells, GridCell{Col: c.Col + 1, Row: c.Row})
+		walls = append(walls, gridWall{cell: c})
+	}
+	if c.Row > 0 {
+		cells = append(cells, GridCell{Col: c.Col, Row: c.Row - 1})
+		walls = append(walls, gridWall{cell: GridCell{Col: c.Col, Row: c.Row - 1}, south: true})
+	}
+	if c.Row < g.rows-1 {
+		cells = append(cells, GridCell{Col: c.Col, Row: c.Row + 1})
+		walls = append(walls, gridWall{cell: c, south: true})
+	}
+	return cells, walls
+}
+
+// openNeighbours returns the adjacent cells which are not separated by walls from the given cell
+func (g *mazeGrid) openNeighbours(c GridCell) []GridCell {
+	cells, walls := g.neighbours(c)
+	open := make([]GridCell, 0, len(cells))
+	for i, w := range walls {
+		if (w.south && !g.southWalls[w.cell.Row][w.cell.Col]) || (!w.south && !g.eastWalls[w.cell.Row][w.cell.Col]) {
+			open = append(open, cells[i])
+		}
+	}
+	return open
+}
+
+// carveBacktracker is to carve perfect maze using randomized depth-first search
+func (g *mazeGrid) carveBacktracker(rng *rand.Rand, start GridCell) {
+	visited := make([]bool, g.cols*g.rows)
+	visited[start.Row*g.cols+start.Col] = true
+	stack := []GridCell{start}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		cells, walls := g.neighbours(current)
+		candidates := make([]int, 0, len(cells))
+		for i, c := range cells {
+			if !visited[c.Row*g.cols+c.Col] {
+				candidates = append(candidates, i)
+			}
+		}
+		if len(candidates) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		next := candidates[rng.Intn(len(candidates))]
+		g.removeWall(walls[next])
+		visited[cells[next].Row*g.cols+cells[next].Col] = true
+		stack = append(stack, cells[next])
+	}
+}
+
+// carveKruskal is to carve perfect maze using randomized Kruskal's algorithm
+func (g *mazeGrid) carveKruskal(rng *rand.Rand) {
+	parents := make([]int, g.cols*g.rows)
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	walls := g.internalWalls()
+	rng.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+	for _, w := range walls {
+		from := w.cell.Row*g.cols + w.cell.Col
+		to := from + 1
+		if w.south {
+			to = from + g.cols
+		}
+		if rootFrom, rootTo := find(from), find(to); rootFrom != rootTo {
+			parents[rootFrom] = rootTo
+			g.removeWall(w)
+		}
+	}
+}
+
+// removeWalls is to remove given fraction of remaining internal walls to create loops
+func (g *mazeGrid) removeWalls(rng *rand.Rand, fraction float64) {
+	walls := g.internalWalls()
+	count := int(math.Round(fraction * float64(len(walls))))
+	for _, i := range rng.Perm(len(walls))[:count] {
+		g.removeWall(walls[i])
+	}
+}
+
+// farthestCell returns the cell with the longest path from the given cell
+func (g *mazeGrid) farthestCell(from GridCell) GridCell {
+	distances := make([]int, g.cols*g.rows)
+	for i := range distances {
+		distances[i] = -1
+	}
+	distances[from.Row*g.cols+from.Col] = 0
+	farthest := from
+	queue := []GridCell{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		dist := distances[current.Row*g.cols+current.Col]
+		for _, c := range g.openNeighbours(current) {
+			if distances[c.Row*g.cols+c.Col] < 0 {
+				distances[c.Row*g.cols+c.Col] = dist + 1
+				if dist+1 > distances[farthest.Row*g.cols+farthest.Col] {
+					farthest = c
+				}
+				queue = append(queue, c)
+			}
+		}
+	}
+	return farthest
+}
+
+// openHeading returns the heading angle in degrees pointing from the given cell to its first open neighbour
+func (g *mazeGrid) openHeading(c GridCell) float64 {
+	open := g.openNeighbours(c)
+	if len(open) == 0 {
+		return 0
+	}
+	return Point{X: float64(open[0].Col - c.Col), Y: float64(open[0].Row - c.Row)}.Angle()
+}
+
+// walls returns the line segments of the maze walls including boundaries. The collinear adjacent walls are merged.
+func (g *mazeGrid) walls(cellSize, offset float64) []Line {
+	lines := make([]Line, 0)
+	coord := func(i int) float64 {
+		return offset + float64(i)*cellSize
+	}
+	// horizontal walls at the top of each row and at the bottom of the maze
+	for r := 0; r <= g.rows; r++ {
+		start := -1
+		for c := 0; c <= g.cols; c++ {
+			wall := c < g.cols && (r == 0 || r == g.rows || g.southWalls[r-1][c])
+			if wall && start < 0 {
+				start = c
+			} else if !wall && start >= 0 {
+				lines = append(lines, NewLine(Point{X: coord(start), Y: coord(r)}, Point{X: coord(c), Y: coord(r)}))
+				start = -1
+			}
+		}
+	}
+	// vertical walls at the left of each column and at the right of the maze
+	for c := 0; c <= g.cols; c++ {
+		start := -1
+		for r := 0; r <= g.rows; r++ {
+			wall := r < g.rows && (c == 0 || c == g.cols || g.eastWalls[r][c-1])
+			if wall && start < 0 {
+				start = r
+			} else if !wall && start >= 0 {
+				lines = append(lines, NewLine(Point{X: coord(c), Y: coord(start)}, Point{X: coord(c), Y: coord(r)}))
+				start = -1
+			}
+		}
+	}
+	return lines
+}
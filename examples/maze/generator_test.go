@@ -0,0 +1,163 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestMazeAlgorithmFromString(t *testing.T) {
+	algorithms := []MazeAlgorithm{RecursiveBacktracker, Kruskal}
+	for _, algorithm := range algorithms {
+		parsed, err := MazeAlgorithmFromString(algorithm.String())
+		require.NoError(t, err)
+		assert.Equal(t, algorithm, parsed)
+	}
+
+	_, err := MazeAlgorithmFromString("unknown")
+	assert.Error(t, err)
+}
+
+func TestGenerateMaze(t *testing.T) {
+	algorithms := []MazeAlgorithm{RecursiveBacktracker, Kruskal}
+	for _, algorithm := range algorithms {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			opts := DefaultGeneratorOptions()
+			opts.Algorithm = algorithm
+			opts.LoopFactor = 0
+
+			env, err := GenerateMaze(opts)
+			require.NoError(t, err)
+
+			// the perfect maze has exactly cols*rows-1 passages between cells
+			grid := newMazeGrid(opts.Cols, opts.Rows)
+			allWalls := len(grid.internalWalls())
+			carved := newTestGrid(opts)
+			assert.Len(t, carved.internalWalls(), allWalls-(opts.Cols*opts.Rows-1))
+
+			assert.Equal(t, opts.cellCenter(opts.StartCell), env.Hero.Location)
+			assert.Equal(t, opts.Radius, env.Hero.Radius)
+			assert.NotEqual(t, env.Hero.Location, env.MazeExit)
+			assert.True(t, len(env.Lines) >= 4)
+
+			// the maze is solvable
+			field, err := NewDistanceField(env.Lines, env.MazeExit, 2, opts.Radius)
+			require.NoError(t, err)
+			assert.True(t, field.IsReachable(env.Hero.Location))
+		})
+	}
+}
+
+func TestGenerateMaze_deterministic(t *testing.T) {
+	opts := DefaultGeneratorOptions()
+	env1, err := GenerateMaze(opts)
+	require.NoError(t, err)
+	env2, err := GenerateMaze(opts)
+	require.NoError(t, err)
+	assert.Equal(t, env1.Lines, env2.Lines)
+
+	opts.Seed++
+	env3, err := GenerateMaze(opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, env1.Lines, env3.Lines)
+}
+
+func TestGenerateMaze_loops(t *testing.T) {
+	opts := DefaultGeneratorOptions()
+	opts.LoopFactor = 0
+	perfect, err := GenerateMaze(opts)
+	require.NoError(t, err)
+
+	opts.LoopFactor = 0.5
+	thinned, err := GenerateMaze(opts)
+	require.NoError(t, err)
+
+	assert.Less(t, totalLength(thinned.Lines), totalLength(perfect.Lines))
+}
+
+func TestGenerateMaze_exitCell(t *testing.T) {
+	opts := DefaultGeneratorOptions()
+	opts.FarthestExit = false
+	opts.StartCell = GridCell{Col: 1, Row: 1}
+	opts.ExitCell = GridCell{Col: 3, Row: 2}
+
+	env, err := GenerateMaze(opts)
+	require.NoError(t, err)
+	assert.Equal(t, Point{X: 5 + 1.5*28, Y: 5 + 1.5*28}, env.Hero.Location)
+	assert.Equal(t, Point{X: 5 + 3.5*28, Y: 5 + 2.5*28}, env.MazeExit)
+}
+
+func TestGenerateMaze_invalidOptions(t *testing.T) {
+	opts := DefaultGeneratorOptions()
+	opts.CorridorWidth = 2 * opts.Radius
+	_, err := GenerateMaze(opts)
+	assert.Error(t, err, "corridor is too narrow")
+
+	opts = DefaultGeneratorOptions()
+	opts.Cols = 0
+	_, err = GenerateMaze(opts)
+	assert.Error(t, err, "invalid size")
+
+	opts = DefaultGeneratorOptions()
+	opts.LoopFactor = 1.5
+	_, err = GenerateMaze(opts)
+	assert.Error(t, err, "invalid loop factor")
+
+	opts = DefaultGeneratorOptions()
+	opts.StartCell = GridCell{Col: opts.Cols, Row: 0}
+	_, err = GenerateMaze(opts)
+	assert.Error(t, err, "start cell outside")
+}
+
+func TestBenchmarkMazes(t *testing.T) {
+	mazes := BenchmarkMazes(1)
+	require.Len(t, mazes, 5)
+	prevSize := 0
+	for _, m := range mazes {
+		env, err := GenerateMaze(m.Options)
+		require.NoError(t, err, "failed to generate: %s", m.Name)
+		require.NotNil(t, env)
+
+		size := m.Options.Cols * m.Options.Rows
+		assert.Greater(t, size, prevSize, "maze size must grow: %s", m.Name)
+		prevSize = size
+	}
+}
+
+func TestWriteEnvironment(t *testing.T) {
+	env, err := GenerateMaze(DefaultGeneratorOptions())
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = WriteEnvironment(&buf, env)
+	require.NoError(t, err)
+
+	readEnv, err := ReadEnvironment(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, env.Lines, readEnv.Lines)
+	assert.Equal(t, env.Hero.Location, readEnv.Hero.Location)
+	assert.Equal(t, env.Hero.Heading, readEnv.Hero.Heading)
+	assert.Equal(t, env.MazeExit, readEnv.MazeExit)
+}
+
+// repeats carving of the maze grid with given options
+func newTestGrid(opts GeneratorOptions) *mazeGrid {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	grid := newMazeGrid(opts.Cols, opts.Rows)
+	if opts.Algorithm == Kruskal {
+		grid.carveKruskal(rng)
+	} else {
+		grid.carveBacktracker(rng, opts.StartCell)
+	}
+	return grid
+}
+
+func totalLength(lines []Line) float64 {
+	length := 0.0
+	for _, l := range lines {
+		length += l.Length()
+	}
+	return length
+}
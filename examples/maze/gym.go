@@ -0,0 +1,219 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrEpisodeDone to be returned if simulation step requested for the episode which is already done
+var ErrEpisodeDone = errors.New("episode is done, the environment must be reset")
+
+// Box is the specification of the bounded continuous space of observations or actions
+type Box struct {
+	// The lower bounds of each dimension
+	Low []float64 `json:"low"`
+	// The upper bounds of each dimension
+	High []float64 `json:"high"`
+}
+
+// NewBox creates space with given number of dimensions and the same bounds for each dimension
+func NewBox(size int, low, high float64) Box {
+	box := Box{Low: make([]float64, size), High: make([]float64, size)}
+	for i := 0; i < size; i++ {
+		box.Low[i], box.High[i] = low, high
+	}
+	return box
+}
+
+// Shape returns the number of dimensions of the space
+func (b Box) Shape() int {
+	return len(b.Low)
+}
+
+// Contains is to check whether given vector belongs to the space
+func (b Box) Contains(v []float64) bool {
+	if len(v) != b.Shape() {
+		return false
+	}
+	for i, x := range v {
+		if math.IsNaN(x) || x < b.Low[i] || x > b.High[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Clip returns the copy of given vector with values clipped to the bounds of the space
+func (b Box) Clip(v []float64) []float64 {
+	clipped := make([]float64, len(v))
+	for i, x := range v {
+		clipped[i] = math.Min(math.Max(x, b.Low[i]), b.High[i])
+	}
+	return clipped
+}
+
+// StepInfo is the auxiliary information about the state of the simulation after the time step
+type StepInfo struct {
+	// The number of steps executed since the episode start
+	Steps int `json:"steps"`
+	// The simulation time step of the environment
+	TimeStep int `json:"time_step"`
+	// The current location of the agent
+	Location Point `json:"location"`
+	// The current heading of the agent in degrees
+	Heading float64 `json:"heading"`
+	// The flag to indicate whether agent was in contact with maze walls during the last time step
+	Collided bool `json:"collided"`
+	// The number of waypoints visited by the agent
+	VisitedWaypoints int `json:"visited_waypoints"`
+	// The agent's distance to the maze exit according to the fitness metric of the environment
+	DistanceToExit float64 `json:"distance_to_exit"`
+	// The normalized fitness of the agent at this step
+	Fitness float64 `json:"fitness"`
+	// The flag to indicate whether the maze exit was found
+	ExitFound bool `json:"exit_found"`
+	// The flag to indicate whether the episode was terminated by reaching the time steps limit
+	Truncated bool `json:"truncated"`
+}
+
+// Policy is to select the action to be executed by the agent given the current observation
+type Policy func(observation []float64) ([]float64, error)
+
+// GymEnvironment exposes the maze simulation through the Reset/Step style interface to be driven by external
+// controllers, such as scripted or random-walk agents. It runs exactly the same simulation as maze experiments: the
+// observation is the vector of neural network inputs produced by Environment.GetInputs, the action is the pair of
+// outputs applied by Environment.ApplyOutputs, and the reward is the increment of the agent's fitness per step, i.e.,
+// the episode return equals to the fitness gained by the agent during the episode.
+type GymEnvironment struct {
+	// The specification of the observations
+	ObservationSpace Box
+	// The specification of the actions
+	ActionSpace Box
+
+	// The maze environment to start each episode from
+	template *Environment
+	// The maze environment of the current episode
+	env *Environment
+	// The number of steps executed within the current episode
+	steps int
+	// The fitness of the agent at the previous step
+	fitness float64
+	// The flag to indicate whether the current episode is done
+	done bool
+}
+
+// NewGymEnvironment creates new Reset/Step style environment over provided maze environment. The provided maze
+// environment is not modified, each episode is simulated within its copy.
+func NewGymEnvironment(env *Environment) (*GymEnvironment, error) {
+	probe := env.Clone()
+	if err := probe.initialize(); err != nil {
+		return nil, err
+	}
+	inputs, err := probe.GetInputs()
+	if err != nil {
+		return nil, err
+	}
+	return &GymEnvironment{
+		// bias, normalized rangefinders and radars are all within unit range
+		ObservationSpace: NewBox(len(inputs), 0, 1),
+		// the motion models expect both outputs in unit range
+		ActionSpace: NewBox(2, 0, 1),
+		template:    env,
+		done:        true,
+	}, nil
+}
+
+// Reset starts new episode from the initial state of the maze environment and returns the first observation
+func (g *GymEnvironment) Reset() ([]float64, error) {
+	g.env = g.template.Clone()
+	g.env.TimeStep = 0
+	if err := g.env.initialize(); err != nil {
+		return nil, err
+	}
+	// the same as maze experiments do before the first activation of the controller
+	if err := g.env.Update(); err != nil {
+		return nil, err
+	}
+	g.steps = 0
+	g.fitness = g.env.normalizedFitness()
+	g.done = g.env.ExitFound
+	return g.env.GetInputs()
+}
+
+// Step executes one time step of simulation with given action and returns the next observation, the reward, the flag
+// to indicate whether the episode is done, and the auxiliary information. The action values are clipped to the bounds
+// of the ActionSpace. The episode is done when the maze exit is found or the number of time steps of the maze
+// environment is executed.
+func (g *GymEnvironment) Step(action []float64) ([]float64, float64, bool, StepInfo, error) {
+	if g.env == nil || g.done {
+		return nil, 0, true, StepInfo{}, ErrEpisodeDone
+	}
+	if len(action) != g.ActionSpace.Shape() {
+		return nil, 0, false, StepInfo{}, fmt.Errorf("action must have %d values, but has: %d",
+			g.ActionSpace.Shape(), len(action))
+	}
+	action = g.ActionSpace.Clip(action)
+	if err := g.env.ApplyOutputs(action[0], action[1]); err != nil {
+		return nil, 0, false, StepInfo{}, err
+	}
+	if err := g.env.Update(); err != nil {
+		return nil, 0, false, StepInfo{}, err
+	}
+	g.steps++
+
+	observation, err := g.env.GetInputs()
+	if err != nil {
+		return nil, 0, false, StepInfo{}, err
+	}
+	fitness := g.env.normalizedFitness()
+	reward := fitness - g.fitness
+	g.fitness = fitness
+
+	info := g.info()
+	g.done = info.ExitFound || info.Truncated
+	return observation, reward, g.done, info, nil
+}
+
+// RunEpisode is to reset the environment and to run the episode with given policy until it's done. Returns the
+// episode return and the information about the final state of the simulation.
+func (g *GymEnvironment) RunEpisode(policy Policy) (float64, StepInfo, error) {
+	observation, err := g.Reset()
+	if err != nil {
+		return 0, StepInfo{}, err
+	}
+	total, info, done := 0.0, g.info(), g.done
+	for !done {
+		action, err := policy(observation)
+		if err != nil {
+			return total, info, err
+		}
+		var reward float64
+		if observation, reward, done, info, err = g.Step(action); err != nil {
+			return total, info, err
+		}
+		total += reward
+	}
+	return total, info, nil
+}
+
+// State returns the maze environment of the current episode, nil if the environment was not reset yet
+func (g *GymEnvironment) State() *Environment {
+	return g.env
+}
+
+// info returns the information about the current state of the simulation
+func (g *GymEnvironment) info() StepInfo {
+	return StepInfo{
+		Steps:            g.steps,
+		TimeStep:         g.env.TimeStep,
+		Location:         g.env.Hero.Location,
+		Heading:          g.env.Hero.Heading,
+		Collided:         g.env.Hero.Collided,
+		VisitedWaypoints: len(g.env.VisitedWaypoints),
+		DistanceToExit:   g.env.fitnessDistanceToExit(),
+		Fitness:          g.fitness,
+		ExitFound:        g.env.ExitFound,
+		Truncated:        !g.env.ExitFound && g.env.TimeSteps > 0 && g.steps >= g.env.TimeSteps,
+	}
+}
@@ -0,0 +1,107 @@
+package maze
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+// Client is the client of the maze environment server. It's safe to use it from multiple goroutines, but requests
+// are executed one by one.
+type Client struct {
+	// guards the connection
+	mu sync.Mutex
+	// the connection to the server
+	conn io.ReadWriter
+	// the reader of responses
+	reader *bufio.Reader
+	// the encoder of requests
+	encoder *json.Encoder
+	// the ID of the last request
+	lastID int
+}
+
+// NewClient creates new client communicating with the maze environment server through given connection, e.g. the
+// standard input and output of the server process.
+func NewClient(conn io.ReadWriter) *Client {
+	return &Client{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		encoder: json.NewEncoder(conn),
+	}
+}
+
+// DialServer connects to the maze environment server listening at given TCP address
+func DialServer(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// Reset starts new episode within given maze if episode is empty, or restarts the existing episode. The maze can be
+// empty to use the default maze of the server or the maze of the existing episode.
+func (c *Client) Reset(episode, maze string) (*ServerResponse, error) {
+	return c.Do(&ServerRequest{Command: ResetCommand, Episode: episode, Maze: maze})
+}
+
+// Step executes one time step of the episode with given action
+func (c *Client) Step(episode string, action []float64) (*ServerResponse, error) {
+	return c.Do(&ServerRequest{Command: StepCommand, Episode: episode, Action: action})
+}
+
+// Observation returns the current observation of the episode
+func (c *Client) Observation(episode string) (*ServerResponse, error) {
+	return c.Do(&ServerRequest{Command: ObservationCommand, Episode: episode})
+}
+
+// Render returns the PNG image of the current state of the episode rendered with given scale factor
+func (c *Client) Render(episode string, scale float64) ([]byte, error) {
+	resp, err := c.Do(&ServerRequest{Command: RenderCommand, Episode: episode, Scale: scale})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Image, nil
+}
+
+// CloseEpisode closes the episode on the server
+func (c *Client) CloseEpisode(episode string) error {
+	_, err := c.Do(&ServerRequest{Command: CloseCommand, Episode: episode})
+	return err
+}
+
+// Do sends the request to the server and waits for the response. The error is returned if the request failed.
+func (c *Client) Do(req *ServerRequest) (*ServerResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastID++
+	req.ID = c.lastID
+	if err := c.encoder.Encode(req); err != nil {
+		return nil, err
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, err
+	}
+	var resp ServerResponse
+	if err = json.Unmarshal(line, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Error) > 0 {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Close closes the connection to the server if it's closable
+func (c *Client) Close() error {
+	if closer, ok := c.conn.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// The commands of the maze environment server protocol
+const (
+	// ResetCommand starts new episode or restarts existing one and returns the first observation
+	ResetCommand = "reset"
+	// StepCommand executes one time step of the episode with given action
+	StepCommand = "step"
+	// ObservationCommand returns the current observation of the episode
+	ObservationCommand = "observation"
+	// RenderCommand renders the current state of the episode as PNG image
+	RenderCommand = "render"
+	// CloseCommand closes the episode and releases its resources
+	CloseCommand = "close"
+)
+
+// MaxRenderScale is the maximal scale factor of the image rendered by the server
+const MaxRenderScale = 10.0
+
+// ServerRequest is the request of the maze environment server protocol. The requests and responses are exchanged as
+// JSON objects, one per line.
+type ServerRequest struct {
+	// The ID of the request to be echoed in the response
+	ID int `json:"id"`
+	// The command to be executed [reset, step, observation, render, close]
+	Command string `json:"cmd"`
+	// The ID of the episode, the new episode is started by reset command if empty
+	Episode string `json:"episode,omitempty"`
+	// The path to the maze config file relative to the mazes directory of the server, used by reset command. If
+	// empty the default maze of the server or the maze of existing episode is used.
+	Maze string `json:"maze,omitempty"`
+	// The action to be executed by the step command
+	Action []float64 `json:"action,omitempty"`
+	// The scale factor of the image rendered by render command in range (0, MaxRenderScale], 1 if not set
+	Scale float64 `json:"scale,omitempty"`
+}
+
+// ServerResponse is the response of the maze environment server protocol
+type ServerResponse struct {
+	// The ID of the request
+	ID int `json:"id"`
+	// The ID of the episode
+	Episode string `json:"episode,omitempty"`
+	// The observation of the agent
+	Observation []float64 `json:"observation,omitempty"`
+	// The reward received by the step
+	Reward float64 `json:"reward"`
+	// The flag to indicate whether the episode is done
+	Done bool `json:"done"`
+	// The auxiliary information about the state of the simulation
+	Info *StepInfo `json:"info,omitempty"`
+	// The specification of observations, returned by reset command
+	ObservationSpace *Box `json:"observation_space,omitempty"`
+	// The specification of actions, returned by reset command
+	ActionSpace *Box `json:"action_space,omitempty"`
+	// The rendered PNG image encoded in base64, returned by render command
+	Image []byte `json:"png,omitempty"`
+	// The error message if request failed
+	Error string `json:"error,omitempty"`
+}
+
+// ServerOptions is the options of the maze environment server
+type ServerOptions struct {
+	// The directory to load maze config files from
+	MazeDir string
+	// The maze config file to be used if not requested by the client
+	DefaultMaze string
+	// The number of time steps per episode, used if not set by maze config
+	TimeSteps int
+	// The range around maze exit point to consider it as reached, used if not set by maze config
+	ExitFoundRange float64
+}
+
+// Server serves the maze environment over the line-delimited JSON protocol. Many episodes can be simulated
+// concurrently, and each episode can be accessed from any connection by its ID.
+type Server struct {
+	opts ServerOptions
+
+	// guards the maps below and episodes counter
+	mu sync.Mutex
+	// the running episodes by ID
+	episodes map[string]*serverEpisode
+	// the loaded maze environments by path
+	mazes map[string]*Environment
+	// the counter to generate episode IDs
+	episodesCounter int
+}
+
+// serverEpisode is the episode of maze simulation served by the server
+type serverEpisode struct {
+	// guards the episode state
+	mu sync.Mutex
+	// the path of the episode maze
+	maze string
+	// the environment simulating the episode
+	gym *GymEnvironment
+	// the last observation
+	observation []float64
+}
+
+// NewServer creates new maze environment server with given options
+func NewServer(opts ServerOptions) *Server {
+	return &Server{
+		opts:     opts,
+		episodes: make(map[string]*serverEpisode),
+		mazes:    make(map[string]*Environment),
+	}
+}
+
+// Serve is to serve requests read from the reader, e.g. standard input, and to write responses into the writer, e.g.
+// standard output, until the end of input.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err = encoder.Encode(s.handleLine(line)); err != nil {
+				return err
+			}
+			if err = writer.Flush(); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// ServeListener is to accept connections from the listener and to serve each of them concurrently until the
+// listener is closed.
+func (s *Server) ServeListener(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go func() {
+			defer func() {
+				_ = conn.Close()
+			}()
+			_ = s.Serve(conn, conn)
+		}()
+	}
+}
+
+// handleLine is to decode request and to handle it
+func (s *Server) handleLine(line []byte) *ServerResponse {
+	var req ServerRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return &ServerResponse{Error: fmt.Sprintf("malformed request: %s", err)}
+	}
+	resp, err := s.Handle(&req)
+	if err != nil {
+		return &ServerResponse{ID: req.ID, Episode: req.Episode, Error: err.Error()}
+	}
+	return resp
+}
+
+// Handle executes the request and returns the response
+func (s *Server) Handle(req *ServerRequest) (*ServerResponse, error) {
+	if req.Command == ResetCommand {
+		return s.reset(req)
+	}
+	episode, err := s.episode(req.Episode)
+	if err != nil {
+		return nil, err
+	}
+	episode.mu.Lock()
+	defer episode.mu.Unlock()
+
+	resp := &ServerResponse{ID: req.ID, Episode: req.Episode}
+	switch req.Command {
+	case StepCommand:
+		observation, reward, done, info, err := episode.gym.Step(req.Action)
+		if err != nil {
+			return nil, err
+		}
+		episode.observation = observation
+		resp.Observation, resp.Reward, resp.Done, resp.Info = observation, reward, done, &info
+	case ObservationCommand:
+		info := episode.gym.info()
+		resp.Observation, resp.Done, resp.Info = episode.observation, episode.gym.done, &info
+	case RenderCommand:
+		if req.Scale < 0 || req.Scale > MaxRenderScale {
+			return nil, fmt.Errorf("render scale must be in range (0, %g], got: %g", MaxRenderScale, req.Scale)
+		}
+		buf := bytes.NewBuffer(nil)
+		if err = WriteEnvironmentPNG(buf, episode.gym.State(), req.Scale); err != nil {
+			return nil, err
+		}
+		resp.Image = buf.Bytes()
+	case CloseCommand:
+		s.mu.Lock()
+		delete(s.episodes, req.Episode)
+		s.mu.Unlock()
+	default:
+		return nil, fmt.Errorf("unsupported command: %s", req.Command)
+	}
+	return resp, nil
+}
+
+// reset is to start new episode or to restart existing one
+func (s *Server) reset(req *ServerRequest) (*ServerResponse, error) {
+	episode := &serverEpisode{}
+	id := req.Episode
+	if len(id) > 0 {
+		var err error
+		if episode, err = s.episode(id); err != nil {
+			return nil, err
+		}
+	}
+	episode.mu.Lock()
+	defer episode.mu.Unlock()
+
+	mazePath := req.Maze
+	if len(mazePath) == 0 {
+		mazePath = episode.maze
+	}
+	if len(mazePath) == 0 {
+		mazePath = s.opts.DefaultMaze
+	}
+	if episode.gym == nil || mazePath != episode.maze {
+		env, err := s.loadMaze(mazePath)
+		if err != nil {
+			return nil, err
+		}
+		if episode.gym, err = NewGymEnvironment(env); err != nil {
+			return nil, err
+		}
+		episode.maze = mazePath
+	}
+
+	observation, err := episode.gym.Reset()
+	if err != nil {
+		return nil, err
+	}
+	episode.observation = observation
+
+	if len(id) == 0 {
+		// register new episode only when it's started
+		s.mu.Lock()
+		s.episodesCounter++
+		id = fmt.Sprintf("episode-%d", s.episodesCounter)
+		s.episodes[id] = episode
+		s.mu.Unlock()
+	}
+	info := episode.gym.info()
+	return &ServerResponse{
+		ID:               req.ID,
+		Episode:          id,
+		Observation:      observation,
+		Done:             episode.gym.done,
+		Info:             &info,
+		ObservationSpace: &episode.gym.ObservationSpace,
+		ActionSpace:      &episode.gym.ActionSpace,
+	}, nil
+}
+
+// episode returns the running episode with given ID
+func (s *Server) episode(id string) (*serverEpisode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	episode, ok := s.episodes[id]
+	if !ok {
+		return nil, fmt.Errorf("episode not found: %s", id)
+	}
+	return episode, nil
+}
+
+// loadMaze returns the maze environment loaded from the config file with given path relative to the mazes directory.
+// The loaded mazes are cached and never modified by the episodes.
+func (s *Server) loadMaze(path string) (*Environment, error) {
+	if len(path) == 0 {
+		return nil, errors.New("maze config file not set")
+	}
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("maze config file must be within the mazes directory: %s", path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if env, ok := s.mazes[clean]; ok {
+		return env, nil
+	}
+	env, err := ReadEnvironmentFromFile(filepath.Join(s.opts.MazeDir, clean))
+	if err != nil {
+		return nil, err
+	}
+	if env.TimeSteps == 0 {
+		env.TimeSteps = s.opts.TimeSteps
+	}
+	if env.ExitFoundRange == 0 {
+		env.ExitFoundRange = s.opts.ExitFoundRange
+	}
+	s.mazes[clean] = env
+	return env, nil
+}
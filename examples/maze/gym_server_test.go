@@ -0,0 +1,167 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/png"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestServer_tcp(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewServer(createTestServerOptions())
+	served := make(chan error)
+	go func() {
+		served <- server.ServeListener(listener)
+	}()
+
+	// run concurrent episodes from different clients
+	wg := sync.WaitGroup{}
+	results := make([]*ServerResponse, 4)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = runServerEpisode(listener.Addr().String(), 0.5+float64(i)*0.1)
+		}(i)
+	}
+	wg.Wait()
+	episodes := make(map[string]bool)
+	for i, resp := range results {
+		require.NoError(t, errs[i])
+		assert.True(t, resp.Done)
+		assert.True(t, resp.Info.Truncated)
+		assert.Equal(t, 20, resp.Info.Steps)
+		episodes[resp.Episode] = true
+	}
+	assert.Len(t, episodes, len(results), "each client has its own episode")
+
+	// the same episode produces the same results
+	again, err := runServerEpisode(listener.Addr().String(), 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, results[0].Info, again.Info)
+
+	require.NoError(t, listener.Close())
+	assert.NoError(t, <-served)
+}
+
+func TestServer_stdio(t *testing.T) {
+	server := NewServer(createTestServerOptions())
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	served := make(chan error)
+	go func() {
+		served <- server.Serve(serverReader, serverWriter)
+		_ = serverWriter.Close()
+	}()
+	client := NewClient(struct {
+		io.Reader
+		io.Writer
+	}{clientReader, clientWriter})
+
+	resp, err := client.Reset("", "")
+	require.NoError(t, err)
+	episode := resp.Episode
+	assert.Equal(t, 1, resp.ID)
+	require.NotNil(t, resp.ObservationSpace)
+	assert.Len(t, resp.Observation, resp.ObservationSpace.Shape())
+	assert.Equal(t, 2, resp.ActionSpace.Shape())
+	start := resp.Info.Location
+
+	resp, err = client.Step(episode, []float64{0.5, 1})
+	require.NoError(t, err)
+	assert.False(t, resp.Done)
+	observation := resp.Observation
+
+	resp, err = client.Observation(episode)
+	require.NoError(t, err)
+	assert.Equal(t, observation, resp.Observation)
+	assert.Equal(t, 1, resp.Info.Steps)
+
+	image, err := client.Render(episode, 2)
+	require.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(image))
+	require.NoError(t, err)
+	assert.True(t, img.Bounds().Dx() > 0)
+
+	// restart the episode within another maze
+	resp, err = client.Reset(episode, "hard_maze.txt")
+	require.NoError(t, err)
+	assert.Equal(t, episode, resp.Episode)
+	assert.NotEqual(t, start, resp.Info.Location)
+
+	require.NoError(t, client.CloseEpisode(episode))
+	_, err = client.Observation(episode)
+	assert.EqualError(t, err, fmt.Sprintf("episode not found: %s", episode))
+
+	require.NoError(t, clientWriter.Close())
+	assert.NoError(t, <-served)
+}
+
+func TestServer_errors(t *testing.T) {
+	server := NewServer(createTestServerOptions())
+	requests := strings.Join([]string{
+		`{"id": 1, "cmd": "reset", "maze": "../data/medium_maze.txt"}`,
+		`{"id": 2, "cmd": "reset", "maze": "unknown.txt"}`,
+		`{"id": 3, "cmd": "step", "episode": "unknown", "action": [0.5, 0.5]}`,
+		`{"id": 4, "cmd": "reset"}`,
+		`{"id": 5, "cmd": "step", "episode": "episode-1", "action": [0.5]}`,
+		`{"id": 6, "cmd": "unknown", "episode": "episode-1"}`,
+		`{"id": 7, "cmd": "render", "episode": "episode-1", "scale": 1e6}`,
+		`{"id": 8, "cmd": "render", "episode": "episode-1", "scale": -1}`,
+		`not a json`,
+	}, "\n")
+	out := bytes.NewBuffer(nil)
+	err := server.Serve(strings.NewReader(requests), out)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 9)
+	for i, line := range lines {
+		var resp ServerResponse
+		require.NoError(t, json.Unmarshal([]byte(line), &resp))
+		if i == 3 {
+			assert.Empty(t, resp.Error)
+			assert.Equal(t, "episode-1", resp.Episode, "failed resets must not start episodes")
+		} else {
+			assert.NotEmpty(t, resp.Error, "request: %d", i+1)
+		}
+		if i < 8 {
+			assert.Equal(t, i+1, resp.ID)
+		}
+	}
+}
+
+// runServerEpisode is to run the episode with constant action on the server until it's done
+func runServerEpisode(addr string, turn float64) (*ServerResponse, error) {
+	client, err := DialServer(addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	resp, err := client.Reset("", "medium_maze.txt")
+	for err == nil && !resp.Done {
+		resp, err = client.Step(resp.Episode, []float64{turn, 0.7})
+	}
+	return resp, err
+}
+
+func createTestServerOptions() ServerOptions {
+	return ServerOptions{
+		MazeDir:        "../../data",
+		DefaultMaze:    "medium_maze.txt",
+		TimeSteps:      20,
+		ExitFoundRange: 5,
+	}
+}
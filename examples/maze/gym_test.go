@@ -0,0 +1,132 @@
+package maze
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestGymEnvironment_sameAsMazeSimulation(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 100
+	env.SampleSize = 10
+	env.ExitFoundRange = 5
+
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+	org, err := genetics.NewOrganism(0, genome, 1)
+	require.NoError(t, err)
+
+	record := AgentRecord{}
+	nItem, _, err := mazeSimulationEvaluate(env, org, &record, nil)
+	require.NoError(t, err)
+
+	gym, err := NewGymEnvironment(env)
+	require.NoError(t, err)
+	assert.Equal(t, 11, gym.ObservationSpace.Shape())
+	assert.Equal(t, 2, gym.ActionSpace.Shape())
+
+	phenotype, err := org.Phenotype()
+	require.NoError(t, err)
+	_, err = phenotype.Flush()
+	require.NoError(t, err)
+	// the maximal depth is used if exceeded
+	netDepth, _ := phenotype.MaxActivationDepthWithCap(1)
+	activate := func(observation []float64) ([]float64, error) {
+		if err := phenotype.LoadSensors(observation); err != nil {
+			return nil, err
+		}
+		if _, err := phenotype.ForwardSteps(netDepth); err != nil && !errors.Is(err, network.ErrNetExceededMaxActivationAttempts) {
+			return nil, err
+		}
+		return []float64{phenotype.Outputs[0].Activation, phenotype.Outputs[1].Activation}, nil
+	}
+
+	observation, err := gym.Reset()
+	require.NoError(t, err)
+	assert.True(t, gym.ObservationSpace.Contains(observation))
+	// the controller is activated once before the first step as maze experiments do
+	_, err = activate(observation)
+	require.NoError(t, err)
+
+	total, done := 0.0, false
+	var info StepInfo
+	for !done {
+		action, err := activate(observation)
+		require.NoError(t, err)
+		var reward float64
+		observation, reward, done, info, err = gym.Step(action)
+		require.NoError(t, err)
+		total += reward
+	}
+	assert.True(t, info.Truncated)
+	assert.Equal(t, env.TimeSteps, info.Steps)
+	assert.Equal(t, Point{X: record.X, Y: record.Y}, info.Location)
+	assert.Equal(t, nItem.Fitness, info.Fitness)
+	assert.InDelta(t, info.Fitness, total, 1e-9)
+
+	// the episode is over
+	_, _, done, _, err = gym.Step([]float64{0.5, 0.5})
+	assert.ErrorIs(t, err, ErrEpisodeDone)
+	assert.True(t, done)
+}
+
+func TestGymEnvironment_RunEpisode(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 50
+	env.ExitFoundRange = 5
+	start := env.Hero.Location
+
+	gym, err := NewGymEnvironment(env)
+	require.NoError(t, err)
+	assert.Nil(t, gym.State())
+
+	// random-walk agent
+	rng := rand.New(rand.NewSource(1))
+	randomWalk := func(_ []float64) ([]float64, error) {
+		return []float64{rng.Float64(), rng.Float64()}, nil
+	}
+	total, info, err := gym.RunEpisode(randomWalk)
+	require.NoError(t, err)
+	assert.Equal(t, 50, info.Steps)
+	assert.InDelta(t, info.Fitness, total, 1e-9)
+	assert.NotEqual(t, start, info.Location)
+	// the template environment is not modified
+	assert.Equal(t, start, env.Hero.Location)
+	assert.Equal(t, 0, env.TimeStep)
+
+	// scripted agent moving forward
+	forward := func(_ []float64) ([]float64, error) {
+		return []float64{0.5, 1}, nil
+	}
+	_, info, err = gym.RunEpisode(forward)
+	require.NoError(t, err)
+	assert.Equal(t, gym.State().Hero.Location, info.Location)
+
+	// the wrong action
+	_, err = gym.Reset()
+	require.NoError(t, err)
+	_, _, _, _, err = gym.Step([]float64{0.5})
+	assert.Error(t, err)
+	_, _, _, _, err = gym.Step([]float64{math.NaN(), 0.5})
+	assert.ErrorIs(t, err, ErrOutputIsNaN)
+}
+
+func TestBox(t *testing.T) {
+	box := NewBox(2, 0, 1)
+	assert.Equal(t, 2, box.Shape())
+	assert.True(t, box.Contains([]float64{0, 1}))
+	assert.False(t, box.Contains([]float64{0, 1.1}))
+	assert.False(t, box.Contains([]float64{0}))
+	assert.False(t, box.Contains([]float64{math.NaN(), 0}))
+	assert.Equal(t, []float64{0, 1}, box.Clip([]float64{-1, 2}))
+}
@@ -0,0 +1,138 @@
+package maze
+
+import (
+	"math"
+)
+
+// VisitationHeatmap is the grid of counts of agents' visits to the maze cells. The grid cells reachable by the agent
+// from the start location are used to estimate the coverage of the maze by visited cells.
+type VisitationHeatmap struct {
+	// The grid of the maze with cells reachable from the agent's start location
+	Field *DistanceField
+	// The number of visits per grid cell in row major order
+	Counts []int
+	// The maximal number of visits per cell
+	MaxCount int
+}
+
+// NewVisitationHeatmap creates new empty heatmap over the maze with given size of the grid cell. The cell is considered
+// reachable if the agent's body can be located within it, i.e., some point of the cell is farther from maze walls than
+// the agent's radius, and it's connected with the start location.
+func NewVisitationHeatmap(env *Environment, cellSize float64) (*VisitationHeatmap, error) {
+	clearance := math.Max(0, env.Hero.Radius-cellSize*math.Sqrt2/2)
+	field, err := NewDistanceField(env.Lines, env.Hero.Location, cellSize, clearance)
+	if err != nil {
+		return nil, err
+	}
+	return &VisitationHeatmap{
+		Field:  field,
+		Counts: make([]int, field.Cols*field.Rows),
+	}, nil
+}
+
+// AddPoint adds visit of the cell holding given point
+func (h *VisitationHeatmap) AddPoint(p Point) {
+	if col, row, ok := h.Field.CellAt(p); ok {
+		h.visit(col, row)
+	}
+}
+
+// AddPath adds visits of cells along the path. The cell is visited once per path, even if the path crosses it
+// multiple times.
+func (h *VisitationHeatmap) AddPath(path []Point) {
+	visited := make(map[int]bool)
+	visit := func(p Point) {
+		if col, row, ok := h.Field.CellAt(p); ok && !visited[row*h.Field.Cols+col] {
+			visited[row*h.Field.Cols+col] = true
+			h.visit(col, row)
+		}
+	}
+	for i, p := range path {
+		if i > 0 {
+			// rasterize the segment between path points to not skip cells
+			prev := path[i-1]
+			steps := int(math.Ceil(prev.Distance(p) / (h.Field.CellSize / 2)))
+			for s := 1; s < steps; s++ {
+				t := float64(s) / float64(steps)
+				visit(Point{X: prev.X + (p.X-prev.X)*t, Y: prev.Y + (p.Y-prev.Y)*t})
+			}
+		}
+		visit(p)
+	}
+}
+
+// AddRecords adds visits of the agents' final locations from the record store. If trajectories requested, the full
+// paths are used for agents which have them, i.e., the maze solvers.
+func (h *VisitationHeatmap) AddRecords(rs *RecordStore, trajectories bool) {
+	for i := range rs.Records {
+		r := &rs.Records[i]
+		if trajectories {
+			if s := rs.FindSolver(r.AgentID); s != nil && len(s.Path) > 0 {
+				h.AddPath(s.Path)
+				continue
+			}
+		}
+		h.AddPoint(Point{X: r.X, Y: r.Y})
+	}
+}
+
+// Count returns the number of visits of the cell at given column and row
+func (h *VisitationHeatmap) Count(col, row int) int {
+	if !h.Field.contains(col, row) {
+		return 0
+	}
+	return h.Counts[row*h.Field.Cols+col]
+}
+
+// Intensity returns the number of visits of the cell normalized by maxCount into [0, 1] range. The logarithmic scale
+// is applied to counts if requested.
+func (h *VisitationHeatmap) Intensity(col, row, maxCount int, logScale bool) float64 {
+	count := h.Count(col, row)
+	if count == 0 || maxCount == 0 {
+		return 0
+	}
+	if logScale {
+		return math.Min(math.Log1p(float64(count))/math.Log1p(float64(maxCount)), 1)
+	}
+	return math.Min(float64(count)/float64(maxCount), 1)
+}
+
+// ReachableCells returns the number of grid cells reachable from the agent's start location
+func (h *VisitationHeatmap) ReachableCells() int {
+	reachable := 0
+	for row := 0; row < h.Field.Rows; row++ {
+		for col := 0; col < h.Field.Cols; col++ {
+			if !math.IsInf(h.Field.CellDistance(col, row), 1) {
+				reachable++
+			}
+		}
+	}
+	return reachable
+}
+
+// Coverage returns the fraction of reachable cells visited at least once
+func (h *VisitationHeatmap) Coverage() float64 {
+	reachable, visited := 0, 0
+	for row := 0; row < h.Field.Rows; row++ {
+		for col := 0; col < h.Field.Cols; col++ {
+			if math.IsInf(h.Field.CellDistance(col, row), 1) {
+				continue
+			}
+			reachable++
+			if h.Count(col, row) > 0 {
+				visited++
+			}
+		}
+	}
+	if reachable == 0 {
+		return 0
+	}
+	return float64(visited) / float64(reachable)
+}
+
+// visit is to increment the number of visits of the cell
+func (h *VisitationHeatmap) visit(col, row int) {
+	i := row*h.Field.Cols + col
+	h.Counts[i]++
+	h.MaxCount = max(h.MaxCount, h.Counts[i])
+}
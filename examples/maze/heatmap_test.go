@@ -0,0 +1,102 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestNewVisitationHeatmap(t *testing.T) {
+	h := createTestHeatmap(t)
+	assert.Equal(t, 10.0, h.Field.CellSize)
+	assert.Len(t, h.Counts, h.Field.Cols*h.Field.Rows)
+	// only the left half of the box is reachable from the start
+	assert.Equal(t, 50, h.ReachableCells())
+	assert.Zero(t, h.Coverage())
+
+	_, err := NewVisitationHeatmap(createTestHeatmapEnvironment(), 0)
+	assert.Error(t, err)
+}
+
+func TestVisitationHeatmap_AddPoint(t *testing.T) {
+	h := createTestHeatmap(t)
+	h.AddPoint(Point{X: 25, Y: 50})
+	h.AddPoint(Point{X: 26, Y: 51})
+	col, row, ok := h.Field.CellAt(Point{X: 25, Y: 50})
+	require.True(t, ok)
+	assert.Equal(t, 2, h.Count(col, row))
+	assert.Equal(t, 2, h.MaxCount)
+	assert.Equal(t, 1.0/50, h.Coverage())
+
+	// the unreachable cells are counted but not covered
+	h.AddPoint(Point{X: 75, Y: 50})
+	col, row, _ = h.Field.CellAt(Point{X: 75, Y: 50})
+	assert.Equal(t, 1, h.Count(col, row))
+	assert.Equal(t, 1.0/50, h.Coverage())
+
+	// outside the grid
+	h.AddPoint(Point{X: 500, Y: 500})
+	assert.Zero(t, h.Count(-1, 100))
+}
+
+func TestVisitationHeatmap_AddPath(t *testing.T) {
+	h := createTestHeatmap(t)
+	// the path skips cells between points and returns to the start cell
+	h.AddPath([]Point{{X: 5, Y: 5}, {X: 45, Y: 5}, {X: 6, Y: 6}})
+	for x := 5.0; x < 50; x += 10 {
+		col, row, _ := h.Field.CellAt(Point{X: x, Y: 5})
+		assert.Equal(t, 1, h.Count(col, row), x)
+	}
+	assert.Equal(t, 1, h.MaxCount)
+	assert.Equal(t, 5.0/50, h.Coverage())
+}
+
+func TestVisitationHeatmap_AddRecords(t *testing.T) {
+	rs := &RecordStore{
+		Records: []AgentRecord{
+			{AgentID: 0, X: 25, Y: 50},
+			{AgentID: 1, X: 45, Y: 95, GotExit: true},
+		},
+		Solvers: []SolverRecord{
+			{AgentID: 1, Path: []Point{{X: 45, Y: 75}, {X: 45, Y: 85}, {X: 45, Y: 95}}},
+		},
+	}
+	h := createTestHeatmap(t)
+	h.AddRecords(rs, false)
+	assert.Equal(t, 2.0/50, h.Coverage())
+
+	h = createTestHeatmap(t)
+	h.AddRecords(rs, true)
+	assert.Equal(t, 4.0/50, h.Coverage())
+}
+
+func TestVisitationHeatmap_Intensity(t *testing.T) {
+	h := createTestHeatmap(t)
+	for i := 0; i < 9; i++ {
+		h.AddPoint(Point{X: 25, Y: 50})
+	}
+	h.AddPoint(Point{X: 5, Y: 5})
+	col, row, _ := h.Field.CellAt(Point{X: 5, Y: 5})
+
+	assert.InDelta(t, 1.0/9, h.Intensity(col, row, h.MaxCount, false), 1e-12)
+	assert.InDelta(t, math.Log(2)/math.Log(10), h.Intensity(col, row, h.MaxCount, true), 1e-12)
+	// the common maximum of compared heatmaps
+	assert.InDelta(t, 1.0/18, h.Intensity(col, row, 18, false), 1e-12)
+	assert.Zero(t, h.Intensity(0, 0, h.MaxCount, true))
+	assert.Zero(t, h.Intensity(col, row, 0, false))
+}
+
+func createTestHeatmap(t *testing.T) *VisitationHeatmap {
+	h, err := NewVisitationHeatmap(createTestHeatmapEnvironment(), 10)
+	require.NoError(t, err)
+	return h
+}
+
+// createTestHeatmapEnvironment creates the box maze divided by the wall into two halves
+func createTestHeatmapEnvironment() *Environment {
+	return &Environment{
+		Lines: append(createBoxLines(100, 100), Line{A: Point{X: 50, Y: 0}, B: Point{X: 50, Y: 100}}),
+		Hero:  Agent{Location: Point{X: 25, Y: 50}, Radius: 4},
+	}
+}
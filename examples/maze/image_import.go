@@ -0,0 +1,267 @@
+package maze
+
+import (
+	"errors"
+	_ "golang.org/x/image/bmp"
+	"image"
+	"image/color"
+	_ "image/png"
+	"io"
+	"math"
+)
+
+// ImageImportOptions is the options to import maze from the raster image
+type ImageImportOptions struct {
+	// The scale factor to convert pixel coordinates into maze coordinates
+	Scale float64
+	// The maximal deviation of simplified wall segments from traced contours in pixels
+	Tolerance float64
+	// The luminance threshold [0, 1], pixels darker than it are considered as walls
+	WallThreshold float64
+	// The color of pixels marking the start location of the agent
+	StartColor color.Color
+	// The color of pixels marking the maze exit
+	ExitColor color.Color
+	// The maximal Euclidean distance in RGB space [0, 255] for pixel to be considered as marker
+	ColorTolerance float64
+	// The initial heading of the agent in degrees
+	StartHeading float64
+}
+
+// DefaultImageImportOptions returns default maze image import options: pure green start marker, pure red exit marker,
+// one pixel simplification tolerance and pixel coordinates kept as is.
+func DefaultImageImportOptions() ImageImportOptions {
+	return ImageImportOptions{
+		Scale:          1,
+		Tolerance:      1,
+		WallThreshold:  0.5,
+		StartColor:     color.RGBA{G: 255, A: 255},
+		ExitColor:      color.RGBA{R: 255, A: 255},
+		ColorTolerance: 64,
+	}
+}
+
+// ImportMazeImage reads maze environment from the PNG or BMP image
+func ImportMazeImage(r io.Reader, opts ImageImportOptions) (*Environment, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewEnvironmentFromImage(img, opts)
+}
+
+// NewEnvironmentFromImage creates maze environment from the image. The dark pixels are walls, the colored markers
+// give the start location and the maze exit. The contours of walls are traced along pixel edges and simplified into
+// line segments with given tolerance.
+func NewEnvironmentFromImage(img image.Image, opts ImageImportOptions) (*Environment, error) {
+	if opts.Scale <= 0 {
+		return nil, errors.New("scale must be positive")
+	}
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	walls := make([]bool, width*height)
+	var start, exit markerCentroid
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			switch {
+			case colorDistance(c, opts.StartColor) <= opts.ColorTolerance:
+				start.add(x, y)
+			case colorDistance(c, opts.ExitColor) <= opts.ColorTolerance:
+				exit.add(x, y)
+			default:
+				walls[y*width+x] = luminance(c) < opts.WallThreshold
+			}
+		}
+	}
+	if start.count == 0 {
+		return nil, errors.New("start marker not found")
+	}
+	if exit.count == 0 {
+		return nil, errors.New("exit marker not found")
+	}
+
+	env := &Environment{
+		Hero:     NewAgent(),
+		Lines:    make([]Line, 0),
+		MazeExit: exit.center(opts.Scale),
+	}
+	env.Hero.Location = start.center(opts.Scale)
+	env.Hero.Heading = opts.StartHeading
+
+	for _, contour := range traceContours(walls, width, height) {
+		points := simplifyContour(contour, opts.Tolerance)
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			env.Lines = append(env.Lines, NewLine(
+				Point{X: a.X * opts.Scale, Y: a.Y * opts.Scale},
+				Point{X: b.X * opts.Scale, Y: b.Y * opts.Scale}))
+		}
+	}
+	if len(env.Lines) == 0 {
+		return nil, errors.New("no walls found")
+	}
+
+	if err := env.initialize(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// markerCentroid is to accumulate centroid of the marker pixels
+type markerCentroid struct {
+	sumX, sumY float64
+	count      int
+}
+
+func (m *markerCentroid) add(x, y int) {
+	m.sumX += float64(x) + 0.5
+	m.sumY += float64(y) + 0.5
+	m.count++
+}
+
+func (m *markerCentroid) center(scale float64) Point {
+	return Point{X: m.sumX / float64(m.count) * scale, Y: m.sumY / float64(m.count) * scale}
+}
+
+// luminance returns the luminance of the color in range [0, 1]. The transparent pixels are considered as white.
+func luminance(c color.Color) float64 {
+	_, _, _, a := c.RGBA()
+	if a < 0x8000 {
+		return 1
+	}
+	return float64(color.Gray16Model.Convert(c).(color.Gray16).Y) / 0xffff
+}
+
+// colorDistance returns the Euclidean distance between colors in RGB space [0, 255]
+func colorDistance(c1, c2 color.Color) float64 {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, _ := c2.RGBA()
+	if a1 < 0x8000 {
+		return math.Inf(1)
+	}
+	dr := float64(r1>>8) - float64(r2>>8)
+	dg := float64(g1>>8) - float64(g2>>8)
+	db := float64(b1>>8) - float64(b2>>8)
+	return math.Sqrt(dr*dr + dg*dg + db*db)
+}
+
+// pixelEdge is the directed edge between pixel corners at the boundary of the wall region
+type pixelEdge struct {
+	x, y   int
+	dx, dy int
+}
+
+// traceContours is to trace the closed contours of the wall regions along pixel edges. The contours are traversed
+// clockwise with the wall on the right hand side.
+func traceContours(walls []bool, width, height int) [][]Point {
+	isWall := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < width && y < height && walls[y*width+x]
+	}
+	edges := make([]pixelEdge, 0)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if !isWall(x, y) {
+				continue
+			}
+			if !isWall(x, y-1) {
+				edges = append(edges, pixelEdge{x: x, y: y, dx: 1})
+			}
+			if !isWall(x+1, y) {
+				edges = append(edges, pixelEdge{x: x + 1, y: y, dy: 1})
+			}
+			if !isWall(x, y+1) {
+				edges = append(edges, pixelEdge{x: x + 1, y: y + 1, dx: -1})
+			}
+			if !isWall(x-1, y) {
+				edges = append(edges, pixelEdge{x: x, y: y + 1, dy: -1})
+			}
+		}
+	}
+
+	// index edges by start vertex
+	outgoing := make(map[[2]int][]int)
+	for i, e := range edges {
+		key := [2]int{e.x, e.y}
+		outgoing[key] = append(outgoing[key], i)
+	}
+
+	used := make([]bool, len(edges))
+	contours := make([][]Point, 0)
+	for i := range edges {
+		if used[i] {
+			continue
+		}
+		contour := make([]Point, 0)
+		for current := i; current >= 0 && !used[current]; {
+			used[current] = true
+			e := edges[current]
+			contour = append(contour, Point{X: float64(e.x), Y: float64(e.y)})
+			current = nextContourEdge(e, edges, used, outgoing[[2]int{e.x + e.dx, e.y + e.dy}])
+		}
+		contours = append(contours, contour)
+	}
+	return contours
+}
+
+// nextContourEdge is to select the unused edge to continue contour after given one preferring the right turn, which
+// separates diagonally touching wall pixels into different contours. Returns -1 if no edges left.
+func nextContourEdge(e pixelEdge, edges []pixelEdge, used []bool, candidates []int) int {
+	directions := [][2]int{{-e.dy, e.dx}, {e.dx, e.dy}, {e.dy, -e.dx}}
+	for _, d := range directions {
+		for _, c := range candidates {
+			if !used[c] && edges[c].dx == d[0] && edges[c].dy == d[1] {
+				return c
+			}
+		}
+	}
+	return -1
+}
+
+// simplifyContour is to remove collinear points from the closed contour and to simplify it using Douglas-Peucker
+// algorithm with given tolerance
+func simplifyContour(contour []Point, tolerance float64) []Point {
+	points := make([]Point, 0, len(contour))
+	for i, p := range contour {
+		prev := contour[(i+len(contour)-1)%len(contour)]
+		next := contour[(i+1)%len(contour)]
+		if (p.X-prev.X)*(next.Y-p.Y)-(p.Y-prev.Y)*(next.X-p.X) != 0 {
+			points = append(points, p)
+		}
+	}
+	if tolerance <= 0 || len(points) <= 4 {
+		return points
+	}
+
+	// split closed contour at the point farthest from the first one
+	farthest := 0
+	for i, p := range points {
+		if p.Distance(points[0]) > points[farthest].Distance(points[0]) {
+			farthest = i
+		}
+	}
+	first := douglasPeucker(points[:farthest+1], tolerance)
+	closing := append(append([]Point{}, points[farthest:]...), points[0])
+	second := douglasPeucker(closing, tolerance)
+	return append(first[:len(first)-1], second[:len(second)-1]...)
+}
+
+// douglasPeucker is to simplify the polyline keeping its end points
+func douglasPeucker(points []Point, tolerance float64) []Point {
+	if len(points) < 3 {
+		return append([]Point{}, points...)
+	}
+	chord := NewLine(points[0], points[len(points)-1])
+	index, maxDistance := 0, 0.0
+	for i := 1; i < len(points)-1; i++ {
+		if d := chord.Distance(points[i]); d > maxDistance {
+			index, maxDistance = i, d
+		}
+	}
+	if maxDistance <= tolerance {
+		return []Point{points[0], points[len(points)-1]}
+	}
+	left := douglasPeucker(points[:index+1], tolerance)
+	right := douglasPeucker(points[index:], tolerance)
+	return append(left[:len(left)-1], right...)
+}
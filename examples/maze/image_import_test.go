@@ -0,0 +1,118 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/bmp"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"testing"
+)
+
+func TestNewEnvironmentFromImage(t *testing.T) {
+	img := createTestMazeImage()
+	opts := DefaultImageImportOptions()
+	opts.Scale = 2
+	opts.StartHeading = 90
+
+	env, err := NewEnvironmentFromImage(img, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, Point{X: 11, Y: 11}, env.Hero.Location)
+	assert.Equal(t, 90.0, env.Hero.Heading)
+	assert.Equal(t, Point{X: 71, Y: 51}, env.MazeExit)
+	// the outer contour of the border and the inner one going around the internal wall
+	assert.Len(t, env.Lines, 12)
+	for _, l := range env.Lines {
+		assert.True(t, l.A.X == l.B.X || l.A.Y == l.B.Y, "line must be axis aligned: %v", l)
+	}
+	assert.Contains(t, env.Lines, NewLine(Point{X: 0, Y: 0}, Point{X: 80, Y: 0}))
+	assert.Contains(t, env.Lines, NewLine(Point{X: 44, Y: 4}, Point{X: 44, Y: 40}))
+	assert.Contains(t, env.Lines, NewLine(Point{X: 44, Y: 40}, Point{X: 40, Y: 40}))
+}
+
+func TestNewEnvironmentFromImage_simplify(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 60, 60))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	// the diagonal staircase wall
+	for i := 10; i < 50; i++ {
+		img.Set(i, i, color.Black)
+		img.Set(i+1, i, color.Black)
+	}
+	img.Set(5, 50, color.RGBA{G: 255, A: 255})
+	img.Set(50, 5, color.RGBA{R: 255, A: 255})
+
+	opts := DefaultImageImportOptions()
+	opts.Tolerance = 0
+	env, err := NewEnvironmentFromImage(img, opts)
+	require.NoError(t, err)
+	pixelLines := len(env.Lines)
+
+	opts.Tolerance = 1.5
+	env, err = NewEnvironmentFromImage(img, opts)
+	require.NoError(t, err)
+	assert.Less(t, len(env.Lines), 10)
+	assert.Greater(t, pixelLines, 100)
+	for _, l := range env.Lines {
+		assert.True(t, l.Length() > 0)
+	}
+}
+
+func TestImportMazeImage(t *testing.T) {
+	img := createTestMazeImage()
+	encoders := map[string]func(buf *bytes.Buffer) error{
+		"png": func(buf *bytes.Buffer) error { return png.Encode(buf, img) },
+		"bmp": func(buf *bytes.Buffer) error { return bmp.Encode(buf, img) },
+	}
+	for name, encode := range encoders {
+		buf := bytes.NewBuffer(nil)
+		require.NoError(t, encode(buf), name)
+
+		env, err := ImportMazeImage(buf, DefaultImageImportOptions())
+		require.NoError(t, err, name)
+		assert.Len(t, env.Lines, 12, name)
+		assert.Equal(t, Point{X: 5.5, Y: 5.5}, env.Hero.Location, name)
+		assert.Equal(t, Point{X: 35.5, Y: 25.5}, env.MazeExit, name)
+	}
+}
+
+func TestNewEnvironmentFromImage_errors(t *testing.T) {
+	opts := DefaultImageImportOptions()
+
+	noMarkers := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	_, err := NewEnvironmentFromImage(noMarkers, opts)
+	assert.EqualError(t, err, "start marker not found")
+
+	noExit := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	noExit.Set(1, 1, opts.StartColor)
+	_, err = NewEnvironmentFromImage(noExit, opts)
+	assert.EqualError(t, err, "exit marker not found")
+
+	noWalls := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(noWalls, noWalls.Bounds(), image.White, image.Point{}, draw.Src)
+	noWalls.Set(1, 1, opts.StartColor)
+	noWalls.Set(8, 8, opts.ExitColor)
+	_, err = NewEnvironmentFromImage(noWalls, opts)
+	assert.EqualError(t, err, "no walls found")
+
+	opts.Scale = 0
+	_, err = NewEnvironmentFromImage(noWalls, opts)
+	assert.Error(t, err)
+}
+
+// createTestMazeImage creates 40x30 maze image with two pixels wide border, the internal wall, and the start and exit
+// markers of 3x3 pixels
+func createTestMazeImage() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), image.Black, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(2, 2, 38, 28), image.White, image.Point{}, draw.Src)
+	// the internal wall
+	draw.Draw(img, image.Rect(20, 2, 22, 20), image.Black, image.Point{}, draw.Src)
+	// the markers
+	draw.Draw(img, image.Rect(4, 4, 7, 7), image.NewUniform(color.RGBA{G: 255, A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(34, 24, 37, 27), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+	return img
+}
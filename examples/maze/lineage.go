@@ -0,0 +1,392 @@
+package maze
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/experiment/utils"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// The name of the file to store the lineage graph of the trial
+const lineageFileName = "lineage.json"
+
+// LineageNode is the node of the lineage graph holding info about evaluated agent and its parents
+type LineageNode struct {
+	// The ID of the agent, the same as in the agent record
+	AgentID int `json:"agent_id"`
+	// The ID of the agent's genome within its generation
+	GenomeID int `json:"genome_id"`
+	// The generation when agent was evaluated
+	Generation int `json:"generation"`
+	// The ID of species the agent belongs to
+	SpeciesID int `json:"species_id"`
+	// The agent IDs of parents from the previous generation guessed from genes, empty if parents not found
+	InferredParentIDs []int `json:"inferred_parent_ids"`
+	// The confidence of inferred parents in range [0, 1], zero if parents not found
+	ParentsConfidence float64 `json:"parents_confidence"`
+	// The fitness and novelty of the agent
+	Fitness float64 `json:"fitness"`
+	Novelty float64 `json:"novelty"`
+	// The flag to indicate whether agent reached the maze exit
+	GotExit bool `json:"got_exit"`
+	// The flag to indicate whether agent's novelty item was added to the novelty archive
+	Archived bool `json:"archived"`
+}
+
+// Lineage is the lineage graph of agents evaluated during one trial.
+//
+// The NEAT library keeps no ancestry of organisms, and genomes are renumbered each generation. Thus, the parents are
+// only guessed from genes when agent is added: the first parent is the agent of the previous generation sharing the most
+// genes with equal innovation numbers, with ties resolved by the number of genes with equal weights. The second parent
+// is the agent which explains the rest of genes, i.e., genes missing in the first parent or inherited with weights
+// equal to its own. The second parent is not found if offspring mutated the rest of genes or averaged parents' weights.
+// The confidence of the guess is the fraction of agent's genes inherited with weights equal to the parents' ones,
+// divided by the number of candidates that match genes as good as the first parent.
+type Lineage struct {
+	// The ID of the trial
+	TrialID int `json:"trial_id"`
+	// The nodes of evaluated agents in order of agent IDs
+	Nodes []LineageNode `json:"nodes"`
+
+	// the index of nodes by agent IDs
+	index map[int]int
+	// the generation of agents being added
+	generation int
+	// the genes of agents of the previous and current generations
+	previous, current []lineageGenes
+}
+
+// lineageGenes the genes of the agent's genome as weights by innovation numbers
+type lineageGenes struct {
+	agentID int
+	weights map[int64]float64
+}
+
+// NewLineage creates new empty lineage graph for the trial
+func NewLineage(trialID int) *Lineage {
+	return &Lineage{
+		TrialID:    trialID,
+		Nodes:      make([]LineageNode, 0),
+		index:      make(map[int]int),
+		generation: -1,
+	}
+}
+
+// ReadLineage reads lineage graph from JSON
+func ReadLineage(r io.Reader) (*Lineage, error) {
+	l := NewLineage(0)
+	if err := json.NewDecoder(r).Decode(l); err != nil {
+		return nil, err
+	}
+	for i, n := range l.Nodes {
+		l.index[n.AgentID] = i
+	}
+	return l, nil
+}
+
+// Add adds evaluated organism with given agent record to the lineage graph. The organisms must be added in order of
+// generations, and their parents are inferred among the organisms added in the previous generation.
+func (l *Lineage) Add(org *genetics.Organism, record *AgentRecord) {
+	if record.Generation != l.generation {
+		if record.Generation == l.generation+1 {
+			l.previous = l.current
+		} else {
+			l.previous = nil
+		}
+		l.current = nil
+		l.generation = record.Generation
+	}
+	genes := lineageGenes{agentID: record.AgentID, weights: make(map[int64]float64, len(org.Genotype.Genes))}
+	for _, g := range org.Genotype.Genes {
+		genes.weights[g.InnovationNum] = g.Link.ConnectionWeight
+	}
+	node := LineageNode{
+		AgentID:    record.AgentID,
+		GenomeID:   org.Genotype.Id,
+		Generation: record.Generation,
+		SpeciesID:  record.SpeciesID,
+		Fitness:    record.Fitness,
+		Novelty:    record.Novelty,
+		GotExit:    record.GotExit,
+	}
+	node.InferredParentIDs, node.ParentsConfidence = inferParents(genes, l.previous)
+	if org.Data != nil {
+		if item, ok := org.Data.Value.(*neatns.NoveltyItem); ok {
+			node.Archived = item.IsArchived()
+		}
+	}
+	l.current = append(l.current, genes)
+	l.index[node.AgentID] = len(l.Nodes)
+	l.Nodes = append(l.Nodes, node)
+}
+
+// Node returns the node of the agent with given ID or nil if not found
+func (l *Lineage) Node(agentID int) *LineageNode {
+	if i, ok := l.index[agentID]; ok {
+		return &l.Nodes[i]
+	}
+	return nil
+}
+
+// Solvers returns the nodes of agents that reached the maze exit
+func (l *Lineage) Solvers() []LineageNode {
+	solvers := make([]LineageNode, 0)
+	for _, n := range l.Nodes {
+		if n.GotExit {
+			solvers = append(solvers, n)
+		}
+	}
+	return solvers
+}
+
+// Ancestry returns the subgraph with the agents with given IDs and all their inferred ancestors
+func (l *Lineage) Ancestry(agentIDs ...int) (*Lineage, error) {
+	visited := make(map[int]bool)
+	queue := make([]int, 0, len(agentIDs))
+	for _, id := range agentIDs {
+		if l.Node(id) == nil {
+			return nil, fmt.Errorf("agent not found in lineage: %d", id)
+		}
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		if n := l.Node(id); n != nil {
+			queue = append(queue, n.InferredParentIDs...)
+		}
+	}
+	ids := make([]int, 0, len(visited))
+	for id := range visited {
+		if l.Node(id) != nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	ancestry := NewLineage(l.TrialID)
+	for _, id := range ids {
+		ancestry.index[id] = len(ancestry.Nodes)
+		ancestry.Nodes = append(ancestry.Nodes, *l.Node(id))
+	}
+	return ancestry, nil
+}
+
+// SteppingStones returns the ancestors of the agent with given ID that were added to the novelty archive in order of
+// generations. The ancestors are inferred from genes, thus the stepping stones are the best guess rather than the
+// true ancestry, see ParentsConfidence of the nodes.
+func (l *Lineage) SteppingStones(agentID int) ([]LineageNode, error) {
+	ancestry, err := l.Ancestry(agentID)
+	if err != nil {
+		return nil, err
+	}
+	stones := make([]LineageNode, 0)
+	for _, n := range ancestry.Nodes {
+		if n.Archived && n.AgentID != agentID {
+			stones = append(stones, n)
+		}
+	}
+	return stones, nil
+}
+
+// WriteJSON writes lineage graph as JSON
+func (l *Lineage) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(l)
+}
+
+// WriteGraphML writes lineage graph as GraphML with edges directed from parents to offspring
+func (l *Lineage) WriteGraphML(w io.Writer) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type key struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+	}
+	type graph struct {
+		ID          string `xml:"id,attr"`
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	doc := struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{"genome_id", "node", "genome_id", "int"},
+			{"generation", "node", "generation", "int"},
+			{"species_id", "node", "species_id", "int"},
+			{"fitness", "node", "fitness", "double"},
+			{"novelty", "node", "novelty", "double"},
+			{"got_exit", "node", "got_exit", "boolean"},
+			{"archived", "node", "archived", "boolean"},
+			{"parents_confidence", "node", "parents_confidence", "double"},
+		},
+		Graph: graph{ID: fmt.Sprintf("trial_%d", l.TrialID), EdgeDefault: "directed"},
+	}
+	nodeID := func(agentID int) string {
+		return fmt.Sprintf("n%d", agentID)
+	}
+	for _, n := range l.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{
+			ID: nodeID(n.AgentID),
+			Data: []data{
+				{"genome_id", strconv.Itoa(n.GenomeID)},
+				{"generation", strconv.Itoa(n.Generation)},
+				{"species_id", strconv.Itoa(n.SpeciesID)},
+				{"fitness", strconv.FormatFloat(n.Fitness, 'g', -1, 64)},
+				{"novelty", strconv.FormatFloat(n.Novelty, 'g', -1, 64)},
+				{"got_exit", strconv.FormatBool(n.GotExit)},
+				{"archived", strconv.FormatBool(n.Archived)},
+				{"parents_confidence", strconv.FormatFloat(n.ParentsConfidence, 'g', -1, 64)},
+			},
+		})
+		for _, p := range n.InferredParentIDs {
+			if l.Node(p) != nil {
+				doc.Graph.Edges = append(doc.Graph.Edges, edge{Source: nodeID(p), Target: nodeID(n.AgentID)})
+			}
+		}
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// inferParents is to guess parents of the agent with given genes among the candidates from the previous generation.
+// Returns agent IDs of found parents and the confidence of the guess.
+func inferParents(genes lineageGenes, candidates []lineageGenes) ([]int, float64) {
+	parents := make([]int, 0, 2)
+	acceptAll := func(_ int64, _ float64, _ bool) bool {
+		return true
+	}
+	mom := bestParent(genes.weights, candidates, acceptAll, -1)
+	if mom < 0 {
+		return parents, 0
+	}
+	parents = append(parents, candidates[mom].agentID)
+
+	// the genes not inherited from the first parent as is
+	momWeights := candidates[mom].weights
+	dad := bestParent(genes.weights, candidates, func(innovation int64, weight float64, equal bool) bool {
+		momWeight, ok := momWeights[innovation]
+		return !ok || (equal && momWeight != weight)
+	}, mom)
+	var dadWeights map[int64]float64
+	if dad >= 0 {
+		parents = append(parents, candidates[dad].agentID)
+		dadWeights = candidates[dad].weights
+	}
+
+	// the fraction of genes inherited as is from the parents
+	inherited := 0
+	for innovation, weight := range genes.weights {
+		if w, ok := momWeights[innovation]; ok && w == weight {
+			inherited++
+		} else if w, ok = dadWeights[innovation]; ok && w == weight {
+			inherited++
+		}
+	}
+	// the candidates that could be the first parent as well
+	alternatives := 0
+	momShared, momEqual, momMissing := parentScore(genes.weights, candidates[mom], acceptAll)
+	for i, c := range candidates {
+		if i == mom || i == dad {
+			continue
+		}
+		if shared, equal, missing := parentScore(genes.weights, c, acceptAll); shared == momShared &&
+			equal == momEqual && missing == momMissing {
+			alternatives++
+		}
+	}
+	return parents, float64(inherited) / float64(len(genes.weights)) / float64(1+alternatives)
+}
+
+// bestParent is to find the index of candidate sharing the most genes accepted by the filter, with ties resolved by
+// the number of genes with equal weights and then by the number of candidate's genes missing in offspring. The filter
+// receives the innovation number and weight of offspring's gene and whether candidate's weight is equal to it.
+// Returns -1 if no candidate shares accepted genes.
+func bestParent(weights map[int64]float64, candidates []lineageGenes, accept func(innovation int64, weight float64, equal bool) bool, skip int) int {
+	best, bestShared, bestEqual, bestMissing := -1, 0, 0, 0
+	for i, c := range candidates {
+		if i == skip {
+			continue
+		}
+		shared, equal, missing := parentScore(weights, c, accept)
+		if shared == 0 {
+			continue
+		}
+		if best < 0 || shared > bestShared ||
+			(shared == bestShared && (equal > bestEqual || (equal == bestEqual && missing < bestMissing))) {
+			best, bestShared, bestEqual, bestMissing = i, shared, equal, missing
+		}
+	}
+	return best
+}
+
+// parentScore is to count the offspring's genes accepted by the filter which are shared with the candidate, the number
+// of them with equal weights, and the number of candidate's genes missing in offspring
+func parentScore(weights map[int64]float64, candidate lineageGenes, accept func(innovation int64, weight float64, equal bool) bool) (shared, equal, missing int) {
+	for innovation, weight := range weights {
+		w, ok := candidate.weights[innovation]
+		if !ok || !accept(innovation, weight, w == weight) {
+			continue
+		}
+		shared++
+		if w == weight {
+			equal++
+		}
+	}
+	missing = len(candidate.weights) - shared
+	return shared, equal, missing
+}
+
+// storeLineage is to store the lineage graph of the current trial into the output directory if it's tracked
+func storeLineage(outputPath string) {
+	if trialSim.lineage == nil {
+		return
+	}
+	linPath := filepath.Join(utils.CreateOutDirForTrial(outputPath, trialSim.trialID), lineageFileName)
+	linFile, err := os.Create(linPath)
+	if err == nil {
+		err = trialSim.lineage.WriteJSON(linFile)
+		if closeErr := linFile.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to store lineage graph, reason: %s\n", err))
+	}
+}
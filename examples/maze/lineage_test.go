@@ -0,0 +1,241 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLineage_Add(t *testing.T) {
+	l := createTestLineage()
+	require.Len(t, l.Nodes, 7)
+
+	// the first generation has no parents
+	for _, id := range []int{0, 1, 2} {
+		assert.Empty(t, l.Node(id).InferredParentIDs, id)
+	}
+	// the duplicate of the parent
+	assert.Equal(t, []int{0}, l.Node(3).InferredParentIDs)
+	// the mutated offspring with a new gene
+	assert.Equal(t, []int{1}, l.Node(4).InferredParentIDs)
+	// the offspring of mating, the most genes are from the first parent
+	assert.Equal(t, []int{1, 2}, l.Node(5).InferredParentIDs)
+	// the offspring of mating in the next generation
+	assert.Equal(t, []int{5, 4}, l.Node(6).InferredParentIDs)
+
+	// the confidence of the guess
+	assert.Zero(t, l.Node(0).ParentsConfidence)
+	assert.Equal(t, 1.0, l.Node(3).ParentsConfidence)
+	assert.Equal(t, 0.5, l.Node(4).ParentsConfidence, "only half of genes inherited as is")
+	assert.Equal(t, 1.0, l.Node(5).ParentsConfidence)
+	assert.Equal(t, 1.0, l.Node(6).ParentsConfidence)
+
+	node := l.Node(6)
+	assert.Equal(t, 2, node.Generation)
+	assert.Equal(t, 8, node.GenomeID)
+	assert.True(t, node.GotExit)
+	assert.Nil(t, l.Node(7))
+
+	assert.True(t, l.Node(1).Archived)
+	assert.False(t, l.Node(2).Archived)
+}
+
+func TestLineage_Add_generationGap(t *testing.T) {
+	l := NewLineage(1)
+	l.Add(createTestLineageOrganism(0, nil, map[int64]float64{1: 0.5}),
+		&AgentRecord{AgentID: 0, Generation: 0})
+	l.Add(createTestLineageOrganism(0, nil, map[int64]float64{1: 0.5}),
+		&AgentRecord{AgentID: 1, Generation: 2})
+	assert.Empty(t, l.Node(1).InferredParentIDs, "parents must be from the previous generation only")
+}
+
+func TestLineage_Add_ambiguousParents(t *testing.T) {
+	l := NewLineage(1)
+	for i := 0; i < 3; i++ {
+		l.Add(createTestLineageOrganism(i, nil, map[int64]float64{1: 0.5, 2: 0.6}),
+			&AgentRecord{AgentID: i, Generation: 0})
+	}
+	l.Add(createTestLineageOrganism(0, nil, map[int64]float64{1: 0.5, 2: 0.6}),
+		&AgentRecord{AgentID: 3, Generation: 1})
+	assert.Equal(t, []int{0}, l.Node(3).InferredParentIDs)
+	assert.InDelta(t, 1.0/3.0, l.Node(3).ParentsConfidence, 1e-9, "any of identical agents can be the parent")
+}
+
+func TestLineage_Ancestry(t *testing.T) {
+	l := createTestLineage()
+	ancestry, err := l.Ancestry(6)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ancestry.TrialID)
+	ids := make([]int, 0)
+	for _, n := range ancestry.Nodes {
+		ids = append(ids, n.AgentID)
+	}
+	assert.Equal(t, []int{1, 2, 4, 5, 6}, ids)
+	assert.Equal(t, l.Node(5), ancestry.Node(5))
+
+	// multiple agents
+	ancestry, err = l.Ancestry(3, 4)
+	require.NoError(t, err)
+	assert.Len(t, ancestry.Nodes, 4)
+
+	_, err = l.Ancestry(100)
+	assert.Error(t, err)
+}
+
+func TestLineage_SteppingStones(t *testing.T) {
+	l := createTestLineage()
+	stones, err := l.SteppingStones(6)
+	require.NoError(t, err)
+	require.Len(t, stones, 2)
+	assert.Equal(t, 1, stones[0].AgentID)
+	assert.Equal(t, 5, stones[1].AgentID)
+
+	// the agent itself is not the stepping stone
+	stones, err = l.SteppingStones(1)
+	require.NoError(t, err)
+	assert.Empty(t, stones)
+
+	solvers := l.Solvers()
+	require.Len(t, solvers, 1)
+	assert.Equal(t, 6, solvers[0].AgentID)
+}
+
+func TestLineage_WriteJSON(t *testing.T) {
+	l := createTestLineage()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, l.WriteJSON(buf))
+
+	read, err := ReadLineage(buf)
+	require.NoError(t, err)
+	assert.Equal(t, l.TrialID, read.TrialID)
+	assert.Equal(t, l.Nodes, read.Nodes)
+	assert.Equal(t, l.Node(5), read.Node(5))
+
+	_, err = ReadLineage(bytes.NewBufferString("garbage"))
+	assert.Error(t, err)
+}
+
+func TestLineage_WriteGraphML(t *testing.T) {
+	l := createTestLineage()
+	ancestry, err := l.Ancestry(6)
+	require.NoError(t, err)
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, ancestry.WriteGraphML(buf))
+
+	var doc struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Graph struct {
+			ID    string `xml:"id,attr"`
+			Nodes []struct {
+				ID   string `xml:"id,attr"`
+				Data []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Len(t, doc.Keys, 8)
+	assert.Equal(t, "trial_1", doc.Graph.ID)
+	require.Len(t, doc.Graph.Nodes, 5)
+	assert.Equal(t, "n6", doc.Graph.Nodes[4].ID)
+	assert.Contains(t, doc.Graph.Nodes[4].Data, struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}{"got_exit", "true"})
+	// 1 -> 5, 2 -> 5, 5 -> 6, 1 -> 4, 4 -> 6
+	assert.Len(t, doc.Graph.Edges, 5)
+	assert.Equal(t, "n1", doc.Graph.Edges[0].Source)
+	assert.Equal(t, "n4", doc.Graph.Edges[0].Target)
+}
+
+func TestStoreLineage(t *testing.T) {
+	outDir := t.TempDir()
+	trialSim = mazeSimResults{trialID: 1, lineage: createTestLineage()}
+	storeLineage(outDir)
+
+	files, err := filepath.Glob(filepath.Join(outDir, "*", lineageFileName))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	file, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	l, err := ReadLineage(file)
+	require.NoError(t, err)
+	assert.Equal(t, trialSim.lineage.Nodes, l.Nodes)
+}
+
+// createTestLineage creates the lineage of three generations:
+// 0: 0, 1 (archived), 2
+// 1: 3 (duplicate of 0), 4 (mutated 1 with a new gene), 5 (archived, mating 1 and 2)
+// 2: 6 (solver, mating 5 and 4)
+func createTestLineage() *Lineage {
+	l := NewLineage(1)
+	generations := [][]struct {
+		weights  map[int64]float64
+		archived bool
+		gotExit  bool
+	}{
+		{
+			{weights: map[int64]float64{1: 0.1, 2: 0.2}},
+			{weights: map[int64]float64{1: 0.3, 2: 0.4, 3: 0.5}, archived: true},
+			{weights: map[int64]float64{1: 0.6, 2: 0.7, 4: 0.8}},
+		},
+		{
+			{weights: map[int64]float64{1: 0.1, 2: 0.2}},
+			{weights: map[int64]float64{1: 0.31, 2: 0.4, 3: 0.5, 5: 0.9}},
+			{weights: map[int64]float64{1: 0.3, 2: 0.7, 3: 0.5, 4: 0.8}, archived: true},
+		},
+		{
+			{weights: map[int64]float64{1: 0.3, 2: 0.7, 3: 0.5, 4: 0.8, 5: 0.9}, gotExit: true},
+		},
+	}
+	agentID := 0
+	for generation, organisms := range generations {
+		for i, o := range organisms {
+			item := neatns.NewNoveltyItem()
+			if o.archived {
+				archive := neatns.NewNoveltyArchive(1, NoveltyMetric, neatns.DefaultNoveltyArchiveOptions())
+				org := &genetics.Organism{Data: &genetics.OrganismData{Value: item}}
+				archive.EvaluateIndividualNovelty(org, nil, false)
+			}
+			org := createTestLineageOrganism(i+generation*4, item, o.weights)
+			l.Add(org, &AgentRecord{
+				AgentID: agentID, Generation: generation, SpeciesID: 1, Fitness: float64(agentID) / 10,
+				GotExit: o.gotExit,
+			})
+			agentID++
+		}
+	}
+	return l
+}
+
+func createTestLineageOrganism(genomeID int, item *neatns.NoveltyItem, weights map[int64]float64) *genetics.Organism {
+	genome := &genetics.Genome{Id: genomeID}
+	for innovation, weight := range weights {
+		genome.Genes = append(genome.Genes, &genetics.Gene{
+			Link: &network.Link{ConnectionWeight: weight}, InnovationNum: innovation, IsEnabled: true,
+		})
+	}
+	org := &genetics.Organism{Genotype: genome}
+	if item != nil {
+		org.Data = &genetics.OrganismData{Value: item}
+	}
+	return org
+}
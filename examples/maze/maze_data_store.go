@@ -1,53 +1,244 @@
 package maze
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"io"
 )
 
+// RecordStoreVersion is the current version of the record store data schema. It must be incremented on any change of
+// AgentRecord, SolverRecord or RecordStore, and the reader of the previous version must be added to recordStoreReaders
+// along with the frozen copy of the previous types.
+const RecordStoreVersion = 2
+
+// The magic bytes at the beginning of the record store data, followed by the schema version
+const recordStoreMagic = "MZRECORD"
+
+// The readers of the record store data per schema version written with header. The legacy data written without header
+// is read by readLegacy.
+var recordStoreReaders = map[uint16]func(r io.Reader, s *RecordStore) error{
+	2: readRecordStoreV2,
+}
+
 // AgentRecord is the record holding info about individual maze agent performance at the end of simulation
 type AgentRecord struct {
 	// The ID of agent
-	AgentID int
+	AgentID int `json:"agent_id"`
 	// The agent position at the end of simulation
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 	// The agent fitness
-	Fitness float64
+	Fitness float64 `json:"fitness"`
 	// The flag to indicate whether agent reached maze exit
-	GotExit bool
+	GotExit bool `json:"got_exit"`
 	// The population generation when agent data was collected
-	Generation int
+	Generation int `json:"generation"`
 	// The novelty value associated
-	Novelty float64
+	Novelty float64 `json:"novelty"`
 
 	// The ID of species to whom individual belongs
-	SpeciesID int
+	SpeciesID int `json:"species_id"`
 	// The age of species to whom individual belongs at time of recording
-	SpeciesAge int
+	SpeciesAge int `json:"species_age"`
+	// The index of agent within the team in multi-agent simulation
+	TeamIndex int `json:"team_index"`
+}
+
+// SolverRecord is the record holding info about the agent that solved the maze and its path
+type SolverRecord struct {
+	// The ID of agent
+	AgentID int `json:"agent_id"`
+	// The ID of agent's genome
+	GenomeID int `json:"genome_id"`
+	// The population generation when agent solved the maze
+	Generation int `json:"generation"`
+	// The ID of species to whom individual belongs
+	SpeciesID int `json:"species_id"`
+	// The age of species to whom individual belongs at time of recording
+	SpeciesAge int `json:"species_age"`
+	// The number of simulation steps the agent took to reach the maze exit
+	StepsToExit int `json:"steps_to_exit"`
+	// The agent path points from the start to the maze exit
+	Path []Point `json:"-"`
 }
 
 // RecordStore the maze agent records storage
 type RecordStore struct {
 	// The array of agent records
 	Records []AgentRecord
-	// The array of the solver agent path points
+	// The array of the last solver agent path points
 	SolverPathPoints []Point
+	// The array of all agents that solved the maze in order of evaluation
+	Solvers []SolverRecord
 }
 
-// Writes record store to the provided writer
+// Writes record store to the provided writer, prefixed with the header holding the schema version
 func (s *RecordStore) Write(w io.Writer) error {
 	if len(s.Records) == 0 {
 		return errors.New("no records to store")
 	}
+	header := make([]byte, len(recordStoreMagic)+2)
+	copy(header, recordStoreMagic)
+	binary.BigEndian.PutUint16(header[len(recordStoreMagic):], RecordStoreVersion)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
 	enc := gob.NewEncoder(w)
 	err := enc.Encode(s)
 	return err
 }
 
-// Reads record store data from provided reader
+// FindSolver returns the record of the solver agent with given ID or nil if not found
+func (s *RecordStore) FindSolver(agentID int) *SolverRecord {
+	for i := range s.Solvers {
+		if s.Solvers[i].AgentID == agentID {
+			return &s.Solvers[i]
+		}
+	}
+	return nil
+}
+
+// Reads record store data from provided reader. The data of older schema versions, including the legacy data written
+// without header, is migrated to the current schema.
 func (s *RecordStore) Read(r io.Reader) error {
-	dec := gob.NewDecoder(r)
-	err := dec.Decode(s)
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(recordStoreMagic)); err != nil || string(magic) != recordStoreMagic {
+		return s.readLegacy(br)
+	}
+	header := make([]byte, len(recordStoreMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return err
+	}
+	version := binary.BigEndian.Uint16(header[len(recordStoreMagic):])
+	read, ok := recordStoreReaders[version]
+	if !ok {
+		return fmt.Errorf("unsupported record store version: %d, the latest supported: %d", version, RecordStoreVersion)
+	}
+	return read(br, s)
+}
+
+// readLegacy is to read the legacy data written without header, the version is detected by trying to decode the data
+// as the record store of version 1 first, and as the sequence of agent records of version 0 after that
+func (s *RecordStore) readLegacy(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err = readRecordStoreV1(bytes.NewReader(data), s); err == nil {
+		return nil
+	}
+	if errV0 := readRecordStoreV0(bytes.NewReader(data), s); errV0 == nil {
+		return nil
+	}
 	return err
 }
+
+// agentRecordV2 is the frozen AgentRecord of schema version 2, the records of version 0 have no team index
+type agentRecordV2 struct {
+	AgentID    int
+	X, Y       float64
+	Fitness    float64
+	GotExit    bool
+	Generation int
+	Novelty    float64
+	SpeciesID  int
+	SpeciesAge int
+	TeamIndex  int
+}
+
+// solverRecordV2 is the frozen SolverRecord of schema version 2
+type solverRecordV2 struct {
+	AgentID     int
+	GenomeID    int
+	Generation  int
+	SpeciesID   int
+	SpeciesAge  int
+	StepsToExit int
+	Path        []Point
+}
+
+// recordStoreV2 is the frozen RecordStore of schema version 2. The legacy record store of version 1 has the same
+// schema, but it's written without header.
+type recordStoreV2 struct {
+	Records          []agentRecordV2
+	SolverPathPoints []Point
+	Solvers          []solverRecordV2
+}
+
+// readRecordStoreV2 reads the record store data of schema version 2 and migrates it to the current schema
+func readRecordStoreV2(r io.Reader, s *RecordStore) error {
+	var v2 recordStoreV2
+	if err := gob.NewDecoder(r).Decode(&v2); err != nil {
+		return err
+	}
+	v2.migrate(s)
+	return nil
+}
+
+// readRecordStoreV1 reads the legacy record store data written without header and migrates it to the current schema
+func readRecordStoreV1(r io.Reader, s *RecordStore) error {
+	return readRecordStoreV2(r, s)
+}
+
+// readRecordStoreV0 reads the legacy sequence of agent records and migrates it to the current schema
+func readRecordStoreV0(r io.Reader, s *RecordStore) error {
+	dec := gob.NewDecoder(r)
+	var v0 recordStoreV2
+	for {
+		var record agentRecordV2
+		if err := dec.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		v0.Records = append(v0.Records, record)
+	}
+	v0.migrate(s)
+	return nil
+}
+
+// migrate is to copy the data of schema version 2 into the record store of the current schema
+func (v2 *recordStoreV2) migrate(s *RecordStore) {
+	s.Records = make([]AgentRecord, len(v2.Records))
+	for i, r := range v2.Records {
+		s.Records[i] = r.migrate()
+	}
+	s.SolverPathPoints = v2.SolverPathPoints
+	s.Solvers = nil
+	for _, r := range v2.Solvers {
+		s.Solvers = append(s.Solvers, r.migrate())
+	}
+}
+
+// migrate is to convert the agent record of schema version 2 into the record of the current schema
+func (r agentRecordV2) migrate() AgentRecord {
+	return AgentRecord{
+		AgentID:    r.AgentID,
+		X:          r.X,
+		Y:          r.Y,
+		Fitness:    r.Fitness,
+		GotExit:    r.GotExit,
+		Generation: r.Generation,
+		Novelty:    r.Novelty,
+		SpeciesID:  r.SpeciesID,
+		SpeciesAge: r.SpeciesAge,
+		TeamIndex:  r.TeamIndex,
+	}
+}
+
+// migrate is to convert the solver record of schema version 2 into the record of the current schema
+func (r solverRecordV2) migrate() SolverRecord {
+	return SolverRecord{
+		AgentID:     r.AgentID,
+		GenomeID:    r.GenomeID,
+		Generation:  r.Generation,
+		SpeciesID:   r.SpeciesID,
+		SpeciesAge:  r.SpeciesAge,
+		StepsToExit: r.StepsToExit,
+		Path:        r.Path,
+	}
+}
@@ -2,24 +2,32 @@ package maze
 
 import (
 	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"os"
 	"testing"
 )
 
 func TestRecordStore_Write_Read(t *testing.T) {
 	rs := new(RecordStore)
 	rs.Records = []AgentRecord{
-		{0, 1, 2, 4, false, 1, 0, 1, 1},
-		{1, 10, 20, 40, false, 1, 0, 1, 1},
-		{2, 11, 21, 41, false, 1, 0, 1, 1},
-		{3, 12, 22, 42, true, 1, 0, 1, 1},
+		{0, 1, 2, 4, false, 1, 0, 1, 1, 0},
+		{1, 10, 20, 40, false, 1, 0, 1, 1, 0},
+		{2, 11, 21, 41, false, 1, 0, 1, 1, 0},
+		{3, 12, 22, 42, true, 1, 0, 1, 1, 0},
 	}
 	rs.SolverPathPoints = []Point{
 		{0, 1},
 		{2, 3},
 		{4, 5},
 	}
+	rs.Solvers = []SolverRecord{
+		{AgentID: 3, GenomeID: 3, Generation: 1, SpeciesID: 1, SpeciesAge: 1, StepsToExit: 3, Path: rs.SolverPathPoints},
+		{AgentID: 5, GenomeID: 1, Generation: 2, SpeciesID: 2, SpeciesAge: 1, StepsToExit: 1, Path: []Point{{6, 7}}},
+	}
 
 	// the store medium
 	var store bytes.Buffer
@@ -36,4 +44,88 @@ func TestRecordStore_Write_Read(t *testing.T) {
 	// check that saved records match original
 	assert.ElementsMatch(t, rs.Records, nrs.Records, "wrong records saved")
 	assert.ElementsMatch(t, rs.SolverPathPoints, nrs.SolverPathPoints, "wrong solver path points saved")
+	assert.Equal(t, rs.Solvers, nrs.Solvers, "wrong solvers saved")
+}
+
+func TestRecordStore_FindSolver(t *testing.T) {
+	rs := RecordStore{Solvers: []SolverRecord{{AgentID: 3}, {AgentID: 5}}}
+	assert.Equal(t, &rs.Solvers[1], rs.FindSolver(5))
+	assert.Nil(t, rs.FindSolver(4))
+}
+
+func TestRecordStore_Write_header(t *testing.T) {
+	rs := RecordStore{Records: []AgentRecord{{AgentID: 1}}}
+	var store bytes.Buffer
+	require.NoError(t, rs.Write(&store))
+
+	data := store.Bytes()
+	assert.Equal(t, recordStoreMagic, string(data[:len(recordStoreMagic)]))
+	assert.Equal(t, uint16(RecordStoreVersion), binary.BigEndian.Uint16(data[len(recordStoreMagic):]))
+}
+
+func TestRecordStore_Read_legacy(t *testing.T) {
+	// the records written by the first version of the maze experiments without header
+	type legacyAgentRecord struct {
+		AgentID    int
+		X, Y       float64
+		Fitness    float64
+		GotExit    bool
+		Generation int
+		Novelty    float64
+		SpeciesID  int
+		SpeciesAge int
+	}
+	legacy := struct {
+		Records          []legacyAgentRecord
+		SolverPathPoints []Point
+	}{
+		Records: []legacyAgentRecord{
+			{AgentID: 1, X: 2, Y: 3, Fitness: 0.5, Generation: 4, Novelty: 6, SpeciesID: 7, SpeciesAge: 8},
+			{AgentID: 2, X: 3, Y: 4, Fitness: 1, GotExit: true, Generation: 5, Novelty: 7, SpeciesID: 8, SpeciesAge: 9},
+		},
+		SolverPathPoints: []Point{{1, 2}, {3, 4}},
+	}
+	var store bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&store).Encode(legacy))
+
+	var rs RecordStore
+	require.NoError(t, rs.Read(&store))
+	assert.Equal(t, []AgentRecord{
+		{AgentID: 1, X: 2, Y: 3, Fitness: 0.5, Generation: 4, Novelty: 6, SpeciesID: 7, SpeciesAge: 8},
+		{AgentID: 2, X: 3, Y: 4, Fitness: 1, GotExit: true, Generation: 5, Novelty: 7, SpeciesID: 8, SpeciesAge: 9},
+	}, rs.Records)
+	assert.Equal(t, legacy.SolverPathPoints, rs.SolverPathPoints)
+	assert.Empty(t, rs.Solvers)
+}
+
+func TestRecordStore_Read_archived(t *testing.T) {
+	file, err := os.Open("../../contents/NS_medium_16/record.dat")
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	var rs RecordStore
+	// the archived records are stored as the sequence of agent records
+	require.NoError(t, rs.Read(file))
+	require.NotEmpty(t, rs.Records)
+	assert.Equal(t, 0, rs.Records[0].AgentID)
+	assert.Equal(t, 1, rs.Records[1].AgentID)
+	assert.True(t, rs.Records[0].X > 0 && rs.Records[0].Y > 0)
+}
+
+func TestRecordStore_Read_corrupted(t *testing.T) {
+	var rs RecordStore
+	assert.Error(t, rs.Read(bytes.NewReader([]byte("not a record store"))))
+}
+
+func TestRecordStore_Read_unsupportedVersion(t *testing.T) {
+	rs := RecordStore{Records: []AgentRecord{{AgentID: 1}}}
+	var store bytes.Buffer
+	require.NoError(t, rs.Write(&store))
+	data := store.Bytes()
+	binary.BigEndian.PutUint16(data[len(recordStoreMagic):], RecordStoreVersion+1)
+
+	err := rs.Read(bytes.NewReader(data))
+	assert.EqualError(t, err, fmt.Sprintf("unsupported record store version: %d, the latest supported: %d",
+		RecordStoreVersion+1, RecordStoreVersion))
 }
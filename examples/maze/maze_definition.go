@@ -0,0 +1,448 @@
+package maze
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MazeFormat is the format of the maze configuration file
+type MazeFormat int
+
+const (
+	// LegacyFormat the line-position dependent text format read by ReadEnvironment
+	LegacyFormat MazeFormat = iota
+	// YAMLFormat the structured maze definition encoded as YAML
+	YAMLFormat
+	// JSONFormat the structured maze definition encoded as JSON
+	JSONFormat
+)
+
+// MazeFormatFromPath detects the maze configuration file format by file extension: .yml and .yaml for YAMLFormat,
+// .json for JSONFormat and LegacyFormat for everything else.
+func MazeFormatFromPath(path string) MazeFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return YAMLFormat
+	case ".json":
+		return JSONFormat
+	default:
+		return LegacyFormat
+	}
+}
+
+// MazeDefinition is the structured definition of the maze environment with metadata, which can be stored as YAML or JSON
+type MazeDefinition struct {
+	// The name of the maze
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// The description of the maze
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// The maze walls as [x1, y1, x2, y2] line segments
+	Walls []WallDefinition `yaml:"walls" json:"walls"`
+	// The moving maze obstacles
+	Obstacles []ObstacleDefinition `yaml:"obstacles,omitempty" json:"obstacles,omitempty"`
+	// The start pose of the agent
+	Start PoseDefinition `yaml:"start" json:"start"`
+	// The goals of the maze. The last goal is the maze exit and all others are waypoints to be visited before it.
+	Goals []GoalDefinition `yaml:"goals" json:"goals"`
+	// The flag to indicate whether goals must be visited in the order of definition
+	OrderedGoals bool `yaml:"ordered_goals,omitempty" json:"ordered_goals,omitempty"`
+
+	// The recommended number of time steps of maze solving simulation
+	TimeSteps int `yaml:"time_steps,omitempty" json:"time_steps,omitempty"`
+	// The recommended sample size to store agent path during simulation
+	SampleSize int `yaml:"sample_size,omitempty" json:"sample_size,omitempty"`
+	// The recommended range around goal point to consider it as reached
+	ExitFoundRange float64 `yaml:"exit_found_range,omitempty" json:"exit_found_range,omitempty"`
+
+	// The configuration of the agent, if omitted the default agent configuration is used
+	Agent *AgentDefinition `yaml:"agent,omitempty" json:"agent,omitempty"`
+	// The metric of agent's distance to exit for fitness calculation, if omitted the Euclidean distance is used
+	Fitness *FitnessDefinition `yaml:"fitness,omitempty" json:"fitness,omitempty"`
+}
+
+// WallDefinition is the maze wall line segment as [x1, y1, x2, y2]
+type WallDefinition [4]float64
+
+// MarshalYAML is to encode wall as compact flow sequence
+func (w WallDefinition) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, v := range w {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: formatFloat(v)})
+	}
+	return node, nil
+}
+
+// ObstacleDefinition is the moving maze obstacle
+type ObstacleDefinition struct {
+	// The shape of obstacle [SEGMENT, DISC]
+	Shape string `yaml:"shape" json:"shape"`
+	// The line segment of SEGMENT obstacle at its initial position
+	Segment *WallDefinition `yaml:"segment,omitempty" json:"segment,omitempty"`
+	// The center of DISC obstacle at its initial position as [x, y]
+	Center []float64 `yaml:"center,omitempty,flow" json:"center,omitempty"`
+	// The radius of DISC obstacle
+	Radius float64 `yaml:"radius,omitempty" json:"radius,omitempty"`
+	// The type of obstacle's motion [NONE, LINEAR, PERIODIC]
+	Motion string `yaml:"motion,omitempty" json:"motion,omitempty"`
+	// The maximal displacement of obstacle from its initial position as [dx, dy]
+	Displacement []float64 `yaml:"displacement,omitempty,flow" json:"displacement,omitempty"`
+	// The number of time steps in the full cycle of motion
+	Period int `yaml:"period,omitempty" json:"period,omitempty"`
+	// The phase of motion cycle at the time step zero as fraction of the period [0, 1)
+	Phase float64 `yaml:"phase,omitempty" json:"phase,omitempty"`
+}
+
+// PoseDefinition is the location and heading of the agent
+type PoseDefinition struct {
+	X       float64 `yaml:"x" json:"x"`
+	Y       float64 `yaml:"y" json:"y"`
+	Heading float64 `yaml:"heading" json:"heading"`
+}
+
+// GoalDefinition is the goal point in the maze
+type GoalDefinition struct {
+	Name string  `yaml:"name,omitempty" json:"name,omitempty"`
+	X    float64 `yaml:"x" json:"x"`
+	Y    float64 `yaml:"y" json:"y"`
+}
+
+// AgentDefinition is the configuration of the maze agent. The omitted values are set to defaults.
+type AgentDefinition struct {
+	// The radius of agent body
+	Radius float64 `yaml:"radius,omitempty" json:"radius,omitempty"`
+	// The maximal range of range finder sensors
+	RangeFinderRange float64 `yaml:"range_finder_range,omitempty" json:"range_finder_range,omitempty"`
+	// The angles of range finder sensors
+	RangeFinderAngles []float64 `yaml:"range_finder_angles,omitempty" json:"range_finder_angles,omitempty"`
+	// The beginning angles for radar sensors
+	RadarAngles1 []float64 `yaml:"radar_angles_1,omitempty" json:"radar_angles_1,omitempty"`
+	// The ending angles for radar sensors
+	RadarAngles2 []float64 `yaml:"radar_angles_2,omitempty" json:"radar_angles_2,omitempty"`
+	// The collision response mode [STOP, SLIDE, BOUNCE]
+	Collision string `yaml:"collision,omitempty" json:"collision,omitempty"`
+	// The motion model of the agent
+	Motion *MotionDefinition `yaml:"motion,omitempty" json:"motion,omitempty"`
+}
+
+// MotionDefinition is the configuration of the agent's motion model
+type MotionDefinition struct {
+	// The name of motion model [ACCEL, DIFF_DRIVE, DIRECT]
+	Model string `yaml:"model" json:"model"`
+	// The maximal absolute speed of the agent
+	MaxSpeed float64 `yaml:"max_speed" json:"max_speed"`
+	// The maximal absolute angular velocity of the agent
+	MaxAngularVelocity float64 `yaml:"max_angular_velocity" json:"max_angular_velocity"`
+	// The distance between wheels for differential-drive model
+	WheelBase float64 `yaml:"wheel_base,omitempty" json:"wheel_base,omitempty"`
+}
+
+// FitnessDefinition is the configuration of the metric of agent's distance to exit for fitness calculation
+type FitnessDefinition struct {
+	// The name of distance metric [EUCLIDEAN, GEODESIC]
+	Metric string `yaml:"metric" json:"metric"`
+	// The cell size of the maze grid to calculate GEODESIC distances
+	CellSize float64 `yaml:"cell_size,omitempty" json:"cell_size,omitempty"`
+}
+
+// ReadEnvironmentFromFile reads maze environment from the file detecting its format by file extension
+func ReadEnvironmentFromFile(path string) (*Environment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	format := MazeFormatFromPath(path)
+	var env *Environment
+	if format == LegacyFormat {
+		env, err = ReadEnvironment(file)
+	} else {
+		var def *MazeDefinition
+		if def, err = ReadMazeDefinition(file, format); err == nil {
+			env, err = def.Environment()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Name) == 0 {
+		env.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return env, nil
+}
+
+// WriteEnvironmentToFile writes maze environment to the file using format detected by file extension
+func WriteEnvironmentToFile(path string, env *Environment) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	format := MazeFormatFromPath(path)
+	if format == LegacyFormat {
+		err = WriteEnvironment(file, env)
+	} else {
+		var def *MazeDefinition
+		if def, err = NewMazeDefinition(env); err == nil {
+			err = def.Write(file, format)
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// ReadMazeDefinition reads maze definition from the reader using specified format
+func ReadMazeDefinition(r io.Reader, format MazeFormat) (*MazeDefinition, error) {
+	def := &MazeDefinition{}
+	var err error
+	switch format {
+	case YAMLFormat:
+		err = yaml.NewDecoder(r).Decode(def)
+	case JSONFormat:
+		err = json.NewDecoder(r).Decode(def)
+	default:
+		var env *Environment
+		if env, err = ReadEnvironment(r); err == nil {
+			return NewMazeDefinition(env)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return def, nil
+}
+
+// Write writes maze definition to the writer using specified format
+func (d *MazeDefinition) Write(w io.Writer, format MazeFormat) error {
+	switch format {
+	case YAMLFormat:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+		return enc.Close()
+	case JSONFormat:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	default:
+		env, err := d.Environment()
+		if err != nil {
+			return err
+		}
+		return WriteEnvironment(w, env)
+	}
+}
+
+// NewMazeDefinition creates maze definition from the maze environment
+func NewMazeDefinition(env *Environment) (*MazeDefinition, error) {
+	def := &MazeDefinition{
+		Name:           env.Name,
+		Walls:          make([]WallDefinition, len(env.Lines)),
+		Start:          PoseDefinition{X: env.Hero.Location.X, Y: env.Hero.Location.Y, Heading: env.Hero.Heading},
+		Goals:          make([]GoalDefinition, 0, len(env.Waypoints)+1),
+		OrderedGoals:   env.OrderedWaypoints,
+		TimeSteps:      env.TimeSteps,
+		SampleSize:     env.SampleSize,
+		ExitFoundRange: env.ExitFoundRange,
+	}
+	for i, l := range env.Lines {
+		def.Walls[i] = WallDefinition{l.A.X, l.A.Y, l.B.X, l.B.Y}
+	}
+	for _, o := range env.Obstacles {
+		def.Obstacles = append(def.Obstacles, newObstacleDefinition(o))
+	}
+	for i, w := range env.Waypoints {
+		def.Goals = append(def.Goals, GoalDefinition{Name: fmt.Sprintf("waypoint_%d", i), X: w.X, Y: w.Y})
+	}
+	def.Goals = append(def.Goals, GoalDefinition{Name: "exit", X: env.MazeExit.X, Y: env.MazeExit.Y})
+
+	agent := &AgentDefinition{
+		Radius:            env.Hero.Radius,
+		RangeFinderRange:  env.Hero.RangeFinderRange,
+		RangeFinderAngles: env.Hero.RangeFinderAngles,
+		RadarAngles1:      env.Hero.RadarAngles1,
+		RadarAngles2:      env.Hero.RadarAngles2,
+		Collision:         env.Collision.String(),
+	}
+	switch m := env.Motion.(type) {
+	case nil:
+		// the default motion model
+	case *AccelerationMotion:
+		agent.Motion = &MotionDefinition{Model: "ACCEL", MaxSpeed: m.MaxSpeed, MaxAngularVelocity: m.MaxAngularVelocity}
+	case *DifferentialDriveMotion:
+		agent.Motion = &MotionDefinition{Model: "DIFF_DRIVE", MaxSpeed: m.MaxSpeed,
+			MaxAngularVelocity: m.MaxAngularVelocity, WheelBase: m.WheelBase}
+	case *DirectVelocityMotion:
+		agent.Motion = &MotionDefinition{Model: "DIRECT", MaxSpeed: m.MaxSpeed, MaxAngularVelocity: m.MaxAngularVelocity}
+	default:
+		return nil, fmt.Errorf("unsupported motion model: %T", env.Motion)
+	}
+	def.Agent = agent
+
+	if env.FitnessMetric != EuclideanDistance {
+		def.Fitness = &FitnessDefinition{Metric: env.FitnessMetric.String()}
+		if env.distanceField != nil {
+			def.Fitness.CellSize = env.distanceField.CellSize
+		}
+	}
+
+	return def, nil
+}
+
+// Environment creates maze environment from this definition
+func (d *MazeDefinition) Environment() (*Environment, error) {
+	if len(d.Goals) == 0 {
+		return nil, errors.New("at least one goal must be defined")
+	}
+	exit := d.Goals[len(d.Goals)-1]
+	env := &Environment{
+		Name:             d.Name,
+		Hero:             NewAgent(),
+		Lines:            make([]Line, len(d.Walls)),
+		MazeExit:         Point{X: exit.X, Y: exit.Y},
+		OrderedWaypoints: d.OrderedGoals,
+		TimeSteps:        d.TimeSteps,
+		SampleSize:       d.SampleSize,
+		ExitFoundRange:   d.ExitFoundRange,
+	}
+	for i, w := range d.Walls {
+		env.Lines[i] = NewLine(Point{X: w[0], Y: w[1]}, Point{X: w[2], Y: w[3]})
+	}
+	for i, o := range d.Obstacles {
+		obstacle, err := o.obstacle()
+		if err != nil {
+			return nil, fmt.Errorf("invalid obstacle at index: %d, reason: %s", i, err)
+		}
+		env.Obstacles = append(env.Obstacles, *obstacle)
+	}
+	for _, g := range d.Goals[:len(d.Goals)-1] {
+		env.Waypoints = append(env.Waypoints, Point{X: g.X, Y: g.Y})
+	}
+	env.Hero.Location = Point{X: d.Start.X, Y: d.Start.Y}
+	env.Hero.Heading = d.Start.Heading
+
+	if d.Agent != nil {
+		if err := d.Agent.apply(env); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := env.initialize(); err != nil {
+		return nil, err
+	}
+
+	if d.Fitness != nil {
+		metric, err := DistanceMetricFromString(d.Fitness.Metric)
+		if err != nil {
+			return nil, err
+		}
+		if err = env.SetFitnessMetric(metric, d.Fitness.CellSize); err != nil {
+			return nil, err
+		}
+	}
+	return env, nil
+}
+
+// apply is to apply agent configuration to the environment
+func (a *AgentDefinition) apply(env *Environment) error {
+	if a.Radius > 0 {
+		env.Hero.Radius = a.Radius
+	}
+	if a.RangeFinderRange > 0 {
+		env.Hero.RangeFinderRange = a.RangeFinderRange
+	}
+	if len(a.RangeFinderAngles) > 0 {
+		env.Hero.RangeFinderAngles = a.RangeFinderAngles
+		env.Hero.RangeFinders = make([]float64, len(a.RangeFinderAngles))
+	}
+	if len(a.RadarAngles1) != len(a.RadarAngles2) {
+		return fmt.Errorf("radar angles mismatch: %d != %d", len(a.RadarAngles1), len(a.RadarAngles2))
+	}
+	if len(a.RadarAngles1) > 0 {
+		env.Hero.RadarAngles1 = a.RadarAngles1
+		env.Hero.RadarAngles2 = a.RadarAngles2
+		env.Hero.Radar = make([]float64, len(a.RadarAngles1))
+	}
+	if len(a.Collision) > 0 {
+		collision, err := CollisionModeFromString(a.Collision)
+		if err != nil {
+			return err
+		}
+		env.Collision = collision
+	}
+	if a.Motion != nil {
+		motion, err := NewMotionModel(a.Motion.Model, a.Motion.MaxSpeed, a.Motion.MaxAngularVelocity, a.Motion.WheelBase)
+		if err != nil {
+			return err
+		}
+		env.Motion = motion
+	}
+	return nil
+}
+
+// newObstacleDefinition creates definition of the maze obstacle
+func newObstacleDefinition(o Obstacle) ObstacleDefinition {
+	def := ObstacleDefinition{
+		Shape:  o.Shape.String(),
+		Motion: o.Motion.String(),
+		Period: o.Period,
+		Phase:  o.Phase,
+	}
+	if o.Shape == DiscObstacle {
+		def.Center = []float64{o.Center.X, o.Center.Y}
+		def.Radius = o.Radius
+	} else {
+		def.Segment = &WallDefinition{o.Segment.A.X, o.Segment.A.Y, o.Segment.B.X, o.Segment.B.Y}
+	}
+	if o.Motion != NoMotion {
+		def.Displacement = []float64{o.Displacement.X, o.Displacement.Y}
+	}
+	return def
+}
+
+// obstacle creates the maze obstacle from this definition
+func (d *ObstacleDefinition) obstacle() (*Obstacle, error) {
+	shape, err := ObstacleShapeFromString(d.Shape)
+	if err != nil {
+		return nil, err
+	}
+	o := &Obstacle{Shape: shape, Period: d.Period, Phase: d.Phase}
+	switch shape {
+	case DiscObstacle:
+		if len(d.Center) != 2 || d.Radius <= 0 {
+			return nil, errors.New("disc obstacle must have center as [x, y] and positive radius")
+		}
+		o.Center = Point{X: d.Center[0], Y: d.Center[1]}
+		o.Radius = d.Radius
+	default:
+		if d.Segment == nil {
+			return nil, errors.New("segment obstacle must have segment as [x1, y1, x2, y2]")
+		}
+		o.Segment = NewLine(Point{X: d.Segment[0], Y: d.Segment[1]}, Point{X: d.Segment[2], Y: d.Segment[3]})
+	}
+
+	if len(d.Motion) > 0 {
+		if o.Motion, err = ObstacleMotionTypeFromString(d.Motion); err != nil {
+			return nil, err
+		}
+	}
+	if o.Motion != NoMotion {
+		if len(d.Displacement) != 2 || d.Period <= 0 {
+			return nil, errors.New("moving obstacle must have displacement as [dx, dy] and positive period")
+		}
+		o.Displacement = Point{X: d.Displacement[0], Y: d.Displacement[1]}
+	}
+	return o, nil
+}
@@ -0,0 +1,274 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMazeFormatFromPath(t *testing.T) {
+	testCases := map[string]MazeFormat{
+		"maze.yml":      YAMLFormat,
+		"maze.YAML":     YAMLFormat,
+		"dir/maze.json": JSONFormat,
+		"maze.txt":      LegacyFormat,
+		"maze":          LegacyFormat,
+	}
+	for path, expected := range testCases {
+		assert.Equal(t, expected, MazeFormatFromPath(path), path)
+	}
+}
+
+func TestReadEnvironmentFromFile(t *testing.T) {
+	legacy, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "medium_maze", legacy.Name)
+
+	structured, err := ReadEnvironmentFromFile("../../data/medium_maze.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "medium_maze", structured.Name)
+	assert.Equal(t, 400, structured.TimeSteps)
+	assert.Equal(t, 1000, structured.SampleSize)
+	assert.Equal(t, 5.0, structured.ExitFoundRange)
+
+	assertEnvironmentsEqual(t, legacy, structured)
+	assert.Equal(t, legacy.AgentDistanceToExit(), structured.AgentDistanceToExit())
+	assert.Equal(t, legacy.Hero.RangeFinders, structured.Hero.RangeFinders)
+	assert.Equal(t, legacy.Hero.Radar, structured.Hero.Radar)
+}
+
+func TestMazeDefinition_roundTrip(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/hard_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 400
+	env.ExitFoundRange = 5
+	env.Collision = CollisionSlide
+	env.Motion = &DifferentialDriveMotion{WheelBase: 16, MaxWheelSpeed: 3, MaxSpeed: 3, MaxAngularVelocity: 5}
+
+	def, err := NewMazeDefinition(env)
+	require.NoError(t, err)
+
+	for _, format := range []MazeFormat{YAMLFormat, JSONFormat} {
+		buf := bytes.NewBufferString("")
+		err = def.Write(buf, format)
+		require.NoError(t, err)
+
+		readDef, err := ReadMazeDefinition(buf, format)
+		require.NoError(t, err)
+		assert.Equal(t, def, readDef)
+
+		readEnv, err := readDef.Environment()
+		require.NoError(t, err)
+		assertEnvironmentsEqual(t, env, readEnv)
+		assert.Equal(t, env.TimeSteps, readEnv.TimeSteps)
+		assert.Equal(t, env.ExitFoundRange, readEnv.ExitFoundRange)
+		assert.Equal(t, env.Collision, readEnv.Collision)
+		motion, ok := readEnv.Motion.(*DifferentialDriveMotion)
+		require.True(t, ok)
+		assert.Equal(t, 16.0, motion.WheelBase)
+		assert.Equal(t, 5.0, motion.MaxAngularVelocity)
+		assert.Equal(t, EuclideanDistance, readEnv.FitnessMetric)
+	}
+
+	// round-trip through the legacy format
+	buf := bytes.NewBufferString("")
+	err = def.Write(buf, LegacyFormat)
+	require.NoError(t, err)
+	legacyDef, err := ReadMazeDefinition(buf, LegacyFormat)
+	require.NoError(t, err)
+	assert.Equal(t, def.Walls, legacyDef.Walls)
+	assert.Equal(t, def.Start, legacyDef.Start)
+	assert.Equal(t, def.Goals, legacyDef.Goals)
+}
+
+func TestMazeDefinition_fitness(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	require.NoError(t, env.SetFitnessMetric(GeodesicDistance, 4))
+
+	def, err := NewMazeDefinition(env)
+	require.NoError(t, err)
+	assert.Equal(t, &FitnessDefinition{Metric: "GEODESIC", CellSize: 4}, def.Fitness)
+
+	buf := bytes.NewBufferString("")
+	require.NoError(t, def.Write(buf, YAMLFormat))
+	readDef, err := ReadMazeDefinition(buf, YAMLFormat)
+	require.NoError(t, err)
+	readEnv, err := readDef.Environment()
+	require.NoError(t, err)
+	assert.Equal(t, GeodesicDistance, readEnv.FitnessMetric)
+	assert.Equal(t, env.AgentPathDistanceToExit(), readEnv.AgentPathDistanceToExit())
+
+	// the cell size is required by GEODESIC metric
+	readDef.Fitness.CellSize = 0
+	_, err = readDef.Environment()
+	assert.Error(t, err)
+}
+
+func TestWriteEnvironmentToFile(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	for _, name := range []string{"maze.yml", "maze.json", "maze.txt"} {
+		path := filepath.Join(dir, name)
+		err = WriteEnvironmentToFile(path, env)
+		require.NoError(t, err, name)
+
+		readEnv, err := ReadEnvironmentFromFile(path)
+		require.NoError(t, err, name)
+		assertEnvironmentsEqual(t, env, readEnv)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "maze.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "- [5, 5, 295, 5]")
+}
+
+func TestMazeDefinition_Environment_agent(t *testing.T) {
+	config := `
+walls:
+  - [0, 0, 100, 0]
+start: {x: 10, y: 20, heading: 90}
+goals:
+  - {x: 50, y: 50}
+agent:
+  radius: 5
+  range_finder_angles: [-45, 0, 45]
+  collision: BOUNCE
+  motion: {model: DIRECT, max_speed: 2, max_angular_velocity: 10}
+`
+	def, err := ReadMazeDefinition(strings.NewReader(config), YAMLFormat)
+	require.NoError(t, err)
+	env, err := def.Environment()
+	require.NoError(t, err)
+
+	assert.Equal(t, Point{X: 10, Y: 20}, env.Hero.Location)
+	assert.Equal(t, 90.0, env.Hero.Heading)
+	assert.Equal(t, Point{X: 50, Y: 50}, env.MazeExit)
+	assert.Equal(t, 5.0, env.Hero.Radius)
+	assert.Len(t, env.Hero.RangeFinders, 3)
+	assert.Len(t, env.Hero.Radar, 4)
+	assert.Equal(t, CollisionBounce, env.Collision)
+	assert.IsType(t, &DirectVelocityMotion{}, env.Motion)
+}
+
+func TestMazeDefinition_Environment_errors(t *testing.T) {
+	testCases := map[string]string{
+		"no goals":       `{"walls": [[0, 0, 10, 0]], "start": {"x": 1, "y": 1}, "goals": []}`,
+		"collision mode": `{"goals": [{"x": 1, "y": 1}], "agent": {"collision": "FLY"}}`,
+		"motion model":   `{"goals": [{"x": 1, "y": 1}], "agent": {"motion": {"model": "JUMP"}}}`,
+		"motion speed":   `{"goals": [{"x": 1, "y": 1}], "agent": {"motion": {"model": "ACCEL", "max_angular_velocity": 3}}}`,
+		"radar mismatch": `{"goals": [{"x": 1, "y": 1}], "agent": {"radar_angles_1": [0, 90], "radar_angles_2": [90]}}`,
+	}
+	for name, config := range testCases {
+		def, err := ReadMazeDefinition(strings.NewReader(config), JSONFormat)
+		require.NoError(t, err, name)
+		_, err = def.Environment()
+		assert.Error(t, err, name)
+	}
+}
+
+func assertEnvironmentsEqual(t *testing.T, expected, actual *Environment) {
+	assert.Equal(t, expected.Lines, actual.Lines)
+	assert.Equal(t, expected.MazeExit, actual.MazeExit)
+	assert.Equal(t, expected.Hero.Location, actual.Hero.Location)
+	assert.Equal(t, expected.Hero.Heading, actual.Hero.Heading)
+	assert.Equal(t, expected.Hero.Radius, actual.Hero.Radius)
+}
+
+func TestMazeDefinition_Environment_waypoints(t *testing.T) {
+	config := `
+walls:
+  - [0, 0, 100, 0]
+start: {x: 10, y: 20, heading: 0}
+goals:
+  - {name: A, x: 20, y: 20}
+  - {name: B, x: 40, y: 20}
+  - {name: exit, x: 50, y: 50}
+ordered_goals: true
+`
+	def, err := ReadMazeDefinition(strings.NewReader(config), YAMLFormat)
+	require.NoError(t, err)
+	env, err := def.Environment()
+	require.NoError(t, err)
+
+	assert.Equal(t, []Point{{X: 20, Y: 20}, {X: 40, Y: 20}}, env.Waypoints)
+	assert.True(t, env.OrderedWaypoints)
+	assert.Equal(t, Point{X: 50, Y: 50}, env.MazeExit)
+	assert.Len(t, env.Hero.WaypointRadar, 2)
+
+	written, err := NewMazeDefinition(env)
+	require.NoError(t, err)
+	assert.True(t, written.OrderedGoals)
+	require.Len(t, written.Goals, 3)
+	assert.Equal(t, GoalDefinition{Name: "exit", X: 50, Y: 50}, written.Goals[2])
+
+	// waypoints can not be stored in the legacy format
+	err = written.Write(bytes.NewBuffer(nil), LegacyFormat)
+	assert.Error(t, err)
+}
+
+func TestMazeDefinition_Environment_obstacles(t *testing.T) {
+	config := `
+walls:
+  - [0, 0, 100, 0]
+start: {x: 10, y: 20, heading: 0}
+goals:
+  - {x: 50, y: 50}
+obstacles:
+  - shape: DISC
+    center: [30, 30]
+    radius: 5
+    motion: PERIODIC
+    displacement: [0, 20]
+    period: 50
+    phase: 0.5
+  - shape: SEGMENT
+    segment: [60, 10, 60, 30]
+`
+	def, err := ReadMazeDefinition(strings.NewReader(config), YAMLFormat)
+	require.NoError(t, err)
+	env, err := def.Environment()
+	require.NoError(t, err)
+
+	expected := []Obstacle{
+		{Shape: DiscObstacle, Center: Point{X: 30, Y: 30}, Radius: 5, Motion: PeriodicMotion,
+			Displacement: Point{X: 0, Y: 20}, Period: 50, Phase: 0.5},
+		{Shape: SegmentObstacle, Segment: NewLine(Point{X: 60, Y: 10}, Point{X: 60, Y: 30})},
+	}
+	assert.Equal(t, expected, env.Obstacles)
+
+	written, err := NewMazeDefinition(env)
+	require.NoError(t, err)
+	for _, format := range []MazeFormat{YAMLFormat, JSONFormat} {
+		buf := bytes.NewBuffer(nil)
+		err = written.Write(buf, format)
+		require.NoError(t, err)
+		readDef, err := ReadMazeDefinition(buf, format)
+		require.NoError(t, err)
+		readEnv, err := readDef.Environment()
+		require.NoError(t, err)
+		assert.Equal(t, expected, readEnv.Obstacles)
+	}
+}
+
+func TestMazeDefinition_Environment_invalidObstacles(t *testing.T) {
+	obstacles := []string{
+		`{"shape": "CUBE"}`,
+		`{"shape": "DISC", "center": [1, 1]}`,
+		`{"shape": "SEGMENT"}`,
+		`{"shape": "SEGMENT", "segment": [0, 0, 1, 1], "motion": "JUMP"}`,
+		`{"shape": "SEGMENT", "segment": [0, 0, 1, 1], "motion": "LINEAR", "displacement": [1, 1]}`,
+	}
+	for _, obstacle := range obstacles {
+		config := `{"goals": [{"x": 1, "y": 1}], "obstacles": [` + obstacle + `]}`
+		def, err := ReadMazeDefinition(strings.NewReader(config), JSONFormat)
+		require.NoError(t, err, obstacle)
+		_, err = def.Environment()
+		assert.Error(t, err, obstacle)
+	}
+}
@@ -0,0 +1,234 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/experiment/utils"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"math"
+	"os"
+)
+
+// NewMultiAgentEvaluator allows creating the evaluator of maze solving teams of agents based on Novelty Search
+// optimization. Each organism is evaluated as the team of teamSize agents controlled by clones of its phenotype, which
+// are navigating the provided maze environment simultaneously. The team is scored by how many agents reach the maze
+// exit, and the novelty is measured over the joint behavior of the team. The numSpeciesTarget specifies the target
+// number of species to maintain in the population. If the number of species differ from the numSpeciesTarget it
+// will be automatically adjusted with compatAdjustFreq frequency, i.e., at each epoch % compatAdjustFreq == 0
+func NewMultiAgentEvaluator(out string, mazeEnv *Environment, teamSize, numSpeciesTarget, compatAdjustFreq int) (experiment.GenerationEvaluator, experiment.TrialRunObserver) {
+	evaluator := &multiAgentEvaluator{
+		outputPath:       out,
+		mazeEnv:          mazeEnv,
+		teamSize:         teamSize,
+		numSpeciesTarget: numSpeciesTarget,
+		compatAdjustFreq: compatAdjustFreq,
+	}
+	return evaluator, evaluator
+}
+
+// multiAgentEvaluator the maze solving experiment for teams of agents with Novelty Search optimization of NEAT algorithm
+type multiAgentEvaluator struct {
+	// The output path to store execution results
+	outputPath string
+	// The maze seed environment
+	mazeEnv *Environment
+	// The number of agents in the team
+	teamSize int
+
+	// The target number of species to be maintained
+	numSpeciesTarget int
+	// The species compatibility threshold adjustment frequency
+	compatAdjustFreq int
+}
+
+func (e *multiAgentEvaluator) TrialRunStarted(trial *experiment.Trial) {
+	opts := neatns.DefaultNoveltyArchiveOptions()
+	opts.KNNNoveltyScore = 10
+	trialSim = mazeSimResults{
+		trialID: trial.Id,
+		records: new(RecordStore),
+		lineage: NewLineage(trial.Id),
+		archive: neatns.NewNoveltyArchive(archiveThresh, NoveltyMetric, opts),
+	}
+	startRecordStream(e.outputPath)
+}
+
+func (e *multiAgentEvaluator) TrialRunFinished(_ *experiment.Trial) {
+	// the last epoch executed
+	e.storeRecorded()
+}
+
+func (e *multiAgentEvaluator) EpochEvaluated(_ *experiment.Trial, epoch *experiment.Generation) {
+	// append records of the evaluated generation to the stream if enabled
+	streamRecords(epoch.Id)
+}
+
+// GenerationEvaluate evaluates one epoch for given population and prints results into output directory if any.
+func (e *multiAgentEvaluator) GenerationEvaluate(ctx context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
+	options, ok := neat.FromContext(ctx)
+	if !ok {
+		return neat.ErrNEATOptionsNotFound
+	}
+	// Evaluate each organism on a test
+	for i, org := range pop.Organisms {
+		res, err := e.orgEvaluate(org, pop, epoch)
+		if err != nil {
+			return err
+		}
+		// store fitness based on team score for statistical purposes
+		if org.Data == nil {
+			neat.ErrorLog(fmt.Sprintf("Novelty point not found at organism: %s", org))
+			pop.Organisms[i].Fitness = 0.0
+		} else {
+			pop.Organisms[i].Fitness = org.Data.Value.(*neatns.NoveltyItem).Fitness
+		}
+
+		if res && (epoch.Champion == nil || org.Fitness > epoch.Champion.Fitness) {
+			epoch.Solved = true
+			epoch.WinnerNodes = len(org.Genotype.Nodes)
+			epoch.WinnerGenes = org.Genotype.Extrons()
+			epoch.WinnerEvals = trialSim.individualsCounter
+			epoch.Champion = org
+		}
+	}
+
+	// Fill statistics about current epoch
+	epoch.FillPopulationStatistics(pop)
+
+	// Only print to file every print_every generation
+	if epoch.Solved || epoch.Id%options.PrintEvery == 0 || epoch.Id == options.NumGenerations-1 {
+		if _, err := utils.WritePopulationPlain(e.outputPath, pop, epoch); err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to dump population, reason: %s\n", err))
+			return err
+		}
+	}
+
+	if epoch.Solved {
+		// print winner organism
+		org := epoch.Champion
+		utils.PrintActivationDepth(org, true)
+
+		genomeFile := "maze_multi_winner"
+		// Prints the winner organism's Genome to the file!
+		if orgPath, err := utils.WriteGenomePlain(genomeFile, e.outputPath, org, epoch); err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to dump winner organism's genome, reason: %s\n", err))
+		} else {
+			neat.InfoLog(fmt.Sprintf("Generation #%d winner's genome dumped to: %s\n", epoch.Id, orgPath))
+		}
+	} else if epoch.Id < options.NumGenerations-1 {
+		// adjust archive settings
+		trialSim.archive.EndOfGeneration()
+		// refresh generation's novelty scores
+		trialSim.archive.EvaluatePopulationNovelty(pop, true)
+
+		speciesCount := len(pop.Species)
+
+		// adjust species count by keeping it constant
+		adjustSpeciesNumber(speciesCount, epoch.Id, e.compatAdjustFreq, e.numSpeciesTarget, options)
+
+		neat.InfoLog(fmt.Sprintf("%d species -> %d organisms [compatibility threshold: %.1f, target: %d]\n",
+			speciesCount, len(pop.Organisms), options.CompatThreshold, e.numSpeciesTarget))
+	}
+
+	return nil
+}
+
+func (e *multiAgentEvaluator) storeRecorded() {
+	// store recorded agents' performance
+	storeRecords(e.outputPath)
+
+	// store the lineage graph of agents
+	storeLineage(e.outputPath)
+
+	// print collected novelty points from archive
+	npPath := fmt.Sprintf("%s/novelty_archive_points.json", utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID))
+	npFile, err := os.Create(npPath)
+	if err == nil {
+		err = trialSim.archive.DumpNoveltyPoints(npFile)
+	}
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to print novelty points from archive, reason: %s\n", err))
+	}
+}
+
+// Evaluates individual organism as the team of agents against maze environment and returns true if all agents of the
+// team were able to solve maze by navigating to exit
+func (e *multiAgentEvaluator) orgEvaluate(org *genetics.Organism, pop *genetics.Population, epoch *experiment.Generation) (bool, error) {
+	team, err := NewMultiAgentEnvironment(e.mazeEnv, e.teamSize)
+	if err != nil {
+		return false, err
+	}
+	// the agents are controlled by clones of organism's phenotype
+	phenotypes := make([]*network.Network, e.teamSize)
+	for i := range phenotypes {
+		if phenotypes[i], err = org.Genotype.Genesis(org.Genotype.Id); err != nil {
+			return false, err
+		}
+	}
+	if err = team.Simulate(phenotypes); err != nil {
+		if errors.Is(err, ErrOutputIsNaN) {
+			// corrupted genome, but OK to continue evolutionary process
+			return false, nil
+		}
+		return false, err
+	}
+	solved := team.ExitFound()
+	if solved {
+		neat.InfoLog(fmt.Sprintf("Maze solved by all %d agents of the team\n", e.teamSize))
+	}
+
+	nItem := neatns.NewNoveltyItem()
+	nItem.IndividualID = org.Genotype.Id
+	nItem.Fitness = team.TeamFitness()
+	if nItem.Fitness <= 0 {
+		nItem.Fitness = 0.01
+	}
+	nItem.Data = team.JointBehavior()
+
+	org.Data = &genetics.OrganismData{Value: nItem} // store novelty item within organism data
+	org.IsWinner = solved                           // store if maze was solved by the team
+	org.Error = 1 - nItem.Fitness                   // error value consider how far the team from solving the maze
+
+	// calculate novelty of new individual within archive of known novel items
+	novelty := math.MaxFloat64
+	if !solved {
+		trialSim.archive.EvaluateIndividualNovelty(org, pop, false)
+		novelty = nItem.Novelty
+	}
+
+	// add records of each agent of the team
+	for i, agentEnv := range team.Agents {
+		record := AgentRecord{
+			AgentID:    trialSim.individualsCounter,
+			TeamIndex:  i,
+			X:          agentEnv.Hero.Location.X,
+			Y:          agentEnv.Hero.Location.Y,
+			Fitness:    team.AgentFitness(i),
+			GotExit:    agentEnv.ExitFound,
+			Generation: epoch.Id,
+			Novelty:    novelty,
+			SpeciesID:  org.Species.Id,
+			SpeciesAge: org.Species.Age,
+		}
+		trialSim.records.Records = append(trialSim.records.Records, record)
+		if i == 0 {
+			// add team to the lineage graph with the team's record of the first agent
+			record.Fitness, record.GotExit = nItem.Fitness, solved
+			trialSim.lineage.Add(org, &record)
+		}
+	}
+
+	// increment tested unique individuals counter
+	trialSim.individualsCounter++
+
+	// update fittest organisms list
+	if err = trialSim.archive.UpdateFittestWithOrganism(org); err != nil {
+		return false, err
+	}
+	return solved, nil
+}
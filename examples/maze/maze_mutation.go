@@ -0,0 +1,146 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// MazeMutationOptions defines how maze layouts are mutated by adding, moving or removing walls
+type MazeMutationOptions struct {
+	// The relative probability to add new wall
+	AddWallProb float64
+	// The relative probability to move one of the internal walls
+	MoveWallProb float64
+	// The relative probability to remove one of the internal walls
+	RemoveWallProb float64
+	// The minimal and maximal length of the added walls
+	MinWallLength, MaxWallLength float64
+	// The maximal distance to move the wall along each axis
+	MaxShift float64
+	// The cell size of the grid to check that mutated maze is solvable
+	CellSize float64
+	// The maximal number of attempts to produce valid mutated maze
+	MaxAttempts int
+}
+
+// DefaultMazeMutationOptions returns the maze mutation options suitable for mazes of size similar to the medium maze
+func DefaultMazeMutationOptions() MazeMutationOptions {
+	return MazeMutationOptions{
+		AddWallProb:    0.5,
+		MoveWallProb:   0.3,
+		RemoveWallProb: 0.2,
+		MinWallLength:  20,
+		MaxWallLength:  80,
+		MaxShift:       20,
+		CellSize:       4,
+		MaxAttempts:    100,
+	}
+}
+
+// MutateMaze creates the mutated copy of the maze environment by adding, moving or removing one wall. The boundary
+// walls of the maze are never moved or removed and walls are never added outside the maze bounds. The mutated maze is
+// guaranteed to be solvable by the agent, otherwise error is returned.
+func MutateMaze(env *Environment, rng *rand.Rand, opts MazeMutationOptions) (*Environment, error) {
+	total := opts.AddWallProb + opts.MoveWallProb + opts.RemoveWallProb
+	if total <= 0 {
+		return nil, errors.New("at least one maze mutation must have positive probability")
+	}
+	if len(env.Lines) == 0 {
+		return nil, errors.New("maze has no walls")
+	}
+	minX, minY, maxX, maxY := wallsBounds(env.Lines)
+	for attempt := 0; attempt < opts.MaxAttempts; attempt++ {
+		lines := make([]Line, len(env.Lines))
+		copy(lines, env.Lines)
+		internal := internalWalls(lines, minX, minY, maxX, maxY)
+
+		r := rng.Float64() * total
+		switch {
+		case r < opts.AddWallProb:
+			center := Point{X: minX + rng.Float64()*(maxX-minX), Y: minY + rng.Float64()*(maxY-minY)}
+			length := opts.MinWallLength + rng.Float64()*(opts.MaxWallLength-opts.MinWallLength)
+			angle := rng.Float64() * math.Pi
+			dx, dy := math.Cos(angle)*length/2, math.Sin(angle)*length/2
+			lines = append(lines, NewLine(
+				Point{X: center.X - dx, Y: center.Y - dy}, Point{X: center.X + dx, Y: center.Y + dy}))
+		case r < opts.AddWallProb+opts.MoveWallProb:
+			if len(internal) == 0 {
+				continue
+			}
+			i := internal[rng.Intn(len(internal))]
+			dx, dy := (rng.Float64()*2-1)*opts.MaxShift, (rng.Float64()*2-1)*opts.MaxShift
+			lines[i] = NewLine(
+				Point{X: lines[i].A.X + dx, Y: lines[i].A.Y + dy}, Point{X: lines[i].B.X + dx, Y: lines[i].B.Y + dy})
+		default:
+			if len(internal) == 0 {
+				continue
+			}
+			i := internal[rng.Intn(len(internal))]
+			lines = append(lines[:i], lines[i+1:]...)
+		}
+
+		child, err := mutatedMaze(env, lines, minX, minY, maxX, maxY, opts.CellSize)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			return child, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to produce solvable mutated maze after %d attempts", opts.MaxAttempts)
+}
+
+// mutatedMaze is to create the copy of maze environment with given walls. Returns nil if walls are outside the maze
+// bounds, the agent collides with walls at the start location or the maze exit is not reachable.
+func mutatedMaze(env *Environment, lines []Line, minX, minY, maxX, maxY, cellSize float64) (*Environment, error) {
+	for _, l := range lines {
+		for _, p := range []Point{l.A, l.B} {
+			if p.X < minX || p.X > maxX || p.Y < minY || p.Y > maxY {
+				return nil, nil
+			}
+		}
+	}
+	// the child must have its own sensors
+	child := env.Clone()
+	child.Lines = lines
+	if child.testAgentCollision(child.Hero.Location) {
+		return nil, nil
+	}
+	field, err := NewDistanceField(lines, child.MazeExit, cellSize, child.Hero.Radius)
+	if err != nil {
+		return nil, err
+	}
+	if !field.IsReachable(child.Hero.Location) {
+		return nil, nil
+	}
+	for _, w := range child.Waypoints {
+		if !field.IsReachable(w) {
+			return nil, nil
+		}
+	}
+	if err = child.initialize(); err != nil {
+		return nil, err
+	}
+	if child.distanceField != nil {
+		// the path distances must be found within the mutated walls
+		if err = child.SetFitnessMetric(GeodesicDistance, child.distanceField.CellSize); err != nil {
+			return nil, err
+		}
+	}
+	return child, nil
+}
+
+// internalWalls returns the indices of walls not lying on the boundary of the maze bounding box
+func internalWalls(lines []Line, minX, minY, maxX, maxY float64) []int {
+	indices := make([]int, 0, len(lines))
+	for i, l := range lines {
+		boundary := (l.A.X == minX && l.B.X == minX) || (l.A.X == maxX && l.B.X == maxX) ||
+			(l.A.Y == minY && l.B.Y == minY) || (l.A.Y == maxY && l.B.Y == maxY)
+		if !boundary {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
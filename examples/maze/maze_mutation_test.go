@@ -0,0 +1,71 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/rand"
+	"testing"
+)
+
+func TestMutateMaze(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	original := env
+	lines := append([]Line{}, env.Lines...)
+	minX, minY, maxX, maxY := wallsBounds(env.Lines)
+
+	opts := DefaultMazeMutationOptions()
+	rng := rand.New(rand.NewSource(42))
+	for i := 0; i < 20; i++ {
+		child, err := MutateMaze(env, rng, opts)
+		require.NoError(t, err)
+		assert.NotEqual(t, env.Lines, child.Lines)
+		assert.InDelta(t, len(env.Lines), len(child.Lines), 1)
+
+		// the walls are within bounds of the parent maze
+		cMinX, cMinY, cMaxX, cMaxY := wallsBounds(child.Lines)
+		assert.True(t, cMinX >= minX && cMinY >= minY && cMaxX <= maxX && cMaxY <= maxY)
+
+		// the maze exit is reachable
+		field, err := NewDistanceField(child.Lines, child.MazeExit, opts.CellSize, child.Hero.Radius)
+		require.NoError(t, err)
+		assert.True(t, field.IsReachable(child.Hero.Location))
+		assert.False(t, child.testAgentCollision(child.Hero.Location))
+
+		// the child has its own sensors
+		assert.Len(t, child.Hero.RangeFinders, len(env.Hero.RangeFinders))
+		assert.NotSame(t, &env.Hero.RangeFinders[0], &child.Hero.RangeFinders[0])
+
+		// mutate the child further
+		env = child
+	}
+	// the original walls are not modified
+	assert.Equal(t, lines, original.Lines)
+}
+
+func TestMutateMaze_boundaryWalls(t *testing.T) {
+	env := &Environment{
+		Hero:           Agent{Location: Point{X: 10, Y: 10}, Radius: 2},
+		MazeExit:       Point{X: 90, Y: 90},
+		Lines:          createBoxLines(100, 100),
+		ExitFoundRange: 5,
+	}
+	opts := DefaultMazeMutationOptions()
+	opts.AddWallProb, opts.MoveWallProb = 0, 0
+	opts.MaxAttempts = 10
+
+	// the boundary walls are never removed
+	_, err := MutateMaze(env, rand.New(rand.NewSource(1)), opts)
+	assert.Error(t, err)
+
+	opts.RemoveWallProb = 0
+	_, err = MutateMaze(env, rand.New(rand.NewSource(1)), opts)
+	assert.Error(t, err)
+}
+
+func TestInternalWalls(t *testing.T) {
+	lines := append(createBoxLines(100, 100), NewLine(Point{X: 0, Y: 50}, Point{X: 50, Y: 50}))
+	minX, minY, maxX, maxY := wallsBounds(lines)
+	assert.Equal(t, []float64{0, 0, 100, 100}, []float64{minX, minY, maxX, maxY})
+	assert.Equal(t, []int{4}, internalWalls(lines, minX, minY, maxX, maxY))
+}
@@ -49,8 +49,10 @@ func (e *noveltySearchEvaluator) TrialRunStarted(trial *experiment.Trial) {
 	trialSim = mazeSimResults{
 		trialID: trial.Id,
 		records: new(RecordStore),
+		lineage: NewLineage(trial.Id),
 		archive: neatns.NewNoveltyArchive(archiveThresh, NoveltyMetric, opts),
 	}
+	startRecordStream(e.outputPath)
 }
 
 func (e *noveltySearchEvaluator) TrialRunFinished(_ *experiment.Trial) {
@@ -58,8 +60,9 @@ func (e *noveltySearchEvaluator) TrialRunFinished(_ *experiment.Trial) {
 	e.storeRecorded()
 }
 
-func (e *noveltySearchEvaluator) EpochEvaluated(_ *experiment.Trial, _ *experiment.Generation) {
-	// just stub
+func (e *noveltySearchEvaluator) EpochEvaluated(_ *experiment.Trial, epoch *experiment.Generation) {
+	// append records of the evaluated generation to the stream if enabled
+	streamRecords(epoch.Id)
 }
 
 // GenerationEvaluate this method evaluates one epoch for given population and prints results into output directory if any.
@@ -142,14 +145,10 @@ func (e *noveltySearchEvaluator) GenerationEvaluate(ctx context.Context, pop *ge
 
 func (e *noveltySearchEvaluator) storeRecorded() {
 	// store recorded agents' performance
-	recPath := fmt.Sprintf("%s/record.dat", utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID))
-	recFile, err := os.Create(recPath)
-	if err == nil {
-		err = trialSim.records.Write(recFile)
-	}
-	if err != nil {
-		neat.ErrorLog(fmt.Sprintf("Failed to store agents' data records, reason: %s\n", err))
-	}
+	storeRecords(e.outputPath)
+
+	// store the lineage graph of agents
+	storeLineage(e.outputPath)
 
 	// print collected novelty points from archive
 	npPath := fmt.Sprintf("%s/novelty_archive_points.json", utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID))
@@ -202,17 +201,20 @@ func (e *noveltySearchEvaluator) orgEvaluate(org *genetics.Organism, pop *geneti
 		record.Novelty = math.MaxFloat64
 
 		// run simulation to store solver path
-		pathPoints := make([]Point, e.mazeEnv.TimeSteps)
-		if _, _, err = mazeSimulationEvaluate(e.mazeEnv, org, nil, pathPoints); err != nil {
-			neat.ErrorLog("Solver's path simulation failed\n")
+		if err = storeSolverPath(e.mazeEnv, org, &record); err != nil {
 			return false, err
 		}
-		trialSim.records.SolverPathPoints = pathPoints
 	}
 
+	// record rollout of the agent if selected
+	storeRollout(e.outputPath, e.mazeEnv, org, &record)
+
 	// add record
 	trialSim.records.Records = append(trialSim.records.Records, record)
 
+	// add agent to the lineage graph
+	trialSim.lineage.Add(org, &record)
+
 	// increment tested unique individuals counter
 	trialSim.individualsCounter++
 
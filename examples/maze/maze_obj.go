@@ -43,8 +43,10 @@ func (e *objectiveEvaluator) TrialRunStarted(trial *experiment.Trial) {
 	trialSim = mazeSimResults{
 		trialID: trial.Id,
 		records: new(RecordStore),
+		lineage: NewLineage(trial.Id),
 		archive: neatns.NewNoveltyArchive(archiveThresh, NoveltyMetric, neatns.DefaultNoveltyArchiveOptions()),
 	}
+	startRecordStream(e.outputPath)
 }
 
 func (e *objectiveEvaluator) TrialRunFinished(_ *experiment.Trial) {
@@ -52,8 +54,9 @@ func (e *objectiveEvaluator) TrialRunFinished(_ *experiment.Trial) {
 	e.storeRecorded()
 }
 
-func (e *objectiveEvaluator) EpochEvaluated(_ *experiment.Trial, _ *experiment.Generation) {
-	// just stub
+func (e *objectiveEvaluator) EpochEvaluated(_ *experiment.Trial, epoch *experiment.Generation) {
+	// append records of the evaluated generation to the stream if enabled
+	streamRecords(epoch.Id)
 }
 
 // GenerationEvaluate evaluates one epoch for given population and prints results into output directory if any.
@@ -123,14 +126,10 @@ func (e *objectiveEvaluator) GenerationEvaluate(ctx context.Context, pop *geneti
 
 func (e *objectiveEvaluator) storeRecorded() {
 	// store recorded agents' performance
-	recPath := fmt.Sprintf("%s/record.dat", utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID))
-	recFile, err := os.Create(recPath)
-	if err == nil {
-		err = trialSim.records.Write(recFile)
-	}
-	if err != nil {
-		neat.ErrorLog(fmt.Sprintf("Failed to store agents' data records, reason: %s\n", err))
-	}
+	storeRecords(e.outputPath)
+
+	// store the lineage graph of agents
+	storeLineage(e.outputPath)
 
 	// print novelty points with maximal fitness
 	npPath := fmt.Sprintf("%s/fittest_archive_points.json", utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID))
@@ -167,18 +166,20 @@ func (e *objectiveEvaluator) orgEvaluate(org *genetics.Organism, _ *genetics.Pop
 
 	if solved {
 		// run simulation to store solver path
-		pathPoints := make([]Point, e.mazeEnv.TimeSteps)
-		_, _, err := mazeSimulationEvaluate(e.mazeEnv, org, &record, pathPoints)
-		if err != nil {
-			neat.ErrorLog("Solver's path simulation failed\n")
+		if err = storeSolverPath(e.mazeEnv, org, &record); err != nil {
 			return false, err
 		}
-		trialSim.records.SolverPathPoints = pathPoints
 	}
 
+	// record rollout of the agent if selected
+	storeRollout(e.outputPath, e.mazeEnv, org, &record)
+
 	// add record
 	trialSim.records.Records = append(trialSim.records.Records, record)
 
+	// add agent to the lineage graph
+	trialSim.lineage.Add(org, &record)
+
 	// increment tested unique individuals counter
 	trialSim.individualsCounter++
 
@@ -0,0 +1,480 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/experiment/utils"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Implementation of the open-ended coevolution of maze layouts and maze solvers inspired by POET (Paired Open-Ended
+// Trailblazer). The population of solvers is evolved by NEAT across all active mazes, while new mazes are created by
+// mutating the mazes which are mastered by the current solvers. The new maze is admitted only if it's neither too
+// easy nor too hard for the current solvers, and the most novel admissible mazes are preferred. The genome of the best
+// solver of each maze found so far is kept with the maze. The trial is solved only when a solver reaches the exit of the
+// optional target maze, otherwise the coevolution runs for all generations.
+
+// PoetOptions is the options of the coevolution of maze layouts and maze solvers
+type PoetOptions struct {
+	// The maximal number of active mazes, the oldest mazes are retired when exceeded
+	MaxActiveMazes int
+	// The frequency of maze reproduction in generations
+	ReproductionFreq int
+	// The minimal fitness of the best solver for the maze to be eligible for reproduction
+	ReproductionThreshold float64
+	// The minimal fitness of the best evaluating solver for the new maze to be not too hard
+	MinCriterion float64
+	// The maximal fitness of the best evaluating solver for the new maze to be not too easy
+	MaxCriterion float64
+	// The number of mutated mazes to be created per reproduction
+	ChildrenCandidates int
+	// The maximal number of mazes to be admitted per reproduction
+	MaxAdmitted int
+	// The number of the fittest solvers to evaluate new mazes
+	Evaluators int
+	// The number of nearest mazes to estimate novelty of the new maze
+	NoveltyNeighbours int
+	// The options of maze mutation
+	Mutation MazeMutationOptions
+	// The seed of the random number generator to mutate mazes
+	Seed int64
+	// The target maze, the trial is solved when a solver reaches its exit. Not solved if nil.
+	Target *Environment
+}
+
+// DefaultPoetOptions returns default options of the coevolution of maze layouts and maze solvers
+func DefaultPoetOptions() PoetOptions {
+	return PoetOptions{
+		MaxActiveMazes:        8,
+		ReproductionFreq:      10,
+		ReproductionThreshold: 0.9,
+		MinCriterion:          0.3,
+		MaxCriterion:          0.95,
+		ChildrenCandidates:    10,
+		MaxAdmitted:           2,
+		Evaluators:            5,
+		NoveltyNeighbours:     5,
+		Mutation:              DefaultMazeMutationOptions(),
+		Seed:                  1,
+	}
+}
+
+// mazeNiche is the maze of coevolution paired with its best solver
+type mazeNiche struct {
+	// The ID of the maze
+	id int
+	// The ID of the parent maze, -1 for the seed maze
+	parentID int
+	// The maze environment
+	env *Environment
+	// The generation when maze was created
+	createdAt int
+	// The generation when maze was solved for the first time, -1 if not solved yet
+	solvedAt int
+
+	// The fitness of the best solver of the current population, reset each generation
+	bestFitness float64
+	// The genome of the best solver found so far
+	solver *genetics.Genome
+	// The fitness of the best solver found so far
+	solverFitness float64
+}
+
+// NewMazePoetEvaluator allows creating the evaluator of the open-ended coevolution of maze layouts and maze solvers.
+// The provided maze environment is used as the seed maze of coevolution. The numSpeciesTarget specifies the target
+// number of species to maintain in the population of solvers. If the number of species differ from the
+// numSpeciesTarget it will be automatically adjusted with compatAdjustFreq frequency, i.e., at each
+// epoch % compatAdjustFreq == 0
+func NewMazePoetEvaluator(out string, mazeEnv *Environment, opts PoetOptions, numSpeciesTarget, compatAdjustFreq int) (experiment.GenerationEvaluator, experiment.TrialRunObserver) {
+	evaluator := &poetEvaluator{
+		outputPath:       out,
+		mazeEnv:          mazeEnv,
+		opts:             opts,
+		numSpeciesTarget: numSpeciesTarget,
+		compatAdjustFreq: compatAdjustFreq,
+	}
+	return evaluator, evaluator
+}
+
+// poetEvaluator the open-ended coevolution of maze layouts and maze solvers
+type poetEvaluator struct {
+	// The output path to store execution results
+	outputPath string
+	// The seed maze environment
+	mazeEnv *Environment
+	// The coevolution options
+	opts PoetOptions
+
+	// The target number of species to be maintained
+	numSpeciesTarget int
+	// The species compatibility threshold adjustment frequency
+	compatAdjustFreq int
+
+	// The random number generator to mutate mazes
+	rng *rand.Rand
+	// The active mazes
+	niches []*mazeNiche
+	// The retired mazes
+	retired []*mazeNiche
+	// The ID of the next created maze
+	nextNicheID int
+}
+
+func (e *poetEvaluator) TrialRunStarted(trial *experiment.Trial) {
+	trialSim = mazeSimResults{
+		trialID: trial.Id,
+		records: new(RecordStore),
+		lineage: NewLineage(trial.Id),
+		archive: neatns.NewNoveltyArchive(archiveThresh, NoveltyMetric, neatns.DefaultNoveltyArchiveOptions()),
+	}
+	e.rng = rand.New(rand.NewSource(e.opts.Seed + int64(trial.Id)))
+	e.retired = nil
+	e.nextNicheID = 0
+	e.niches = []*mazeNiche{e.newNiche(e.mazeEnv, -1, 0)}
+	startRecordStream(e.outputPath)
+}
+
+func (e *poetEvaluator) TrialRunFinished(_ *experiment.Trial) {
+	// the last epoch executed
+	e.storeRecorded()
+}
+
+func (e *poetEvaluator) EpochEvaluated(_ *experiment.Trial, epoch *experiment.Generation) {
+	// append records of the evaluated generation to the stream if enabled
+	streamRecords(epoch.Id)
+}
+
+// GenerationEvaluate evaluates one epoch for given population of solvers across all active mazes, keeps the best
+// solver of each maze and creates new mazes with given frequency.
+func (e *poetEvaluator) GenerationEvaluate(ctx context.Context, pop *genetics.Population, epoch *experiment.Generation) error {
+	options, ok := neat.FromContext(ctx)
+	if !ok {
+		return neat.ErrNEATOptionsNotFound
+	}
+	// the best fitness is of the current population
+	for _, n := range e.niches {
+		n.bestFitness = 0
+	}
+	// Evaluate each organism across active mazes
+	for _, org := range pop.Organisms {
+		res, err := e.orgEvaluate(org, epoch)
+		if err != nil {
+			return err
+		}
+		// the solver that reached the exit of the target maze is the winner
+		if res && (epoch.Champion == nil || org.Fitness > epoch.Champion.Fitness) {
+			epoch.Solved = true
+			epoch.WinnerNodes = len(org.Genotype.Nodes)
+			epoch.WinnerGenes = org.Genotype.Extrons()
+			epoch.WinnerEvals = trialSim.individualsCounter
+			epoch.Champion = org
+		}
+	}
+
+	// Fill statistics about current epoch
+	epoch.FillPopulationStatistics(pop)
+
+	// Only print to file every print_every generation
+	if epoch.Id%options.PrintEvery == 0 || epoch.Id == options.NumGenerations-1 {
+		if _, err := utils.WritePopulationPlain(e.outputPath, pop, epoch); err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to dump population, reason: %s\n", err))
+			return err
+		}
+	}
+
+	// create new mazes
+	if epoch.Id > 0 && e.opts.ReproductionFreq > 0 && epoch.Id%e.opts.ReproductionFreq == 0 {
+		if err := e.reproduceMazes(pop, epoch); err != nil {
+			return err
+		}
+	}
+	for _, n := range e.niches {
+		neat.InfoLog(fmt.Sprintf("Maze: %d [parent: %d, created: %d, solved: %d] best fitness: %.3f, solver fitness: %.3f\n",
+			n.id, n.parentID, n.createdAt, n.solvedAt, n.bestFitness, n.solverFitness))
+	}
+
+	speciesCount := len(pop.Species)
+
+	// adjust species count by keeping it constant
+	adjustSpeciesNumber(speciesCount, epoch.Id, e.compatAdjustFreq, e.numSpeciesTarget, options)
+
+	neat.InfoLog(fmt.Sprintf("%d species -> %d organisms [compatibility threshold: %.1f, target: %d], active mazes: %d, retired: %d\n",
+		speciesCount, len(pop.Organisms), options.CompatThreshold, e.numSpeciesTarget, len(e.niches), len(e.retired)))
+
+	return nil
+}
+
+// orgEvaluate is to evaluate the solver across active mazes. The fitness of solver is its average fitness over mazes.
+// The solver is kept with the maze if it's better than the best solver of the maze found so far. Returns true if the
+// solver reached the exit of the target maze.
+func (e *poetEvaluator) orgEvaluate(org *genetics.Organism, epoch *experiment.Generation) (bool, error) {
+	// create record to store simulation results for organism within the oldest active maze
+	record := AgentRecord{Generation: epoch.Id, AgentID: trialSim.individualsCounter}
+	record.SpeciesID = org.Species.Id
+	record.SpeciesAge = org.Species.Age
+
+	var firstItem *neatns.NoveltyItem
+	fitness := 0.0
+	for i, n := range e.niches {
+		var rec *AgentRecord
+		if i == 0 {
+			rec = &record
+		}
+		nItem, solved, err := mazeSimulationEvaluate(n.env, org, rec, nil)
+		if err != nil {
+			if errors.Is(err, ErrOutputIsNaN) {
+				// corrupted genome, but OK to continue evolutionary process
+				nItem, solved = neatns.NewNoveltyItem(), false
+				nItem.Fitness = 0.01
+			} else {
+				return false, err
+			}
+		}
+		if i == 0 {
+			firstItem = nItem
+		}
+		fitness += nItem.Fitness
+
+		if nItem.Fitness > n.bestFitness {
+			n.bestFitness = nItem.Fitness
+		}
+		if nItem.Fitness > n.solverFitness {
+			// keep the best solver of the maze
+			n.solver = org.Genotype
+			n.solverFitness = nItem.Fitness
+		}
+		if solved && n.solvedAt < 0 {
+			n.solvedAt = epoch.Id
+			neat.InfoLog(fmt.Sprintf("Maze: %d solved at generation: %d by organism: %d\n", n.id, epoch.Id, org.Genotype.Id))
+		}
+	}
+	fitness /= float64(len(e.niches))
+
+	// check if the solver reached the exit of the target maze
+	solvedTarget := false
+	if e.opts.Target != nil {
+		var err error
+		if _, solvedTarget, err = mazeSimulationEvaluate(e.opts.Target, org, nil, nil); err != nil &&
+			!errors.Is(err, ErrOutputIsNaN) {
+			return false, err
+		}
+		if solvedTarget {
+			neat.InfoLog(fmt.Sprintf("Target maze solved at generation: %d by organism: %d\n", epoch.Id, org.Genotype.Id))
+		}
+	}
+	org.IsWinner = solvedTarget
+
+	firstItem.IndividualID = org.Genotype.Id
+	firstItem.Fitness = fitness
+	org.Data = &genetics.OrganismData{Value: firstItem}
+	org.Fitness = fitness
+	org.Error = 1 - fitness
+
+	// record rollout of the agent within the oldest active maze if selected
+	storeRollout(e.outputPath, e.niches[0].env, org, &record)
+
+	// add record
+	trialSim.records.Records = append(trialSim.records.Records, record)
+
+	// add agent to the lineage graph
+	trialSim.lineage.Add(org, &record)
+
+	// increment tested unique individuals counter
+	trialSim.individualsCounter++
+
+	// update the fittest organisms list
+	return solvedTarget, trialSim.archive.UpdateFittestWithOrganism(org)
+}
+
+// reproduceMazes is to create new mazes by mutating the mazes mastered by the current solvers and to admit the most
+// novel of them, which are neither too easy nor too hard for the fittest solvers of the population.
+func (e *poetEvaluator) reproduceMazes(pop *genetics.Population, epoch *experiment.Generation) error {
+	eligible := make([]*mazeNiche, 0)
+	for _, n := range e.niches {
+		if n.bestFitness >= e.opts.ReproductionThreshold {
+			eligible = append(eligible, n)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil
+	}
+
+	evaluators := fittestOrganisms(pop, e.opts.Evaluators)
+	type candidate struct {
+		env       *Environment
+		parent    *mazeNiche
+		scores    []float64
+		novelty   float64
+		solver    *genetics.Organism
+		bestScore float64
+	}
+	candidates := make([]*candidate, 0, e.opts.ChildrenCandidates)
+	for i := 0; i < e.opts.ChildrenCandidates; i++ {
+		parent := eligible[e.rng.Intn(len(eligible))]
+		child, err := MutateMaze(parent.env, e.rng, e.opts.Mutation)
+		if err != nil {
+			neat.DebugLog(fmt.Sprintf("Failed to mutate maze: %d, reason: %s\n", parent.id, err))
+			continue
+		}
+		scores, err := evaluateMazeScores(child, evaluators)
+		if err != nil {
+			return err
+		}
+		c := &candidate{env: child, parent: parent, scores: scores}
+		for j, s := range scores {
+			if c.solver == nil || s > c.bestScore {
+				c.solver, c.bestScore = evaluators[j], s
+			}
+		}
+		// the minimal criterion: not too easy and not too hard for the current solvers
+		if c.bestScore >= e.opts.MinCriterion && c.bestScore <= e.opts.MaxCriterion {
+			candidates = append(candidates, c)
+		}
+	}
+	if len(candidates) == 0 {
+		neat.InfoLog(fmt.Sprintf("No admissible mazes created at generation: %d\n", epoch.Id))
+		return nil
+	}
+
+	// estimate novelty of admissible mazes against all mazes created so far
+	known := append(append([]*mazeNiche{}, e.niches...), e.retired...)
+	knownScores := make([][]float64, len(known))
+	for i, n := range known {
+		scores, err := evaluateMazeScores(n.env, evaluators)
+		if err != nil {
+			return err
+		}
+		knownScores[i] = scores
+	}
+	for _, c := range candidates {
+		c.novelty = mazeNovelty(c.scores, knownScores, e.opts.NoveltyNeighbours)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].novelty > candidates[j].novelty
+	})
+
+	for i := 0; i < len(candidates) && i < e.opts.MaxAdmitted; i++ {
+		c := candidates[i]
+		n := e.newNiche(c.env, c.parent.id, epoch.Id)
+		n.solver, n.solverFitness = c.solver.Genotype, c.bestScore
+		e.niches = append(e.niches, n)
+		neat.InfoLog(fmt.Sprintf("Maze: %d admitted at generation: %d [parent: %d, walls: %d, novelty: %.3f, best fitness: %.3f]\n",
+			n.id, epoch.Id, n.parentID, len(n.env.Lines), c.novelty, c.bestScore))
+	}
+
+	// retire the oldest mazes
+	for len(e.niches) > e.opts.MaxActiveMazes && e.opts.MaxActiveMazes > 0 {
+		neat.InfoLog(fmt.Sprintf("Maze: %d retired at generation: %d\n", e.niches[0].id, epoch.Id))
+		e.retired = append(e.retired, e.niches[0])
+		e.niches = e.niches[1:]
+	}
+	return nil
+}
+
+// newNiche is to create new maze of coevolution with given parent
+func (e *poetEvaluator) newNiche(env *Environment, parentID, epochID int) *mazeNiche {
+	n := &mazeNiche{
+		id:        e.nextNicheID,
+		parentID:  parentID,
+		env:       env,
+		createdAt: epochID,
+		solvedAt:  -1,
+	}
+	if parentID >= 0 {
+		n.env.Name = fmt.Sprintf("poet_maze_%d", n.id)
+	}
+	e.nextNicheID++
+	return n
+}
+
+func (e *poetEvaluator) storeRecorded() {
+	trialDir := utils.CreateOutDirForTrial(e.outputPath, trialSim.trialID)
+	// store recorded agents' performance
+	storeRecords(e.outputPath)
+
+	// store the lineage graph of agents
+	storeLineage(e.outputPath)
+
+	// store mazes created during coevolution with their best solvers
+	mazesDir := filepath.Join(trialDir, "poet")
+	if err := os.MkdirAll(mazesDir, os.ModePerm); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to create directory for mazes, reason: %s\n", err))
+		return
+	}
+	for _, n := range append(append([]*mazeNiche{}, e.retired...), e.niches...) {
+		mazePath := filepath.Join(mazesDir, fmt.Sprintf("maze_%d.yml", n.id))
+		if err := WriteEnvironmentToFile(mazePath, n.env); err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to store maze: %d, reason: %s\n", n.id, err))
+		}
+		if n.solver == nil {
+			continue
+		}
+		solverPath := filepath.Join(mazesDir, fmt.Sprintf("maze_%d_solver", n.id))
+		solverFile, err := os.Create(solverPath)
+		if err == nil {
+			err = n.solver.Write(solverFile)
+		}
+		if err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to store solver of maze: %d, reason: %s\n", n.id, err))
+		}
+	}
+}
+
+// evaluateMazeScores is to evaluate fitness of given solvers within the maze
+func evaluateMazeScores(env *Environment, solvers []*genetics.Organism) ([]float64, error) {
+	scores := make([]float64, len(solvers))
+	for i, org := range solvers {
+		nItem, _, err := mazeSimulationEvaluate(env, org, nil, nil)
+		if err != nil {
+			if errors.Is(err, ErrOutputIsNaN) {
+				continue
+			}
+			return nil, err
+		}
+		scores[i] = nItem.Fitness
+	}
+	return scores, nil
+}
+
+// mazeNovelty returns the novelty of the maze characterized by fitness scores of the solvers as the average distance
+// to the k nearest known mazes characterized by the same solvers
+func mazeNovelty(scores []float64, known [][]float64, k int) float64 {
+	if len(known) == 0 {
+		return 0
+	}
+	distances := make([]float64, len(known))
+	for i, s := range known {
+		distances[i] = histDiff(scores, s)
+	}
+	sort.Float64s(distances)
+	k = int(math.Min(float64(k), float64(len(distances))))
+	if k <= 0 {
+		k = len(distances)
+	}
+	sum := 0.0
+	for _, d := range distances[:k] {
+		sum += d
+	}
+	return sum / float64(k)
+}
+
+// fittestOrganisms returns up to given number of the fittest organisms of the population
+func fittestOrganisms(pop *genetics.Population, count int) []*genetics.Organism {
+	organisms := append([]*genetics.Organism{}, pop.Organisms...)
+	sort.SliceStable(organisms, func(i, j int) bool {
+		return organisms[i].Fitness > organisms[j].Fitness
+	})
+	if count < len(organisms) {
+		organisms = organisms[:count]
+	}
+	return organisms
+}
@@ -0,0 +1,187 @@
+package maze
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/experiment"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
+	"path/filepath"
+	"testing"
+)
+
+func TestPoetEvaluator_coevolution(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 50
+	env.ExitFoundRange = 5
+	env.SampleSize = 10
+
+	opts := DefaultPoetOptions()
+	opts.MaxActiveMazes = 2
+	opts.ReproductionThreshold = 0
+	opts.MinCriterion, opts.MaxCriterion = 0, 1
+	opts.ChildrenCandidates = 3
+	opts.Evaluators = 2
+	outDir := t.TempDir()
+	evaluator, observer := NewMazePoetEvaluator(outDir, env, opts, 10, 10)
+	poet := evaluator.(*poetEvaluator)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+	require.Len(t, poet.niches, 1)
+
+	pop := createPoetTestPopulation(t, 3)
+	epoch := &experiment.Generation{Id: 1}
+	for _, org := range pop.Organisms {
+		_, err = poet.orgEvaluate(org, epoch)
+		require.NoError(t, err)
+		item, ok := org.Data.Value.(*neatns.NoveltyItem)
+		require.True(t, ok)
+		assert.Equal(t, org.Fitness, item.Fitness)
+	}
+	seed := poet.niches[0]
+	require.NotNil(t, seed.solver, "the best solver is transferred")
+	assert.True(t, seed.solverFitness > 0)
+	assert.Len(t, trialSim.records.Records, 3)
+
+	require.NoError(t, poet.reproduceMazes(pop, epoch))
+	require.Len(t, poet.niches, 2)
+	require.Len(t, poet.retired, 1, "the oldest maze is retired")
+	assert.Equal(t, seed, poet.retired[0])
+	for i, n := range poet.niches {
+		assert.Equal(t, i+1, n.id)
+		assert.Equal(t, seed.id, n.parentID)
+		assert.Equal(t, epoch.Id, n.createdAt)
+		assert.NotNil(t, n.solver)
+		assert.NotEqual(t, env.Lines, n.env.Lines)
+	}
+	// the seed maze is not modified
+	assert.Equal(t, "medium_maze", env.Name)
+
+	// the solvers are evaluated across all active mazes
+	for _, org := range pop.Organisms {
+		_, err = poet.orgEvaluate(org, &experiment.Generation{Id: 2})
+		require.NoError(t, err)
+	}
+	assert.Len(t, trialSim.records.Records, 6)
+
+	// the mazes are stored with their solvers
+	observer.TrialRunFinished(&experiment.Trial{Id: 1})
+	for i := 0; i < 3; i++ {
+		mazeEnv, err := ReadEnvironmentFromFile(filepath.Join(outDir, "1", "poet", fmt.Sprintf("maze_%d.yml", i)))
+		require.NoError(t, err)
+		assert.Len(t, mazeEnv.Lines, len(findNiche(poet, i).env.Lines))
+		assert.FileExists(t, filepath.Join(outDir, "1", "poet", fmt.Sprintf("maze_%d_solver", i)))
+	}
+}
+
+func TestPoetEvaluator_minimalCriterion(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 50
+	env.ExitFoundRange = 5
+	env.SampleSize = 10
+
+	opts := DefaultPoetOptions()
+	opts.ReproductionThreshold = 0
+	// too easy for any solver
+	opts.MinCriterion, opts.MaxCriterion = 1.1, 2
+	evaluator, observer := NewMazePoetEvaluator(t.TempDir(), env, opts, 10, 10)
+	poet := evaluator.(*poetEvaluator)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+
+	pop := createPoetTestPopulation(t, 2)
+	require.NoError(t, poet.reproduceMazes(pop, &experiment.Generation{Id: 1}))
+	assert.Len(t, poet.niches, 1)
+}
+
+func TestPoetEvaluator_GenerationEvaluate_solved(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 10
+	// any solver reaches the exit
+	env.ExitFoundRange = 1000
+	options := &neat.Options{PrintEvery: 100, NumGenerations: 100, CompatThreshold: 3}
+
+	// the solved maze doesn't stop the trial without target maze
+	evaluator, observer := NewMazePoetEvaluator(t.TempDir(), env, DefaultPoetOptions(), 10, 10)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+	pop := createPoetTestPopulation(t, 2)
+	epoch := &experiment.Generation{Id: 1}
+	err = evaluator.GenerationEvaluate(neat.NewContext(context.Background(), options), pop, epoch)
+	require.NoError(t, err)
+
+	assert.False(t, epoch.Solved)
+	assert.Nil(t, epoch.Champion)
+	poet := evaluator.(*poetEvaluator)
+	assert.Equal(t, 1, poet.niches[0].solvedAt, "the maze solve must be recorded")
+
+	// the best fitness is of the current population only
+	poet.niches[0].bestFitness = 2
+	epoch = &experiment.Generation{Id: 2}
+	err = evaluator.GenerationEvaluate(neat.NewContext(context.Background(), options), pop, epoch)
+	require.NoError(t, err)
+	assert.True(t, poet.niches[0].bestFitness <= 1)
+	assert.Equal(t, 1, poet.niches[0].solvedAt)
+
+	// the trial is solved when solver reaches the exit of the target maze
+	opts := DefaultPoetOptions()
+	opts.Target = env
+	evaluator, observer = NewMazePoetEvaluator(t.TempDir(), env, opts, 10, 10)
+	observer.TrialRunStarted(&experiment.Trial{Id: 1})
+	epoch = &experiment.Generation{Id: 1}
+	err = evaluator.GenerationEvaluate(neat.NewContext(context.Background(), options), pop, epoch)
+	require.NoError(t, err)
+
+	assert.True(t, epoch.Solved)
+	require.NotNil(t, epoch.Champion)
+	assert.True(t, epoch.Champion.IsWinner)
+	assert.NotZero(t, epoch.WinnerEvals)
+}
+
+func TestMazeNovelty(t *testing.T) {
+	known := [][]float64{{0, 0}, {1, 1}, {0.5, 0.5}}
+	assert.InDelta(t, 0.0, mazeNovelty([]float64{0, 0}, known, 1), 1e-9)
+	assert.InDelta(t, 0.25, mazeNovelty([]float64{0, 0}, known, 2), 1e-9)
+	assert.InDelta(t, 0.5, mazeNovelty([]float64{0, 0}, known, 10), 1e-9)
+	assert.Equal(t, 0.0, mazeNovelty([]float64{0, 0}, nil, 2))
+}
+
+func TestFittestOrganisms(t *testing.T) {
+	pop := createCurriculumTestPopulation(1)
+	fittest := fittestOrganisms(pop, 3)
+	require.Len(t, fittest, 3)
+	assert.Equal(t, 1.0, fittest[0].Fitness)
+	assert.Equal(t, 0.8, fittest[2].Fitness)
+	assert.Len(t, fittestOrganisms(pop, 20), 10)
+}
+
+// createPoetTestPopulation creates population of organisms with seed genome of maze solver
+func createPoetTestPopulation(t *testing.T, size int) *genetics.Population {
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+
+	pop := &genetics.Population{}
+	species := genetics.NewSpecies(1)
+	for i := 0; i < size; i++ {
+		org, err := genetics.NewOrganism(0, genome, 1)
+		require.NoError(t, err)
+		org.Species = species
+		pop.Organisms = append(pop.Organisms, org)
+	}
+	return pop
+}
+
+// findNiche returns the active or retired maze with given ID
+func findNiche(e *poetEvaluator, id int) *mazeNiche {
+	for _, n := range append(append([]*mazeNiche{}, e.retired...), e.niches...) {
+		if n.id == id {
+			return n
+		}
+	}
+	return nil
+}
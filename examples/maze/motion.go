@@ -0,0 +1,117 @@
+package maze
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// MotionModel defines how the neural network outputs are transformed into the speed and angular velocity of the maze
+// agent. The outputs are expected to be in range [0, 1].
+type MotionModel interface {
+	// Apply is to apply network outputs o1 and o2 to the given agent
+	Apply(agent *Agent, o1, o2 float64)
+}
+
+// NewMotionModel creates motion model with given name [ACCEL, DIFF_DRIVE, DIRECT]. The maxSpeed and maxAngularVelocity
+// define clamping limits of the agent's speed and angular velocity. The wheelBase is used only by differential-drive model.
+func NewMotionModel(name string, maxSpeed, maxAngularVelocity, wheelBase float64) (MotionModel, error) {
+	if maxSpeed <= 0 {
+		return nil, fmt.Errorf("max speed must be positive, but was: %f", maxSpeed)
+	}
+	if maxAngularVelocity <= 0 {
+		return nil, fmt.Errorf("max angular velocity must be positive, but was: %f", maxAngularVelocity)
+	}
+	switch strings.ToUpper(name) {
+	case "ACCEL":
+		return &AccelerationMotion{MaxSpeed: maxSpeed, MaxAngularVelocity: maxAngularVelocity}, nil
+	case "DIFF_DRIVE":
+		if wheelBase <= 0 {
+			return nil, fmt.Errorf("wheel base must be positive, but was: %f", wheelBase)
+		}
+		return &DifferentialDriveMotion{
+			WheelBase:          wheelBase,
+			MaxWheelSpeed:      maxSpeed,
+			MaxSpeed:           maxSpeed,
+			MaxAngularVelocity: maxAngularVelocity,
+		}, nil
+	case "DIRECT":
+		return &DirectVelocityMotion{MaxSpeed: maxSpeed, MaxAngularVelocity: maxAngularVelocity}, nil
+	default:
+		return nil, fmt.Errorf("unsupported motion model: %s", name)
+	}
+}
+
+// AccelerationMotion is the acceleration-style motion model where outputs are accumulated into the angular velocity
+// and speed of the agent. This is the default motion model used to produce the published results.
+type AccelerationMotion struct {
+	// The maximal absolute speed of the agent
+	MaxSpeed float64
+	// The maximal absolute angular velocity of the agent in degrees per time step
+	MaxAngularVelocity float64
+}
+
+// NewAccelerationMotion creates acceleration motion model with default limits
+func NewAccelerationMotion() *AccelerationMotion {
+	return &AccelerationMotion{MaxSpeed: maxAgentSpeed, MaxAngularVelocity: maxAgentSpeed}
+}
+
+// Apply is to accumulate outputs into agent's angular velocity (o1) and speed (o2)
+func (m *AccelerationMotion) Apply(agent *Agent, o1, o2 float64) {
+	agent.AngularVelocity += o1 - 0.5
+	agent.Speed += o2 - 0.5
+
+	// constraints of speed & angular velocity
+	agent.Speed = clamp(agent.Speed, m.MaxSpeed)
+	agent.AngularVelocity = clamp(agent.AngularVelocity, m.MaxAngularVelocity)
+}
+
+// DifferentialDriveMotion is the differential-drive motion model where outputs define velocities of the left (o1) and
+// the right (o2) wheels of the agent separated by wheel base.
+type DifferentialDriveMotion struct {
+	// The distance between agent's wheels
+	WheelBase float64
+	// The maximal absolute velocity of each wheel
+	MaxWheelSpeed float64
+	// The maximal absolute speed of the agent
+	MaxSpeed float64
+	// The maximal absolute angular velocity of the agent in degrees per time step
+	MaxAngularVelocity float64
+}
+
+// Apply is to set agent's speed and angular velocity from the velocities of its wheels
+func (m *DifferentialDriveMotion) Apply(agent *Agent, o1, o2 float64) {
+	vLeft := (2*o1 - 1) * m.MaxWheelSpeed
+	vRight := (2*o2 - 1) * m.MaxWheelSpeed
+
+	agent.Speed = clamp((vLeft+vRight)/2.0, m.MaxSpeed)
+	// the positive angular velocity turns agent anti-clockwise, i.e., when the right wheel is faster
+	angularVelocity := (vRight - vLeft) / m.WheelBase * 180.0 / math.Pi
+	agent.AngularVelocity = clamp(angularVelocity, m.MaxAngularVelocity)
+}
+
+// DirectVelocityMotion is the motion model where outputs directly set the angular velocity (o1) and speed (o2) of
+// the agent scaled to the maximal allowed values.
+type DirectVelocityMotion struct {
+	// The maximal absolute speed of the agent
+	MaxSpeed float64
+	// The maximal absolute angular velocity of the agent in degrees per time step
+	MaxAngularVelocity float64
+}
+
+// Apply is to set agent's angular velocity and speed from outputs
+func (m *DirectVelocityMotion) Apply(agent *Agent, o1, o2 float64) {
+	agent.AngularVelocity = (2*o1 - 1) * m.MaxAngularVelocity
+	agent.Speed = (2*o2 - 1) * m.MaxSpeed
+}
+
+// clamp is to limit value to the range [-limit, limit]
+func clamp(value, limit float64) float64 {
+	if value > limit {
+		return limit
+	}
+	if value < -limit {
+		return -limit
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestNewMotionModel(t *testing.T) {
+	model, err := NewMotionModel("accel", 2, 4, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &AccelerationMotion{MaxSpeed: 2, MaxAngularVelocity: 4}, model)
+
+	model, err = NewMotionModel("DIFF_DRIVE", 2, 4, 16)
+	require.NoError(t, err)
+	assert.Equal(t, &DifferentialDriveMotion{WheelBase: 16, MaxWheelSpeed: 2, MaxSpeed: 2, MaxAngularVelocity: 4}, model)
+
+	model, err = NewMotionModel("DIRECT", 2, 4, 0)
+	require.NoError(t, err)
+	assert.Equal(t, &DirectVelocityMotion{MaxSpeed: 2, MaxAngularVelocity: 4}, model)
+
+	_, err = NewMotionModel("DIFF_DRIVE", 2, 4, 0)
+	assert.Error(t, err, "wheel base must be positive")
+
+	_, err = NewMotionModel("ACCEL", 0, 4, 16)
+	assert.Error(t, err, "max speed must be positive")
+
+	_, err = NewMotionModel("DIRECT", 2, -1, 16)
+	assert.Error(t, err, "max angular velocity must be positive")
+
+	_, err = NewMotionModel("unknown", 2, 4, 16)
+	assert.Error(t, err)
+}
+
+func TestAccelerationMotion_Apply(t *testing.T) {
+	agent := NewAgent()
+	model := NewAccelerationMotion()
+
+	model.Apply(&agent, 1.0, 0.75)
+	assert.Equal(t, 0.5, agent.AngularVelocity)
+	assert.Equal(t, 0.25, agent.Speed)
+
+	// check clamping
+	for i := 0; i < 20; i++ {
+		model.Apply(&agent, 0.0, 1.0)
+	}
+	assert.Equal(t, -maxAgentSpeed, agent.AngularVelocity)
+	assert.Equal(t, maxAgentSpeed, agent.Speed)
+}
+
+func TestDifferentialDriveMotion_Apply(t *testing.T) {
+	agent := NewAgent()
+	model := &DifferentialDriveMotion{WheelBase: 10, MaxWheelSpeed: 2, MaxSpeed: 2, MaxAngularVelocity: 90}
+
+	// straight forward motion
+	model.Apply(&agent, 1.0, 1.0)
+	assert.Equal(t, 2.0, agent.Speed)
+	assert.Equal(t, 0.0, agent.AngularVelocity)
+
+	// turn in place anti-clockwise
+	model.Apply(&agent, 0.25, 0.75)
+	assert.Equal(t, 0.0, agent.Speed)
+	assert.InDelta(t, 0.2*180.0/math.Pi, agent.AngularVelocity, 1e-9)
+
+	// check angular velocity clamping
+	model.MaxAngularVelocity = 5
+	model.Apply(&agent, 1.0, 0.0)
+	assert.Equal(t, -5.0, agent.AngularVelocity)
+}
+
+func TestDirectVelocityMotion_Apply(t *testing.T) {
+	agent := NewAgent()
+	model := &DirectVelocityMotion{MaxSpeed: 2, MaxAngularVelocity: 4}
+
+	model.Apply(&agent, 0.75, 0.0)
+	assert.Equal(t, 2.0, agent.AngularVelocity)
+	assert.Equal(t, -2.0, agent.Speed)
+
+	// values are not accumulated
+	model.Apply(&agent, 0.5, 1.0)
+	assert.Equal(t, 0.0, agent.AngularVelocity)
+	assert.Equal(t, 2.0, agent.Speed)
+}
+
+func TestEnvironment_ApplyOutputs(t *testing.T) {
+	env := Environment{Hero: NewAgent()}
+
+	// default motion model
+	err := env.ApplyOutputs(1.0, 0.75)
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, env.Hero.AngularVelocity)
+	assert.Equal(t, 0.25, env.Hero.Speed)
+
+	// custom motion model
+	env.Motion = &DirectVelocityMotion{MaxSpeed: 2, MaxAngularVelocity: 4}
+	err = env.ApplyOutputs(1.0, 0.75)
+	require.NoError(t, err)
+	assert.Equal(t, 4.0, env.Hero.AngularVelocity)
+	assert.Equal(t, 1.0, env.Hero.Speed)
+
+	// NaN outputs
+	err = env.ApplyOutputs(math.NaN(), 0.75)
+	assert.ErrorIs(t, err, ErrOutputIsNaN)
+}
@@ -0,0 +1,235 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/network"
+	"math"
+	"sort"
+)
+
+// The maximal number of placement rings around the start location to search free locations for the team of agents
+const maxPlacementRings = 10
+
+// MultiAgentEnvironment is the maze environment with the team of agents navigating it simultaneously. Each agent is
+// simulated within its own copy of the maze environment and sees other agents as disc obstacles, i.e., agents detect
+// each other with rangefinders and collide with each other. The agents that found the maze exit leave the maze.
+type MultiAgentEnvironment struct {
+	// The environments of agents in the team
+	Agents []*Environment
+}
+
+// NewMultiAgentEnvironment creates the maze environment with the team of agents of given size. The agents are placed
+// around the start location of the agent in the provided environment without overlapping each other and maze walls.
+func NewMultiAgentEnvironment(env *Environment, size int) (*MultiAgentEnvironment, error) {
+	if size <= 0 {
+		return nil, errors.New("the team size must be positive")
+	}
+	locations, err := placeTeam(env, size)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MultiAgentEnvironment{Agents: make([]*Environment, size)}
+	for i, loc := range locations {
+		// each agent must have its own sensors
+		agentEnv := env.Clone()
+		agentEnv.Hero.Location = loc
+		m.Agents[i] = agentEnv
+	}
+	for i, agentEnv := range m.Agents {
+		agentEnv.peers = m.peersOf(i)
+		if err = agentEnv.initialize(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// placeTeam is to find non-overlapping locations for the team of agents around the start location of the agent. The
+// candidate locations are checked in rings of growing size and must be visible from the start location.
+func placeTeam(env *Environment, size int) ([]Point, error) {
+	start := env.Hero.Location
+	spacing := env.Hero.Radius*2 + 2
+	locations := []Point{start}
+	for ring := 1; ring <= maxPlacementRings && len(locations) < size; ring++ {
+		candidates := make([]Point, 0)
+		for dx := -ring; dx <= ring; dx++ {
+			for dy := -ring; dy <= ring; dy++ {
+				if int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy)))) == ring {
+					candidates = append(candidates, Point{
+						X: start.X + float64(dx)*spacing, Y: start.Y + float64(dy)*spacing})
+				}
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Distance(start) < candidates[j].Distance(start)
+		})
+		for _, c := range candidates {
+			if len(locations) == size {
+				break
+			}
+			if env.testAgentCollision(c) || crossesWalls(Line{A: start, B: c}, env.Lines) {
+				continue
+			}
+			locations = append(locations, c)
+		}
+	}
+	if len(locations) < size {
+		return nil, fmt.Errorf("failed to place %d agents around start location, placed: %d", size, len(locations))
+	}
+	return locations, nil
+}
+
+// peersOf returns the agents of the team, which are still in the maze, except the agent with given index
+func (m *MultiAgentEnvironment) peersOf(index int) []Obstacle {
+	peers := make([]Obstacle, 0, len(m.Agents)-1)
+	for i, agentEnv := range m.Agents {
+		if i == index || agentEnv.ExitFound {
+			continue
+		}
+		peers = append(peers, Obstacle{Shape: DiscObstacle, Center: agentEnv.Hero.Location, Radius: agentEnv.Hero.Radius})
+	}
+	return peers
+}
+
+// Update does one time step of the simulation for all agents in the team sequentially
+func (m *MultiAgentEnvironment) Update() error {
+	for i, agentEnv := range m.Agents {
+		if agentEnv.ExitFound {
+			continue
+		}
+		agentEnv.peers = m.peersOf(i)
+		if err := agentEnv.Update(); err != nil {
+			return err
+		}
+	}
+	// refresh sensors of agents with the final locations of the team members
+	for i, agentEnv := range m.Agents {
+		agentEnv.peers = m.peersOf(i)
+		if err := agentEnv.updateRangefinders(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Simulate runs the simulation of the team within the maze for the number of time steps of the maze environment or
+// until all agents found the exit. Each agent is controlled by its own phenotype.
+func (m *MultiAgentEnvironment) Simulate(phenotypes []*network.Network) error {
+	if len(phenotypes) != len(m.Agents) {
+		return fmt.Errorf("the number of phenotypes: %d doesn't match the number of agents: %d",
+			len(phenotypes), len(m.Agents))
+	}
+	depths := make([]int, len(phenotypes))
+	for i, phenotype := range phenotypes {
+		if _, err := phenotype.Flush(); err != nil {
+			return err
+		}
+		depth, err := phenotype.MaxActivationDepthWithCap(1)
+		if err != nil {
+			neat.DebugLog(fmt.Sprintf(
+				"Failed to estimate maximal depth of the network. Using default depth: %d", depth))
+		}
+		depths[i] = depth
+	}
+
+	if err := m.Update(); err != nil {
+		return err
+	}
+	for i, agentEnv := range m.Agents {
+		if err := activateAgent(agentEnv, phenotypes[i], depths[i]); err != nil {
+			return err
+		}
+	}
+
+	for step := 0; step < m.Agents[0].TimeSteps && !m.ExitFound(); step++ {
+		for i, agentEnv := range m.Agents {
+			if agentEnv.ExitFound {
+				continue
+			}
+			if err := activateAgent(agentEnv, phenotypes[i], depths[i]); err != nil {
+				return err
+			}
+			outputs := phenotypes[i].Outputs
+			if err := agentEnv.ApplyOutputs(outputs[0].Activation, outputs[1].Activation); err != nil {
+				return err
+			}
+		}
+		if err := m.Update(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// activateAgent is to load agent's sensors into the phenotype and to activate it
+func activateAgent(env *Environment, phenotype *network.Network, netDepth int) error {
+	inputs, err := env.GetInputs()
+	if err != nil {
+		return err
+	}
+	if err = phenotype.LoadSensors(inputs); err != nil {
+		return err
+	}
+	if _, err = phenotype.ForwardSteps(netDepth); err != nil && !errors.Is(err, network.ErrNetExceededMaxActivationAttempts) {
+		return err
+	}
+	return nil
+}
+
+// ExitFoundCount returns the number of agents that found the maze exit
+func (m *MultiAgentEnvironment) ExitFoundCount() int {
+	count := 0
+	for _, agentEnv := range m.Agents {
+		if agentEnv.ExitFound {
+			count++
+		}
+	}
+	return count
+}
+
+// ExitFound returns true if all agents of the team found the maze exit
+func (m *MultiAgentEnvironment) ExitFound() bool {
+	return m.ExitFoundCount() == len(m.Agents)
+}
+
+// AgentFitness returns the fitness of agent with given index in range [0, 1]
+func (m *MultiAgentEnvironment) AgentFitness(index int) float64 {
+	agentEnv := m.Agents[index]
+	if agentEnv.ExitFound {
+		return 1
+	}
+	return math.Max(agentEnv.normalizedFitness(), 0)
+}
+
+// TeamFitness returns the fitness of the team in range [0, 1] as average fitness of agents, i.e., the fraction of
+// agents that found the maze exit plus the partial progress of the others
+func (m *MultiAgentEnvironment) TeamFitness() float64 {
+	fitness := 0.0
+	for i := range m.Agents {
+		fitness += m.AgentFitness(i)
+	}
+	return fitness / float64(len(m.Agents))
+}
+
+// JointBehavior returns the joint behavior characteristic of the team: the locations of agents sorted by coordinates,
+// which makes it independent of agents' order within the team
+func (m *MultiAgentEnvironment) JointBehavior() []float64 {
+	locations := make([]Point, len(m.Agents))
+	for i, agentEnv := range m.Agents {
+		locations[i] = agentEnv.Hero.Location
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].X == locations[j].X {
+			return locations[i].Y < locations[j].Y
+		}
+		return locations[i].X < locations[j].X
+	})
+	behavior := make([]float64, 0, len(locations)*2)
+	for _, loc := range locations {
+		behavior = append(behavior, loc.X, loc.Y)
+	}
+	return behavior
+}
@@ -0,0 +1,100 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewMultiAgentEnvironment(t *testing.T) {
+	m := createMultiAgentTestEnvironment(t, 3)
+	require.Len(t, m.Agents, 3)
+	expected := []Point{{X: 0, Y: 0}, {X: -18, Y: 0}, {X: 0, Y: -18}}
+	for i, agentEnv := range m.Agents {
+		assert.Equal(t, expected[i], agentEnv.Hero.Location, "wrong location of agent: %d", i)
+		assert.Len(t, agentEnv.peers, 2)
+	}
+	// agents have their own sensors
+	assert.Equal(t, 10.0, m.Agents[1].Hero.RangeFinders[2])
+	assert.Equal(t, 100.0, m.Agents[0].Hero.RangeFinders[2])
+}
+
+func TestNewMultiAgentEnvironment_noSpace(t *testing.T) {
+	env := &Environment{
+		Hero:     NewAgent(),
+		MazeExit: Point{X: 100, Y: 0},
+		Lines: []Line{
+			NewLine(Point{X: -10, Y: -10}, Point{X: 10, Y: -10}),
+			NewLine(Point{X: 10, Y: -10}, Point{X: 10, Y: 10}),
+			NewLine(Point{X: 10, Y: 10}, Point{X: -10, Y: 10}),
+			NewLine(Point{X: -10, Y: 10}, Point{X: -10, Y: -10}),
+		},
+	}
+	_, err := NewMultiAgentEnvironment(env, 2)
+	assert.Error(t, err)
+
+	_, err = NewMultiAgentEnvironment(env, 0)
+	assert.Error(t, err)
+}
+
+func TestMultiAgentEnvironment_Update_peerCollision(t *testing.T) {
+	m := createMultiAgentTestEnvironment(t, 2)
+	// the second agent moves towards the first one
+	m.Agents[1].Hero.Speed = 5
+	err := m.Update()
+	require.NoError(t, err)
+	assert.True(t, m.Agents[1].Hero.Collided)
+	assert.Equal(t, Point{X: -18, Y: 0}, m.Agents[1].Hero.Location)
+
+	// the first agent moves away and the second can follow
+	m.Agents[0].Hero.Speed = 5
+	err = m.Update()
+	require.NoError(t, err)
+	assert.Equal(t, Point{X: 5, Y: 0}, m.Agents[0].Hero.Location)
+	assert.False(t, m.Agents[1].Hero.Collided)
+	assert.Equal(t, Point{X: -13, Y: 0}, m.Agents[1].Hero.Location)
+	assert.Equal(t, 10.0, m.Agents[1].Hero.RangeFinders[2])
+}
+
+func TestMultiAgentEnvironment_fitness(t *testing.T) {
+	m := createMultiAgentTestEnvironment(t, 3)
+	assert.False(t, m.ExitFound())
+	assert.Equal(t, 0.0, m.TeamFitness())
+
+	m.Agents[0].ExitFound = true
+	assert.Equal(t, 1, m.ExitFoundCount())
+	assert.Equal(t, 1.0, m.AgentFitness(0))
+	assert.InDelta(t, 1.0/3.0, m.TeamFitness(), 1e-9)
+	// the agent which found exit leaves the maze
+	assert.Len(t, m.peersOf(1), 1)
+
+	m.Agents[1].ExitFound = true
+	m.Agents[2].ExitFound = true
+	assert.True(t, m.ExitFound())
+	assert.Equal(t, 1.0, m.TeamFitness())
+}
+
+func TestMultiAgentEnvironment_JointBehavior(t *testing.T) {
+	m := createMultiAgentTestEnvironment(t, 3)
+	assert.Equal(t, []float64{-18, 0, 0, -18, 0, 0}, m.JointBehavior())
+}
+
+func TestMultiAgentEnvironment_Simulate_phenotypesMismatch(t *testing.T) {
+	m := createMultiAgentTestEnvironment(t, 2)
+	err := m.Simulate(nil)
+	assert.Error(t, err)
+}
+
+func createMultiAgentTestEnvironment(t *testing.T, size int) *MultiAgentEnvironment {
+	env := &Environment{
+		Hero:           NewAgent(),
+		MazeExit:       Point{X: 200, Y: 0},
+		ExitFoundRange: 5,
+		TimeSteps:      10,
+	}
+	err := env.initialize()
+	require.NoError(t, err)
+	m, err := NewMultiAgentEnvironment(env, size)
+	require.NoError(t, err)
+	return m
+}
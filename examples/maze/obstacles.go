@@ -0,0 +1,187 @@
+package maze
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ObstacleShape is the shape of the maze obstacle
+type ObstacleShape int
+
+const (
+	// SegmentObstacle the obstacle shaped as line segment
+	SegmentObstacle ObstacleShape = iota
+	// DiscObstacle the obstacle shaped as disc
+	DiscObstacle
+)
+
+// ObstacleShapeFromString parses obstacle shape from its name
+func ObstacleShapeFromString(name string) (ObstacleShape, error) {
+	switch strings.ToUpper(name) {
+	case "SEGMENT":
+		return SegmentObstacle, nil
+	case "DISC":
+		return DiscObstacle, nil
+	default:
+		return SegmentObstacle, fmt.Errorf("unsupported obstacle shape: %s", name)
+	}
+}
+
+// Stringer
+func (s ObstacleShape) String() string {
+	switch s {
+	case SegmentObstacle:
+		return "SEGMENT"
+	case DiscObstacle:
+		return "DISC"
+	default:
+		return fmt.Sprintf("ObstacleShape(%d)", int(s))
+	}
+}
+
+// ObstacleMotionType is the type of scripted motion of the maze obstacle
+type ObstacleMotionType int
+
+const (
+	// NoMotion the obstacle stays at its initial position
+	NoMotion ObstacleMotionType = iota
+	// LinearMotion the obstacle moves back and forth with constant speed between its initial position and the
+	// position displaced by the given vector
+	LinearMotion
+	// PeriodicMotion the obstacle oscillates sinusoidally between its initial position and the position displaced
+	// by the given vector
+	PeriodicMotion
+)
+
+// ObstacleMotionTypeFromString parses obstacle motion type from its name
+func ObstacleMotionTypeFromString(name string) (ObstacleMotionType, error) {
+	switch strings.ToUpper(name) {
+	case "NONE":
+		return NoMotion, nil
+	case "LINEAR":
+		return LinearMotion, nil
+	case "PERIODIC":
+		return PeriodicMotion, nil
+	default:
+		return NoMotion, fmt.Errorf("unsupported obstacle motion type: %s", name)
+	}
+}
+
+// Stringer
+func (m ObstacleMotionType) String() string {
+	switch m {
+	case NoMotion:
+		return "NONE"
+	case LinearMotion:
+		return "LINEAR"
+	case PeriodicMotion:
+		return "PERIODIC"
+	default:
+		return fmt.Sprintf("ObstacleMotionType(%d)", int(m))
+	}
+}
+
+// Obstacle is the maze obstacle with scripted motion. The position of obstacle is the function of the simulation time
+// step, thus obstacles are never mutated during simulation and can be shared between copies of the environment.
+type Obstacle struct {
+	// The shape of obstacle
+	Shape ObstacleShape
+	// The line segment of SegmentObstacle at its initial position
+	Segment Line
+	// The center of DiscObstacle at its initial position
+	Center Point
+	// The radius of DiscObstacle
+	Radius float64
+
+	// The type of obstacle's motion
+	Motion ObstacleMotionType
+	// The maximal displacement of obstacle from its initial position
+	Displacement Point
+	// The number of time steps in the full cycle of motion
+	Period int
+	// The phase of motion cycle at the time step zero as fraction of the period [0, 1)
+	Phase float64
+}
+
+// Offset returns displacement of the obstacle from its initial position at given time step
+func (o *Obstacle) Offset(step int) Point {
+	if o.Motion == NoMotion || o.Period <= 0 {
+		return Point{}
+	}
+	cycle := float64(step)/float64(o.Period) + o.Phase
+	var k float64
+	switch o.Motion {
+	case LinearMotion:
+		// triangle wave: forth during the first half of the period and back during the second
+		f := cycle - math.Floor(cycle)
+		k = 1 - math.Abs(1-2*f)
+	case PeriodicMotion:
+		k = (1 - math.Cos(2*math.Pi*cycle)) / 2
+	}
+	return Point{X: o.Displacement.X * k, Y: o.Displacement.Y * k}
+}
+
+// SegmentAt returns the line segment of SegmentObstacle at given time step
+func (o *Obstacle) SegmentAt(step int) Line {
+	offset := o.Offset(step)
+	return Line{
+		A: Point{X: o.Segment.A.X + offset.X, Y: o.Segment.A.Y + offset.Y},
+		B: Point{X: o.Segment.B.X + offset.X, Y: o.Segment.B.Y + offset.Y},
+	}
+}
+
+// CenterAt returns the center of DiscObstacle at given time step
+func (o *Obstacle) CenterAt(step int) Point {
+	offset := o.Offset(step)
+	return Point{X: o.Center.X + offset.X, Y: o.Center.Y + offset.Y}
+}
+
+// Distance returns the distance from the point to the obstacle surface at given time step
+func (o *Obstacle) Distance(p Point, step int) float64 {
+	if o.Shape == DiscObstacle {
+		return math.Max(p.Distance(o.CenterAt(step))-o.Radius, 0)
+	}
+	return o.SegmentAt(step).Distance(p)
+}
+
+// ClosestPoint returns the point on the obstacle surface closest to the given point at given time step
+func (o *Obstacle) ClosestPoint(p Point, step int) Point {
+	if o.Shape == DiscObstacle {
+		center := o.CenterAt(step)
+		d := p.Distance(center)
+		if d <= o.Radius {
+			return p
+		}
+		return Point{X: center.X + (p.X-center.X)/d*o.Radius, Y: center.Y + (p.Y-center.Y)/d*o.Radius}
+	}
+	return o.SegmentAt(step).ClosestPoint(p)
+}
+
+// Intersection finds the point where the ray (line segment going from A to B) hits the obstacle at given time step.
+// Returns the closest to A point of intersection, or A itself if it's inside the disc.
+func (o *Obstacle) Intersection(ray Line, step int) (bool, Point) {
+	if o.Shape != DiscObstacle {
+		return o.SegmentAt(step).Intersection(ray)
+	}
+	center := o.CenterAt(step)
+	dx, dy := ray.B.X-ray.A.X, ray.B.Y-ray.A.Y
+	fx, fy := ray.A.X-center.X, ray.A.Y-center.Y
+	a := dx*dx + dy*dy
+	b := 2 * (fx*dx + fy*dy)
+	c := fx*fx + fy*fy - o.Radius*o.Radius
+	discriminant := b*b - 4*a*c
+	if a == 0 || discriminant < 0 {
+		return false, Point{}
+	}
+	sqrtD := math.Sqrt(discriminant)
+	t1, t2 := (-b-sqrtD)/(2*a), (-b+sqrtD)/(2*a)
+	switch {
+	case t1 >= 0 && t1 <= 1:
+		return true, Point{X: ray.A.X + t1*dx, Y: ray.A.Y + t1*dy}
+	case t1 < 0 && t2 >= 0:
+		return true, ray.A
+	default:
+		return false, Point{}
+	}
+}
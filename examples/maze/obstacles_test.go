@@ -0,0 +1,159 @@
+package maze
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestObstacleShapeFromString(t *testing.T) {
+	for _, shape := range []ObstacleShape{SegmentObstacle, DiscObstacle} {
+		parsed, err := ObstacleShapeFromString(shape.String())
+		require.NoError(t, err)
+		assert.Equal(t, shape, parsed)
+	}
+	parsed, err := ObstacleShapeFromString("disc")
+	require.NoError(t, err)
+	assert.Equal(t, DiscObstacle, parsed)
+
+	_, err = ObstacleShapeFromString("unknown")
+	assert.Error(t, err)
+}
+
+func TestObstacleMotionTypeFromString(t *testing.T) {
+	for _, motion := range []ObstacleMotionType{NoMotion, LinearMotion, PeriodicMotion} {
+		parsed, err := ObstacleMotionTypeFromString(motion.String())
+		require.NoError(t, err)
+		assert.Equal(t, motion, parsed)
+	}
+	parsed, err := ObstacleMotionTypeFromString("periodic")
+	require.NoError(t, err)
+	assert.Equal(t, PeriodicMotion, parsed)
+
+	_, err = ObstacleMotionTypeFromString("unknown")
+	assert.Error(t, err)
+}
+
+func TestObstacle_Offset(t *testing.T) {
+	displacement := Point{X: 40, Y: -20}
+	testCases := []struct {
+		motion   ObstacleMotionType
+		phase    float64
+		step     int
+		expected float64
+	}{
+		{motion: NoMotion, step: 25, expected: 0},
+		{motion: LinearMotion, step: 0, expected: 0},
+		{motion: LinearMotion, step: 25, expected: 0.5},
+		{motion: LinearMotion, step: 50, expected: 1},
+		{motion: LinearMotion, step: 75, expected: 0.5},
+		{motion: LinearMotion, step: 100, expected: 0},
+		{motion: LinearMotion, phase: 0.5, step: 0, expected: 1},
+		{motion: PeriodicMotion, step: 0, expected: 0},
+		{motion: PeriodicMotion, step: 25, expected: 0.5},
+		{motion: PeriodicMotion, step: 50, expected: 1},
+		{motion: PeriodicMotion, step: 150, expected: 1},
+		{motion: PeriodicMotion, phase: 0.25, step: 0, expected: 0.5},
+	}
+	for _, tc := range testCases {
+		o := Obstacle{Motion: tc.motion, Displacement: displacement, Period: 100, Phase: tc.phase}
+		offset := o.Offset(tc.step)
+		assert.InDelta(t, displacement.X*tc.expected, offset.X, 1e-9, "%s at %d", tc.motion, tc.step)
+		assert.InDelta(t, displacement.Y*tc.expected, offset.Y, 1e-9, "%s at %d", tc.motion, tc.step)
+	}
+}
+
+func TestObstacle_disc(t *testing.T) {
+	o := Obstacle{
+		Shape:        DiscObstacle,
+		Center:       Point{X: 50, Y: 0},
+		Radius:       10,
+		Motion:       LinearMotion,
+		Displacement: Point{X: 0, Y: 100},
+		Period:       20,
+	}
+	assert.Equal(t, Point{X: 50, Y: 50}, o.CenterAt(5))
+	assert.Equal(t, 30.0, o.Distance(Point{X: 10, Y: 0}, 0))
+	assert.Equal(t, 0.0, o.Distance(Point{X: 55, Y: 0}, 0))
+	assert.Equal(t, Point{X: 40, Y: 0}, o.ClosestPoint(Point{X: 10, Y: 0}, 0))
+
+	ray := Line{A: Point{X: 0, Y: 0}, B: Point{X: 100, Y: 0}}
+	found, p := o.Intersection(ray, 0)
+	require.True(t, found)
+	assert.InDelta(t, 40, p.X, 1e-9)
+	assert.InDelta(t, 0, p.Y, 1e-9)
+
+	// the disc moved away from the ray
+	found, _ = o.Intersection(ray, 5)
+	assert.False(t, found)
+
+	// the ray starts inside the disc
+	found, p = o.Intersection(Line{A: Point{X: 50, Y: 5}, B: Point{X: 150, Y: 5}}, 0)
+	require.True(t, found)
+	assert.Equal(t, Point{X: 50, Y: 5}, p)
+}
+
+func TestObstacle_segment(t *testing.T) {
+	o := Obstacle{
+		Shape:        SegmentObstacle,
+		Segment:      NewLine(Point{X: 50, Y: -10}, Point{X: 50, Y: 10}),
+		Motion:       PeriodicMotion,
+		Displacement: Point{X: 20, Y: 0},
+		Period:       10,
+	}
+	assert.Equal(t, NewLine(Point{X: 70, Y: -10}, Point{X: 70, Y: 10}), o.SegmentAt(5))
+	assert.Equal(t, 40.0, o.Distance(Point{X: 10, Y: 0}, 0))
+	assert.InDelta(t, 60.0, o.Distance(Point{X: 10, Y: 0}, 5), 1e-9)
+
+	ray := Line{A: Point{X: 0, Y: 0}, B: Point{X: 100, Y: 0}}
+	found, p := o.Intersection(ray, 5)
+	require.True(t, found)
+	assert.InDelta(t, 70, p.X, 1e-9)
+}
+
+func TestEnvironment_Update_obstacles(t *testing.T) {
+	// the disc moves back and forth towards the agent
+	env := &Environment{
+		Hero:           NewAgent(),
+		MazeExit:       Point{X: 200, Y: 0},
+		ExitFoundRange: 5,
+		Obstacles: []Obstacle{{
+			Shape:        DiscObstacle,
+			Center:       Point{X: 60, Y: 0},
+			Radius:       10,
+			Motion:       LinearMotion,
+			Displacement: Point{X: -30, Y: 0},
+			Period:       8,
+		}},
+	}
+	err := env.initialize()
+	require.NoError(t, err)
+	// the rangefinder looking forward sees the disc at its initial position
+	assert.Equal(t, 50.0, env.Hero.RangeFinders[2])
+
+	env.Hero.Speed = 5
+	for i := 0; i < 3; i++ {
+		err = env.Update()
+		require.NoError(t, err)
+		assert.False(t, env.Hero.Collided)
+	}
+	assert.Equal(t, Point{X: 37.5, Y: 0}, env.Obstacles[0].CenterAt(env.TimeStep))
+	assert.InDelta(t, 12.5, env.Hero.RangeFinders[2], 1e-9)
+
+	// the disc approaches the agent and blocks it
+	for i := 0; i < 2; i++ {
+		err = env.Update()
+		require.NoError(t, err)
+		assert.True(t, env.Hero.Collided)
+		assert.Equal(t, Point{X: 15, Y: 0}, env.Hero.Location)
+	}
+
+	// the disc moves back and agent can move again
+	err = env.Update()
+	require.NoError(t, err)
+	assert.False(t, env.Hero.Collided)
+	assert.Equal(t, Point{X: 20, Y: 0}, env.Hero.Location)
+
+	// the obstacle is not mutated by simulation
+	assert.Equal(t, Point{X: 60, Y: 0}, env.Obstacles[0].Center)
+}
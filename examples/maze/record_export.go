@@ -0,0 +1,258 @@
+package maze
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/sbinet/npyio/npz"
+	"gonum.org/v1/gonum/mat"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ExportFormat defines the format to export agent records into
+type ExportFormat int
+
+const (
+	// CSVExport the comma separated values with header, one table per file
+	CSVExport ExportFormat = iota
+	// JSONLinesExport the JSON object per line
+	JSONLinesExport
+	// NPZExport the NumPy NPZ archive of float64 arrays
+	NPZExport
+)
+
+// ExportFormatFromString returns export format with given name [CSV, JSONL, NPZ]
+func ExportFormatFromString(name string) (ExportFormat, error) {
+	switch strings.ToUpper(name) {
+	case "CSV":
+		return CSVExport, nil
+	case "JSONL":
+		return JSONLinesExport, nil
+	case "NPZ":
+		return NPZExport, nil
+	default:
+		return CSVExport, fmt.Errorf("unsupported export format: %s", name)
+	}
+}
+
+// String returns the name of export format
+func (f ExportFormat) String() string {
+	switch f {
+	case CSVExport:
+		return "CSV"
+	case JSONLinesExport:
+		return "JSONL"
+	case NPZExport:
+		return "NPZ"
+	default:
+		return fmt.Sprintf("ExportFormat(%d)", int(f))
+	}
+}
+
+// The columns of exported agent records
+var recordColumns = []string{
+	"agent_id", "team_index", "x", "y", "fitness", "got_exit", "generation", "novelty", "species_id", "species_age",
+}
+
+// The columns of exported solver records
+var solverColumns = []string{
+	"agent_id", "genome_id", "generation", "species_id", "species_age", "steps_to_exit",
+}
+
+// The columns of exported solver path points
+var solverPathColumns = []string{"agent_id", "step", "x", "y"}
+
+// The columns holding integer values of exported tables
+var integerColumns = map[string]bool{
+	"agent_id": true, "team_index": true, "got_exit": true, "generation": true, "species_id": true, "species_age": true,
+	"genome_id": true, "steps_to_exit": true, "step": true, "count": true, "solvers": true, "first_generation": true,
+	"last_generation": true, "species": true, "max_species_age": true,
+}
+
+// WriteRecordsCSV writes agent records as CSV table with header
+func (s *RecordStore) WriteRecordsCSV(w io.Writer) error {
+	rows := make([][]float64, len(s.Records))
+	for i := range s.Records {
+		rows[i] = s.Records[i].values()
+	}
+	return writeCSV(w, recordColumns, rows)
+}
+
+// WriteSolversCSV writes solver path points as CSV table with header. Each row holds one point of the solver path
+// along with info about the solver agent.
+func (s *RecordStore) WriteSolversCSV(w io.Writer) error {
+	columns := append(append([]string{}, solverColumns...), solverPathColumns[1:]...)
+	rows := make([][]float64, 0)
+	for i := range s.Solvers {
+		values := s.Solvers[i].values()
+		for step, p := range s.Solvers[i].Path {
+			rows = append(rows, append(append([]float64{}, values...), float64(step), p.X, p.Y))
+		}
+	}
+	return writeCSV(w, columns, rows)
+}
+
+// WriteRecordsJSONL writes agent records as JSON objects, one per line
+func (s *RecordStore) WriteRecordsJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for i := range s.Records {
+		if err := enc.Encode(&s.Records[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSolversJSONL writes solver records as JSON objects, one per line. The solver path is the list of [x, y] pairs.
+func (s *RecordStore) WriteSolversJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for i := range s.Solvers {
+		obj := struct {
+			*SolverRecord
+			Path [][2]float64 `json:"path"`
+		}{
+			SolverRecord: &s.Solvers[i],
+			Path:         make([][2]float64, len(s.Solvers[i].Path)),
+		}
+		for j, p := range s.Solvers[i].Path {
+			obj.Path[j] = [2]float64{p.X, p.Y}
+		}
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteNPZ writes agent records and solvers as NumPy NPZ archive with the following arrays, the empty ones are omitted:
+// - records - the agent records with columns: agent_id, team_index, x, y, fitness, got_exit, generation, novelty,
+// species_id, species_age
+// - solvers - the solver records with columns: agent_id, genome_id, generation, species_id, species_age, steps_to_exit
+// - solver_paths - the solver path points with columns: agent_id, step, x, y
+func (s *RecordStore) WriteNPZ(w io.Writer) error {
+	out := npz.NewWriter(w)
+	records := make([][]float64, len(s.Records))
+	for i := range s.Records {
+		records[i] = s.Records[i].values()
+	}
+	solvers := make([][]float64, len(s.Solvers))
+	paths := make([][]float64, 0)
+	for i := range s.Solvers {
+		solvers[i] = s.Solvers[i].values()
+		for step, p := range s.Solvers[i].Path {
+			paths = append(paths, []float64{float64(s.Solvers[i].AgentID), float64(step), p.X, p.Y})
+		}
+	}
+	if err := writeNPZMatrix(out, "records", records, len(recordColumns)); err != nil {
+		return err
+	}
+	if err := writeNPZMatrix(out, "solvers", solvers, len(solverColumns)); err != nil {
+		return err
+	}
+	if err := writeNPZMatrix(out, "solver_paths", paths, len(solverPathColumns)); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// ExportRecordStore exports agent records and solvers into the files with given path prefix in the specified format.
+// The CSV and JSONL formats produce two files with "_records" and "_solvers" suffixes, and the NPZ format produces one
+// archive. Returns the paths of created files.
+func ExportRecordStore(rs *RecordStore, format ExportFormat, prefix string) ([]string, error) {
+	type export struct {
+		path  string
+		write func(io.Writer) error
+	}
+	var exports []export
+	switch format {
+	case CSVExport:
+		exports = []export{
+			{prefix + "_records.csv", rs.WriteRecordsCSV},
+			{prefix + "_solvers.csv", rs.WriteSolversCSV},
+		}
+	case JSONLinesExport:
+		exports = []export{
+			{prefix + "_records.jsonl", rs.WriteRecordsJSONL},
+			{prefix + "_solvers.jsonl", rs.WriteSolversJSONL},
+		}
+	case NPZExport:
+		exports = []export{{prefix + ".npz", rs.WriteNPZ}}
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	paths := make([]string, 0, len(exports))
+	for _, e := range exports {
+		file, err := os.Create(e.path)
+		if err != nil {
+			return paths, err
+		}
+		err = e.write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, e.path)
+	}
+	return paths, nil
+}
+
+// values returns the values of the agent record in order of recordColumns
+func (r *AgentRecord) values() []float64 {
+	gotExit := 0.0
+	if r.GotExit {
+		gotExit = 1.0
+	}
+	return []float64{
+		float64(r.AgentID), float64(r.TeamIndex), r.X, r.Y, r.Fitness, gotExit, float64(r.Generation), r.Novelty,
+		float64(r.SpeciesID), float64(r.SpeciesAge),
+	}
+}
+
+// values returns the values of the solver record in order of solverColumns
+func (r *SolverRecord) values() []float64 {
+	return []float64{
+		float64(r.AgentID), float64(r.GenomeID), float64(r.Generation), float64(r.SpeciesID), float64(r.SpeciesAge),
+		float64(r.StepsToExit),
+	}
+}
+
+// writeCSV is to write the table with header as CSV. The values of integer columns are formatted as integers, and
+// others with the shortest representation that reads back to the same float64.
+func writeCSV(w io.Writer, columns []string, rows [][]float64) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	line := make([]string, len(columns))
+	for _, row := range rows {
+		for i, v := range row {
+			if integerColumns[columns[i]] {
+				line[i] = strconv.Itoa(int(v))
+			} else {
+				line[i] = strconv.FormatFloat(v, 'g', -1, 64)
+			}
+		}
+		if err := cw.Write(line); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeNPZMatrix is to write the rows as named matrix into the NPZ archive if there are any rows
+func writeNPZMatrix(out *npz.Writer, name string, rows [][]float64, columns int) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	data := make([]float64, 0, len(rows)*columns)
+	for _, row := range rows {
+		data = append(data, row...)
+	}
+	return out.Write(name, mat.NewDense(len(rows), columns, data))
+}
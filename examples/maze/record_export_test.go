@@ -0,0 +1,171 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/sbinet/npyio/npz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gonum.org/v1/gonum/mat"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestExportFormatFromString(t *testing.T) {
+	for _, f := range []ExportFormat{CSVExport, JSONLinesExport, NPZExport} {
+		parsed, err := ExportFormatFromString(f.String())
+		require.NoError(t, err)
+		assert.Equal(t, f, parsed)
+	}
+	format, err := ExportFormatFromString("jsonl")
+	require.NoError(t, err)
+	assert.Equal(t, JSONLinesExport, format)
+
+	_, err = ExportFormatFromString("xml")
+	assert.Error(t, err)
+}
+
+func TestRecordStore_WriteRecordsCSV(t *testing.T) {
+	rs := createTestExportRecordStore()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, rs.WriteRecordsCSV(buf))
+
+	rows, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(rs.Records)+1)
+	assert.Equal(t, recordColumns, rows[0])
+	assert.Equal(t, []string{"3", "0", "0.1", "12.345678901234567", "1", "1", "2", "4.5", "2", "3"}, rows[2])
+
+	// the values are exact
+	y, err := strconv.ParseFloat(rows[2][3], 64)
+	require.NoError(t, err)
+	assert.Equal(t, rs.Records[1].Y, y)
+
+	// the integer columns are written without exponent
+	rs.Records[0].AgentID, rs.Records[0].Generation, rs.Records[0].X = 1000000, 2000000, 3000000
+	buf.Reset()
+	require.NoError(t, rs.WriteRecordsCSV(buf))
+	rows, err = csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, "1000000", rows[1][0])
+	assert.Equal(t, "2000000", rows[1][6])
+	assert.Equal(t, "3e+06", rows[1][2])
+}
+
+func TestRecordStore_WriteSolversCSV(t *testing.T) {
+	rs := createTestExportRecordStore()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, rs.WriteSolversCSV(buf))
+
+	rows, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 1+len(rs.Solvers[0].Path)+len(rs.Solvers[1].Path))
+	assert.Equal(t, []string{
+		"agent_id", "genome_id", "generation", "species_id", "species_age", "steps_to_exit", "step", "x", "y",
+	}, rows[0])
+	assert.Equal(t, []string{"3", "7", "2", "2", "3", "2", "1", "0.1", "12.345678901234567"}, rows[2])
+	assert.Equal(t, []string{"5", "9", "4", "1", "5", "1", "0", "6", "7"}, rows[3])
+}
+
+func TestRecordStore_WriteJSONL(t *testing.T) {
+	rs := createTestExportRecordStore()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, rs.WriteRecordsJSONL(buf))
+
+	scanner := bufio.NewScanner(buf)
+	records := make([]AgentRecord, 0)
+	for scanner.Scan() {
+		var obj map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &obj))
+		assert.Len(t, obj, len(recordColumns))
+		for _, c := range recordColumns {
+			assert.Contains(t, obj, c)
+		}
+		var r AgentRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		records = append(records, r)
+	}
+	assert.Equal(t, rs.Records, records)
+
+	buf.Reset()
+	require.NoError(t, rs.WriteSolversJSONL(buf))
+	scanner = bufio.NewScanner(buf)
+	solvers := make([]map[string]interface{}, 0)
+	for scanner.Scan() {
+		var obj map[string]interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &obj))
+		solvers = append(solvers, obj)
+	}
+	require.Len(t, solvers, 2)
+	assert.Equal(t, float64(2), solvers[0]["steps_to_exit"])
+	assert.Equal(t, []interface{}{[]interface{}{0.0, 1.0}, []interface{}{0.1, 12.345678901234567}}, solvers[0]["path"])
+	assert.Equal(t, float64(9), solvers[1]["genome_id"])
+}
+
+func TestRecordStore_WriteNPZ(t *testing.T) {
+	rs := createTestExportRecordStore()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, rs.WriteNPZ(buf))
+
+	r := bytes.NewReader(buf.Bytes())
+	var records, solvers, paths mat.Dense
+	require.NoError(t, npz.Read(r, "records", &records))
+	require.NoError(t, npz.Read(r, "solvers", &solvers))
+	require.NoError(t, npz.Read(r, "solver_paths", &paths))
+
+	rows, cols := records.Dims()
+	assert.Equal(t, len(rs.Records), rows)
+	assert.Equal(t, len(recordColumns), cols)
+	assert.Equal(t, rs.Records[1].values(), records.RawRowView(1))
+
+	rows, cols = solvers.Dims()
+	assert.Equal(t, len(rs.Solvers), rows)
+	assert.Equal(t, len(solverColumns), cols)
+	assert.Equal(t, rs.Solvers[1].values(), solvers.RawRowView(1))
+
+	rows, cols = paths.Dims()
+	assert.Equal(t, 3, rows)
+	assert.Equal(t, len(solverPathColumns), cols)
+	assert.Equal(t, []float64{3, 1, 0.1, 12.345678901234567}, paths.RawRowView(1))
+}
+
+func TestExportRecordStore(t *testing.T) {
+	rs := createTestExportRecordStore()
+	prefix := filepath.Join(t.TempDir(), "trial")
+	expected := map[ExportFormat][]string{
+		CSVExport:       {prefix + "_records.csv", prefix + "_solvers.csv"},
+		JSONLinesExport: {prefix + "_records.jsonl", prefix + "_solvers.jsonl"},
+		NPZExport:       {prefix + ".npz"},
+	}
+	for format, files := range expected {
+		paths, err := ExportRecordStore(rs, format, prefix)
+		require.NoError(t, err, format)
+		assert.Equal(t, files, paths)
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			require.NoError(t, err)
+			assert.True(t, info.Size() > 0)
+		}
+	}
+	_, err := ExportRecordStore(rs, ExportFormat(10), prefix)
+	assert.Error(t, err)
+}
+
+func createTestExportRecordStore() *RecordStore {
+	return &RecordStore{
+		Records: []AgentRecord{
+			{AgentID: 2, X: 1, Y: 2, Fitness: 0.2, Generation: 1, Novelty: 3, SpeciesID: 1, SpeciesAge: 2},
+			{AgentID: 3, X: 0.1, Y: 12.345678901234567, Fitness: 1, GotExit: true, Generation: 2, Novelty: 4.5,
+				SpeciesID: 2, SpeciesAge: 3},
+		},
+		Solvers: []SolverRecord{
+			{AgentID: 3, GenomeID: 7, Generation: 2, SpeciesID: 2, SpeciesAge: 3, StepsToExit: 2,
+				Path: []Point{{0, 1}, {0.1, 12.345678901234567}}},
+			{AgentID: 5, GenomeID: 9, Generation: 4, SpeciesID: 1, SpeciesAge: 5, StepsToExit: 1, Path: []Point{{6, 7}}},
+		},
+	}
+}
@@ -0,0 +1,309 @@
+package maze
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// RecordFilter is the predicate to select agent records
+type RecordFilter func(r *AgentRecord) bool
+
+// RecordQuery is the query over agent records of the record store. The filters are combined with logical AND and
+// applied when results requested.
+type RecordQuery struct {
+	// the records to query
+	records []AgentRecord
+	// the filters to be applied
+	filters []RecordFilter
+}
+
+// Query creates new query over all agent records of the store
+func (s *RecordStore) Query() *RecordQuery {
+	return &RecordQuery{records: s.Records}
+}
+
+// Where adds the filter to the query
+func (q *RecordQuery) Where(filter RecordFilter) *RecordQuery {
+	q.filters = append(q.filters, filter)
+	return q
+}
+
+// Generations selects the records collected in the generations from the given range inclusive
+func (q *RecordQuery) Generations(from, to int) *RecordQuery {
+	return q.Where(func(r *AgentRecord) bool {
+		return r.Generation >= from && r.Generation <= to
+	})
+}
+
+// Species selects the records of agents belonging to the species with given IDs
+func (q *RecordQuery) Species(ids ...int) *RecordQuery {
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	return q.Where(func(r *AgentRecord) bool {
+		return selected[r.SpeciesID]
+	})
+}
+
+// GotExit selects the records of agents that reached the maze exit or not
+func (q *RecordQuery) GotExit(gotExit bool) *RecordQuery {
+	return q.Where(func(r *AgentRecord) bool {
+		return r.GotExit == gotExit
+	})
+}
+
+// MinFitness selects the records of agents with fitness not less than threshold
+func (q *RecordQuery) MinFitness(threshold float64) *RecordQuery {
+	return q.Where(func(r *AgentRecord) bool {
+		return r.Fitness >= threshold
+	})
+}
+
+// Records returns the records selected by the query
+func (q *RecordQuery) Records() []AgentRecord {
+	selected := make([]AgentRecord, 0)
+	q.each(func(r *AgentRecord) {
+		selected = append(selected, *r)
+	})
+	return selected
+}
+
+// Count returns the number of records selected by the query
+func (q *RecordQuery) Count() int {
+	count := 0
+	q.each(func(_ *AgentRecord) {
+		count++
+	})
+	return count
+}
+
+// Stats returns the statistics of all records selected by the query
+func (q *RecordQuery) Stats() RecordStats {
+	selected := make([]*AgentRecord, 0)
+	q.each(func(r *AgentRecord) {
+		selected = append(selected, r)
+	})
+	return newRecordStats(0, selected)
+}
+
+// ByGeneration returns the statistics of records selected by the query per generation in order of generations
+func (q *RecordQuery) ByGeneration() *RecordStatsTable {
+	return q.groupBy("generation", func(r *AgentRecord) int {
+		return r.Generation
+	})
+}
+
+// BySpecies returns the statistics of records selected by the query per species in order of species IDs
+func (q *RecordQuery) BySpecies() *RecordStatsTable {
+	return q.groupBy("species_id", func(r *AgentRecord) int {
+		return r.SpeciesID
+	})
+}
+
+// each is to call the function for each record selected by the query
+func (q *RecordQuery) each(f func(r *AgentRecord)) {
+	for i := range q.records {
+		r := &q.records[i]
+		selected := true
+		for _, filter := range q.filters {
+			if selected = filter(r); !selected {
+				break
+			}
+		}
+		if selected {
+			f(r)
+		}
+	}
+}
+
+// groupBy is to aggregate the records selected by the query into groups by the key
+func (q *RecordQuery) groupBy(keyName string, key func(r *AgentRecord) int) *RecordStatsTable {
+	groups := make(map[int][]*AgentRecord)
+	q.each(func(r *AgentRecord) {
+		k := key(r)
+		groups[k] = append(groups[k], r)
+	})
+	table := &RecordStatsTable{Key: keyName, Rows: make([]RecordStats, 0, len(groups))}
+	for k, records := range groups {
+		table.Rows = append(table.Rows, newRecordStats(k, records))
+	}
+	sort.Slice(table.Rows, func(i, j int) bool {
+		return table.Rows[i].Key < table.Rows[j].Key
+	})
+	return table
+}
+
+// Distribution is the summary of the distribution of values
+type Distribution struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	StdDev float64 `json:"std_dev"`
+}
+
+// newDistribution creates summary of the distribution of given values
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	d := Distribution{Min: sorted[0], Max: sorted[len(sorted)-1]}
+	if mid := len(sorted) / 2; len(sorted)%2 == 0 {
+		d.Median = (sorted[mid-1] + sorted[mid]) / 2.0
+	} else {
+		d.Median = sorted[mid]
+	}
+	for _, v := range values {
+		d.Mean += v
+	}
+	d.Mean /= float64(len(values))
+	for _, v := range values {
+		d.StdDev += (v - d.Mean) * (v - d.Mean)
+	}
+	d.StdDev = math.Sqrt(d.StdDev / float64(len(values)))
+	return d
+}
+
+// Spread is the spatial spread of the agents' final locations
+type Spread struct {
+	// The centroid of locations
+	Center Point `json:"center"`
+	// The root mean square distance of locations from the centroid
+	Radius float64 `json:"radius"`
+	// The bounding box of locations
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// RecordStats is the statistics of the group of agent records
+type RecordStats struct {
+	// The key of the group, e.g., generation or species ID
+	Key int `json:"key"`
+	// The number of records
+	Count int `json:"count"`
+	// The number of agents that reached the maze exit
+	Solvers int `json:"solvers"`
+	// The distribution of fitness
+	Fitness Distribution `json:"fitness"`
+	// The distribution of novelty excluding the solvers with sentinel novelty score
+	Novelty Distribution `json:"novelty"`
+	// The spatial spread of agents' final locations
+	Spread Spread `json:"spread"`
+	// The range of generations of records
+	FirstGeneration int `json:"first_generation"`
+	LastGeneration  int `json:"last_generation"`
+	// The number of distinct species
+	Species int `json:"species"`
+	// The maximal age of species
+	MaxSpeciesAge int `json:"max_species_age"`
+}
+
+// newRecordStats creates statistics of the group of records with given key
+func newRecordStats(key int, records []*AgentRecord) RecordStats {
+	stats := RecordStats{Key: key, Count: len(records)}
+	if len(records) == 0 {
+		return stats
+	}
+	fitness := make([]float64, len(records))
+	novelty := make([]float64, 0, len(records))
+	species := make(map[int]bool)
+	stats.FirstGeneration, stats.LastGeneration = math.MaxInt, math.MinInt
+	stats.Spread.Min = Point{X: math.Inf(1), Y: math.Inf(1)}
+	stats.Spread.Max = Point{X: math.Inf(-1), Y: math.Inf(-1)}
+	for i, r := range records {
+		fitness[i] = r.Fitness
+		if r.Novelty < math.MaxFloat64 {
+			// the solvers have the sentinel novelty score which would distort the distribution
+			novelty = append(novelty, r.Novelty)
+		}
+		if r.GotExit {
+			stats.Solvers++
+		}
+		species[r.SpeciesID] = true
+		stats.FirstGeneration = min(stats.FirstGeneration, r.Generation)
+		stats.LastGeneration = max(stats.LastGeneration, r.Generation)
+		stats.MaxSpeciesAge = max(stats.MaxSpeciesAge, r.SpeciesAge)
+
+		stats.Spread.Center.X += r.X
+		stats.Spread.Center.Y += r.Y
+		stats.Spread.Min = Point{X: math.Min(stats.Spread.Min.X, r.X), Y: math.Min(stats.Spread.Min.Y, r.Y)}
+		stats.Spread.Max = Point{X: math.Max(stats.Spread.Max.X, r.X), Y: math.Max(stats.Spread.Max.Y, r.Y)}
+	}
+	stats.Species = len(species)
+	stats.Fitness, stats.Novelty = newDistribution(fitness), newDistribution(novelty)
+
+	stats.Spread.Center.X /= float64(len(records))
+	stats.Spread.Center.Y /= float64(len(records))
+	for _, r := range records {
+		dx, dy := r.X-stats.Spread.Center.X, r.Y-stats.Spread.Center.Y
+		stats.Spread.Radius += dx*dx + dy*dy
+	}
+	stats.Spread.Radius = math.Sqrt(stats.Spread.Radius / float64(len(records)))
+	return stats
+}
+
+// RecordStatsTable is the table of statistics of agent records grouped by the key
+type RecordStatsTable struct {
+	// The name of the key of groups, e.g., generation or species_id
+	Key string `json:"key"`
+	// The statistics of groups in order of keys
+	Rows []RecordStats `json:"rows"`
+}
+
+// Keys returns the keys of groups in order
+func (t *RecordStatsTable) Keys() []int {
+	keys := make([]int, len(t.Rows))
+	for i := range t.Rows {
+		keys[i] = t.Rows[i].Key
+	}
+	return keys
+}
+
+// Find returns the statistics of the group with given key or nil if not found
+func (t *RecordStatsTable) Find(key int) *RecordStats {
+	i := sort.Search(len(t.Rows), func(i int) bool {
+		return t.Rows[i].Key >= key
+	})
+	if i < len(t.Rows) && t.Rows[i].Key == key {
+		return &t.Rows[i]
+	}
+	return nil
+}
+
+// Columns returns the names of the table columns
+func (t *RecordStatsTable) Columns() []string {
+	columns := []string{t.Key, "count", "solvers"}
+	for _, d := range []string{"fitness", "novelty"} {
+		for _, s := range []string{"min", "max", "mean", "median", "std_dev"} {
+			columns = append(columns, d+"_"+s)
+		}
+	}
+	return append(columns,
+		"center_x", "center_y", "spread_radius", "min_x", "min_y", "max_x", "max_y",
+		"first_generation", "last_generation", "species", "max_species_age")
+}
+
+// Values returns the table rows as values in order of Columns
+func (t *RecordStatsTable) Values() [][]float64 {
+	values := make([][]float64, len(t.Rows))
+	for i, s := range t.Rows {
+		values[i] = []float64{
+			float64(s.Key), float64(s.Count), float64(s.Solvers),
+			s.Fitness.Min, s.Fitness.Max, s.Fitness.Mean, s.Fitness.Median, s.Fitness.StdDev,
+			s.Novelty.Min, s.Novelty.Max, s.Novelty.Mean, s.Novelty.Median, s.Novelty.StdDev,
+			s.Spread.Center.X, s.Spread.Center.Y, s.Spread.Radius, s.Spread.Min.X, s.Spread.Min.Y, s.Spread.Max.X,
+			s.Spread.Max.Y, float64(s.FirstGeneration), float64(s.LastGeneration), float64(s.Species),
+			float64(s.MaxSpeciesAge),
+		}
+	}
+	return values
+}
+
+// WriteCSV writes the table as CSV with header
+func (t *RecordStatsTable) WriteCSV(w io.Writer) error {
+	return writeCSV(w, t.Columns(), t.Values())
+}
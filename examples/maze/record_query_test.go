@@ -0,0 +1,147 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math"
+	"testing"
+)
+
+func TestRecordQuery_filters(t *testing.T) {
+	rs := createTestQueryRecordStore()
+
+	assert.Equal(t, len(rs.Records), rs.Query().Count())
+	assert.Equal(t, []int{2, 3, 4, 5}, queryAgentIDs(rs.Query().Generations(1, 2)))
+	assert.Equal(t, []int{0, 2, 4}, queryAgentIDs(rs.Query().Species(1)))
+	assert.Equal(t, []int{5}, queryAgentIDs(rs.Query().GotExit(true)))
+	assert.Equal(t, []int{3, 4, 5}, queryAgentIDs(rs.Query().MinFitness(0.5)))
+
+	// the filters are combined
+	assert.Equal(t, []int{4}, queryAgentIDs(rs.Query().Species(1).MinFitness(0.5)))
+	assert.Equal(t, []int{3}, queryAgentIDs(rs.Query().Generations(0, 1).Species(2, 3).GotExit(false).Where(
+		func(r *AgentRecord) bool {
+			return r.X > 2
+		})))
+	assert.Empty(t, rs.Query().Generations(3, 4).Records())
+}
+
+func TestRecordQuery_Stats(t *testing.T) {
+	rs := createTestQueryRecordStore()
+	stats := rs.Query().Stats()
+
+	assert.Equal(t, 6, stats.Count)
+	assert.Equal(t, 1, stats.Solvers)
+	assert.Equal(t, 3, stats.Species)
+	assert.Equal(t, 0, stats.FirstGeneration)
+	assert.Equal(t, 2, stats.LastGeneration)
+	assert.Equal(t, 3, stats.MaxSpeciesAge)
+
+	assert.Equal(t, 0.1, stats.Fitness.Min)
+	assert.Equal(t, 1.0, stats.Fitness.Max)
+	assert.InDelta(t, 2.8/6, stats.Fitness.Mean, 1e-12)
+	assert.InDelta(t, 0.4, stats.Fitness.Median, 1e-12)
+	assert.InDelta(t, math.Sqrt(0.57333333333333333/6), stats.Fitness.StdDev, 1e-12)
+	assert.Equal(t, 1.0, stats.Novelty.Min)
+	assert.Equal(t, 6.0, stats.Novelty.Max)
+
+	assert.Equal(t, Point{X: 2.5, Y: 0}, stats.Spread.Center)
+	assert.Equal(t, Point{X: 0, Y: -1}, stats.Spread.Min)
+	assert.Equal(t, Point{X: 5, Y: 1}, stats.Spread.Max)
+	assert.InDelta(t, math.Sqrt((6.25+2.25+0.25+0.25+2.25+6.25)/6+1), stats.Spread.Radius, 1e-12)
+
+	// no records selected
+	empty := rs.Query().Generations(10, 20).Stats()
+	assert.Equal(t, RecordStats{}, empty)
+}
+
+func TestRecordQuery_Stats_solvers(t *testing.T) {
+	rs := createTestQueryRecordStore()
+	// the solvers stored with the sentinel novelty score
+	rs.Records[4].GotExit, rs.Records[4].Novelty = true, math.MaxFloat64
+	rs.Records[5].Novelty = math.MaxFloat64
+	stats := rs.Query().Stats()
+
+	assert.Equal(t, 2, stats.Solvers)
+	assert.Equal(t, 1.0, stats.Novelty.Min)
+	assert.Equal(t, 4.0, stats.Novelty.Max)
+	assert.Equal(t, 2.5, stats.Novelty.Mean)
+	assert.InDelta(t, math.Sqrt(1.25), stats.Novelty.StdDev, 1e-12)
+
+	// only solvers in the group
+	stats = rs.Query().GotExit(true).Stats()
+	assert.Equal(t, Distribution{}, stats.Novelty)
+}
+
+func TestRecordQuery_ByGeneration(t *testing.T) {
+	rs := createTestQueryRecordStore()
+	table := rs.Query().ByGeneration()
+
+	assert.Equal(t, "generation", table.Key)
+	assert.Equal(t, []int{0, 1, 2}, table.Keys())
+	for _, row := range table.Rows {
+		assert.Equal(t, 2, row.Count)
+		assert.Equal(t, row.Key, row.FirstGeneration)
+		assert.Equal(t, row.Key, row.LastGeneration)
+	}
+	last := table.Find(2)
+	require.NotNil(t, last)
+	assert.Equal(t, 1, last.Solvers)
+	assert.Equal(t, 1.0, last.Fitness.Max)
+	assert.InDelta(t, 0.85, last.Fitness.Mean, 1e-12)
+	assert.Nil(t, table.Find(3))
+}
+
+func TestRecordQuery_BySpecies(t *testing.T) {
+	rs := createTestQueryRecordStore()
+	table := rs.Query().MinFitness(0.2).BySpecies()
+
+	assert.Equal(t, "species_id", table.Key)
+	assert.Equal(t, []int{1, 2, 3}, table.Keys())
+	species := table.Find(1)
+	require.NotNil(t, species)
+	assert.Equal(t, 2, species.Count)
+	assert.Equal(t, 1, species.FirstGeneration)
+	assert.Equal(t, 2, species.LastGeneration)
+	assert.Equal(t, 3, species.MaxSpeciesAge)
+	assert.Equal(t, 1, species.Species)
+}
+
+func TestRecordStatsTable_WriteCSV(t *testing.T) {
+	rs := createTestQueryRecordStore()
+	table := rs.Query().ByGeneration()
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, table.WriteCSV(buf))
+
+	rows, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, len(table.Rows)+1)
+	assert.Equal(t, table.Columns(), rows[0])
+	assert.Equal(t, "generation", rows[0][0])
+	for _, row := range rows {
+		assert.Len(t, row, len(table.Columns()))
+	}
+	assert.Equal(t, []string{"2", "2", "1"}, rows[3][:3])
+}
+
+func queryAgentIDs(q *RecordQuery) []int {
+	ids := make([]int, 0)
+	for _, r := range q.Records() {
+		ids = append(ids, r.AgentID)
+	}
+	return ids
+}
+
+func createTestQueryRecordStore() *RecordStore {
+	return &RecordStore{
+		Records: []AgentRecord{
+			{AgentID: 0, X: 0, Y: 1, Fitness: 0.1, Generation: 0, Novelty: 1, SpeciesID: 1, SpeciesAge: 1},
+			{AgentID: 1, X: 1, Y: -1, Fitness: 0.2, Generation: 0, Novelty: 2, SpeciesID: 2, SpeciesAge: 1},
+			{AgentID: 2, X: 2, Y: 1, Fitness: 0.3, Generation: 1, Novelty: 3, SpeciesID: 1, SpeciesAge: 2},
+			{AgentID: 3, X: 3, Y: -1, Fitness: 0.5, Generation: 1, Novelty: 4, SpeciesID: 2, SpeciesAge: 2},
+			{AgentID: 4, X: 4, Y: 1, Fitness: 0.7, Generation: 2, Novelty: 5, SpeciesID: 1, SpeciesAge: 3},
+			{AgentID: 5, X: 5, Y: -1, Fitness: 1, GotExit: true, Generation: 2, Novelty: 6, SpeciesID: 3, SpeciesAge: 1},
+		},
+	}
+}
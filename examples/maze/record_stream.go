@@ -0,0 +1,320 @@
+package maze
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/experiment/utils"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StreamRecords is to indicate whether the maze experiments should append agent records to the record stream file
+// of the trial after each generation instead of keeping them in memory until the end of the trial
+var StreamRecords = false
+
+// RecordStreamFlushInterval is the minimal interval between flushes of the record stream to the disk
+var RecordStreamFlushInterval = 10 * time.Second
+
+// The name of the record stream file within the trial output directory
+const recordStreamFileName = "record_stream.dat"
+
+// The magic bytes at the beginning of the record stream, followed by the schema version of the records
+const recordStreamMagic = "MZSTREAM"
+
+// The size of the record stream header: the magic bytes and the schema version
+const recordStreamHeaderSize = len(recordStreamMagic) + 2
+
+// The size of frame header: the payload length and its checksum
+const recordFrameHeaderSize = 8
+
+// The readers of the record frame payload per schema version of the records
+var recordFrameReaders = map[uint16]func(payload []byte) (*RecordFrame, error){
+	2: readRecordFrameV2,
+}
+
+// ErrTruncatedRecordFrame is returned when the last frame of the record stream is truncated or corrupted, e.g. when
+// the experiment was interrupted while writing it
+var ErrTruncatedRecordFrame = errors.New("truncated or corrupted record frame")
+
+// RecordFrame is the frame of the record stream holding the records of agents evaluated in one generation
+type RecordFrame struct {
+	// The population generation when agents were evaluated
+	Generation int
+	// The records of evaluated agents
+	Records []AgentRecord
+	// The records of agents that solved the maze
+	Solvers []SolverRecord
+}
+
+// RecordStreamWriter appends the frames of agent records to the stream. Each frame is written as its payload length,
+// CRC32 checksum of the payload, and the payload encoded with gob. The frames are self-contained, thus the stream can
+// be read back up to the last completely written frame.
+type RecordStreamWriter struct {
+	// the buffered writer of the stream
+	w *bufio.Writer
+	// the underlying writer
+	out io.Writer
+	// the minimal interval between flushes
+	flushInterval time.Duration
+	// the time of the last flush
+	lastFlush time.Time
+	// the flag to indicate whether the writer is closed
+	closed bool
+}
+
+// NewRecordStreamWriter creates new record stream writer and writes the stream header. The written frames are
+// flushed to the underlying writer if at least flushInterval elapsed since the last flush.
+func NewRecordStreamWriter(w io.Writer, flushInterval time.Duration) (*RecordStreamWriter, error) {
+	sw := &RecordStreamWriter{
+		w:             bufio.NewWriter(w),
+		out:           w,
+		flushInterval: flushInterval,
+		lastFlush:     time.Now(),
+	}
+	header := make([]byte, recordStreamHeaderSize)
+	copy(header, recordStreamMagic)
+	binary.BigEndian.PutUint16(header[len(recordStreamMagic):], RecordStoreVersion)
+	if _, err := sw.w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := sw.Flush(); err != nil {
+		return nil, err
+	}
+	return sw, nil
+}
+
+// CreateRecordStreamFile creates the file with given path and the record stream writer into it
+func CreateRecordStreamFile(path string, flushInterval time.Duration) (*RecordStreamWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	sw, err := NewRecordStreamWriter(file, flushInterval)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return sw, nil
+}
+
+// WriteFrame appends the frame to the stream
+func (sw *RecordStreamWriter) WriteFrame(frame *RecordFrame) error {
+	if sw.closed {
+		return errors.New("record stream writer is closed")
+	}
+	payload := bytes.NewBuffer(nil)
+	if err := gob.NewEncoder(payload).Encode(frame); err != nil {
+		return err
+	}
+	header := make([]byte, recordFrameHeaderSize)
+	binary.BigEndian.PutUint32(header[:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload.Bytes()))
+	if _, err := sw.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := sw.w.Write(payload.Bytes()); err != nil {
+		return err
+	}
+	if time.Since(sw.lastFlush) >= sw.flushInterval {
+		return sw.Flush()
+	}
+	return nil
+}
+
+// Flush is to flush the written frames to the underlying writer and to sync it with the disk if it's a file
+func (sw *RecordStreamWriter) Flush() error {
+	sw.lastFlush = time.Now()
+	if err := sw.w.Flush(); err != nil {
+		return err
+	}
+	if file, ok := sw.out.(*os.File); ok {
+		return file.Sync()
+	}
+	return nil
+}
+
+// Close is to flush the written frames and to close the underlying writer if it's closable. It's safe to call it
+// many times.
+func (sw *RecordStreamWriter) Close() error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	if closer, ok := sw.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// RecordStreamReader reads the frames of agent records from the stream incrementally
+type RecordStreamReader struct {
+	// the buffered reader of the stream
+	r *bufio.Reader
+	// the reader of frame payload of the stream schema version
+	readFrame func(payload []byte) (*RecordFrame, error)
+}
+
+// NewRecordStreamReader creates new record stream reader and checks the stream header. The frames of older schema
+// versions are migrated to the current schema when read.
+func NewRecordStreamReader(r io.Reader) (*RecordStreamReader, error) {
+	sr := &RecordStreamReader{r: bufio.NewReader(r)}
+	header := make([]byte, recordStreamHeaderSize)
+	if _, err := io.ReadFull(sr.r, header); err != nil || string(header[:len(recordStreamMagic)]) != recordStreamMagic {
+		return nil, errors.New("not a record stream")
+	}
+	version := binary.BigEndian.Uint16(header[len(recordStreamMagic):])
+	read, ok := recordFrameReaders[version]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record stream version: %d, the latest supported: %d", version, RecordStoreVersion)
+	}
+	sr.readFrame = read
+	return sr, nil
+}
+
+// Next returns the next frame of the stream. Returns io.EOF at the end of the stream, or ErrTruncatedRecordFrame if
+// the frame is incomplete or its checksum doesn't match.
+func (sr *RecordStreamReader) Next() (*RecordFrame, error) {
+	header := make([]byte, recordFrameHeaderSize)
+	if n, err := io.ReadFull(sr.r, header); err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: frame header of %d bytes", ErrTruncatedRecordFrame, n)
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[:4]))
+	if n, err := io.ReadFull(sr.r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %d of %d payload bytes", ErrTruncatedRecordFrame, n, len(payload))
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrTruncatedRecordFrame)
+	}
+	return sr.readFrame(payload)
+}
+
+// recordFrameV2 is the frozen RecordFrame of schema version 2
+type recordFrameV2 struct {
+	Generation int
+	Records    []agentRecordV2
+	Solvers    []solverRecordV2
+}
+
+// readRecordFrameV2 reads the frame payload of schema version 2 and migrates it to the current schema
+func readRecordFrameV2(payload []byte) (*RecordFrame, error) {
+	var v2 recordFrameV2
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&v2); err != nil {
+		return nil, err
+	}
+	frame := &RecordFrame{Generation: v2.Generation}
+	for _, r := range v2.Records {
+		frame.Records = append(frame.Records, r.migrate())
+	}
+	for _, r := range v2.Solvers {
+		frame.Solvers = append(frame.Solvers, r.migrate())
+	}
+	return frame, nil
+}
+
+// ReadRecordStream reads all frames of the record stream into the record store. If the stream ends with truncated
+// frame, the store with records of all complete frames is returned along with ErrTruncatedRecordFrame.
+func ReadRecordStream(r io.Reader) (*RecordStore, error) {
+	sr, err := NewRecordStreamReader(r)
+	if err != nil {
+		return nil, err
+	}
+	rs := new(RecordStore)
+	for {
+		frame, err := sr.Next()
+		if err == io.EOF {
+			return rs, nil
+		} else if err != nil {
+			return rs, err
+		}
+		rs.Records = append(rs.Records, frame.Records...)
+		rs.Solvers = append(rs.Solvers, frame.Solvers...)
+		if len(frame.Solvers) > 0 {
+			rs.SolverPathPoints = frame.Solvers[len(frame.Solvers)-1].Path
+		}
+	}
+}
+
+// ReadRecordStore reads the agent records either from the record stream or from the record store data. The truncated
+// frame at the end of the record stream is skipped with a warning.
+func ReadRecordStore(r io.Reader) (*RecordStore, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(recordStreamMagic)); err == nil && string(magic) == recordStreamMagic {
+		rs, err := ReadRecordStream(br)
+		if errors.Is(err, ErrTruncatedRecordFrame) {
+			neat.WarnLog(fmt.Sprintf("Record stream is truncated, the last frame skipped: %s\n", err))
+			err = nil
+		}
+		return rs, err
+	}
+	rs := new(RecordStore)
+	if err := rs.Read(br); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// startRecordStream is to create the record stream file of the current trial if records streaming is enabled. The
+// records are kept in memory if stream can not be created.
+func startRecordStream(outputPath string) {
+	if !StreamRecords {
+		return
+	}
+	path := filepath.Join(utils.CreateOutDirForTrial(outputPath, trialSim.trialID), recordStreamFileName)
+	stream, err := CreateRecordStreamFile(path, RecordStreamFlushInterval)
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to create record stream, records are kept in memory, reason: %s\n", err))
+		return
+	}
+	trialSim.stream = stream
+}
+
+// streamRecords is to append the records collected since the last call to the record stream of the current trial
+// and to release them from memory
+func streamRecords(generation int) {
+	if trialSim.stream == nil || len(trialSim.records.Records) == 0 {
+		return
+	}
+	frame := &RecordFrame{
+		Generation: generation,
+		Records:    trialSim.records.Records,
+		Solvers:    trialSim.records.Solvers,
+	}
+	if err := trialSim.stream.WriteFrame(frame); err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to append records of generation: %d to the stream, reason: %s\n",
+			generation, err))
+		return
+	}
+	trialSim.records.Records, trialSim.records.Solvers = nil, nil
+}
+
+// storeRecords is to store the records of agents collected during the current trial either by closing the record
+// stream, or by writing them into the record store file of the trial
+func storeRecords(outputPath string) {
+	if trialSim.stream != nil {
+		if err := trialSim.stream.Close(); err != nil {
+			neat.ErrorLog(fmt.Sprintf("Failed to close record stream, reason: %s\n", err))
+		}
+		return
+	}
+	recPath := fmt.Sprintf("%s/record.dat", utils.CreateOutDirForTrial(outputPath, trialSim.trialID))
+	recFile, err := os.Create(recPath)
+	if err == nil {
+		err = trialSim.records.Write(recFile)
+	}
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to store agents' data records, reason: %s\n", err))
+	}
+}
@@ -0,0 +1,182 @@
+package maze
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordStreamWriter_WriteFrame(t *testing.T) {
+	frames := createTestRecordFrames()
+	buf := bytes.NewBuffer(nil)
+	sw, err := NewRecordStreamWriter(buf, 0)
+	require.NoError(t, err)
+	for _, f := range frames {
+		require.NoError(t, sw.WriteFrame(f))
+	}
+	require.NoError(t, sw.Close())
+	require.NoError(t, sw.Close(), "close must be idempotent")
+	assert.Error(t, sw.WriteFrame(frames[0]))
+
+	// read frames incrementally
+	sr, err := NewRecordStreamReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	for _, expected := range frames {
+		frame, err := sr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, expected, frame)
+	}
+	_, err = sr.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRecordStreamWriter_flushInterval(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	sw, err := NewRecordStreamWriter(buf, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, recordStreamHeaderSize, buf.Len(), "header must be flushed")
+	assert.Equal(t, recordStreamMagic, buf.String()[:len(recordStreamMagic)])
+
+	require.NoError(t, sw.WriteFrame(createTestRecordFrames()[0]))
+	assert.Equal(t, recordStreamHeaderSize, buf.Len(), "frame must be buffered")
+
+	require.NoError(t, sw.Flush())
+	assert.True(t, buf.Len() > recordStreamHeaderSize)
+}
+
+func TestReadRecordStream(t *testing.T) {
+	frames := createTestRecordFrames()
+	data := writeTestRecordStream(t, frames)
+
+	rs, err := ReadRecordStream(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, append(frames[0].Records, frames[1].Records...), rs.Records)
+	assert.Equal(t, frames[1].Solvers, rs.Solvers)
+	assert.Equal(t, frames[1].Solvers[0].Path, rs.SolverPathPoints)
+}
+
+func TestReadRecordStream_truncated(t *testing.T) {
+	frames := createTestRecordFrames()
+	data := writeTestRecordStream(t, frames)
+
+	// cut the last frame at any position
+	first := len(writeTestRecordStream(t, frames[:1]))
+	for size := first; size < len(data); size++ {
+		rs, err := ReadRecordStream(bytes.NewReader(data[:size]))
+		if size == first {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, ErrTruncatedRecordFrame, "size: %d", size)
+		}
+		assert.Equal(t, frames[0].Records, rs.Records, "size: %d", size)
+		assert.Empty(t, rs.Solvers)
+	}
+
+	// corrupt the payload of the last frame
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	rs, err := ReadRecordStream(bytes.NewReader(corrupted))
+	assert.ErrorIs(t, err, ErrTruncatedRecordFrame)
+	assert.Equal(t, frames[0].Records, rs.Records)
+}
+
+func TestReadRecordStore(t *testing.T) {
+	frames := createTestRecordFrames()
+	data := writeTestRecordStream(t, frames)
+
+	// the truncated stream
+	rs, err := ReadRecordStore(bytes.NewReader(data[:len(data)-1]))
+	require.NoError(t, err)
+	assert.Equal(t, frames[0].Records, rs.Records)
+
+	// the record store data
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, (&RecordStore{Records: frames[1].Records, Solvers: frames[1].Solvers}).Write(buf))
+	rs, err = ReadRecordStore(buf)
+	require.NoError(t, err)
+	assert.Equal(t, frames[1].Records, rs.Records)
+	assert.Equal(t, frames[1].Solvers, rs.Solvers)
+
+	_, err = ReadRecordStore(bytes.NewReader([]byte("garbage")))
+	assert.Error(t, err)
+}
+
+func TestNewRecordStreamReader_version(t *testing.T) {
+	data := writeTestRecordStream(t, createTestRecordFrames())
+	data[recordStreamHeaderSize-1]++
+	_, err := NewRecordStreamReader(bytes.NewReader(data))
+	assert.EqualError(t, err, fmt.Sprintf("unsupported record stream version: %d, the latest supported: %d",
+		RecordStoreVersion+1, RecordStoreVersion))
+}
+
+func TestRecordStream_trial(t *testing.T) {
+	StreamRecords = true
+	defer func() {
+		StreamRecords = false
+	}()
+	outDir := t.TempDir()
+	frames := createTestRecordFrames()
+
+	trialSim = mazeSimResults{trialID: 1, records: new(RecordStore)}
+	startRecordStream(outDir)
+	require.NotNil(t, trialSim.stream)
+	for _, f := range frames {
+		trialSim.records.Records = append(trialSim.records.Records, f.Records...)
+		trialSim.records.Solvers = append(trialSim.records.Solvers, f.Solvers...)
+		streamRecords(f.Generation)
+		assert.Empty(t, trialSim.records.Records, "records must be released")
+	}
+	storeRecords(outDir)
+
+	files, err := filepath.Glob(filepath.Join(outDir, "*", recordStreamFileName))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	file, err := os.Open(files[0])
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	rs, err := ReadRecordStore(file)
+	require.NoError(t, err)
+	assert.Equal(t, append(frames[0].Records, frames[1].Records...), rs.Records)
+	assert.Equal(t, frames[1].Solvers, rs.Solvers)
+}
+
+func writeTestRecordStream(t *testing.T, frames []*RecordFrame) []byte {
+	buf := bytes.NewBuffer(nil)
+	sw, err := NewRecordStreamWriter(buf, 0)
+	require.NoError(t, err)
+	for _, f := range frames {
+		require.NoError(t, sw.WriteFrame(f))
+	}
+	require.NoError(t, sw.Close())
+	return buf.Bytes()
+}
+
+func createTestRecordFrames() []*RecordFrame {
+	return []*RecordFrame{
+		{
+			Generation: 0,
+			Records: []AgentRecord{
+				{AgentID: 0, X: 1, Y: 2, Fitness: 0.1, Generation: 0, SpeciesID: 1, SpeciesAge: 1},
+				{AgentID: 1, X: 3, Y: 4, Fitness: 0.2, Generation: 0, SpeciesID: 2, SpeciesAge: 1},
+			},
+		},
+		{
+			Generation: 1,
+			Records: []AgentRecord{
+				{AgentID: 2, X: 5, Y: 6, Fitness: 0.3, Generation: 1, SpeciesID: 1, SpeciesAge: 2},
+				{AgentID: 3, X: 7, Y: 8, Fitness: 1, GotExit: true, Generation: 1, SpeciesID: 2, SpeciesAge: 2},
+			},
+			Solvers: []SolverRecord{
+				{AgentID: 3, GenomeID: 1, Generation: 1, SpeciesID: 2, SpeciesAge: 2, StepsToExit: 2, Path: []Point{{1, 1}, {7, 8}}},
+			},
+		},
+	}
+}
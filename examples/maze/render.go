@@ -0,0 +1,121 @@
+package maze
+
+import (
+	"errors"
+	"github.com/fogleman/gg"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+)
+
+// The margin around maze walls in the rendered image
+const renderMargin = 10.0
+
+// RenderEnvironment renders the current state of the maze environment with given scale factor: the maze walls,
+// obstacles at the current time step, waypoints, maze exit, and the agent with its heading.
+func RenderEnvironment(env *Environment, scale float64) (image.Image, error) {
+	dc, err := newRenderContext(env, scale)
+	if err != nil {
+		return nil, err
+	}
+	drawEnvironment(env, dc)
+	drawAgent(&env.Hero, dc)
+
+	return dc.Image(), nil
+}
+
+// WriteEnvironmentPNG renders the current state of the maze environment with given scale factor as PNG image into
+// the writer
+func WriteEnvironmentPNG(w io.Writer, env *Environment, scale float64) error {
+	img, err := RenderEnvironment(env, scale)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// newRenderContext is to create the drawing context which fits the maze walls with given scale factor
+func newRenderContext(env *Environment, scale float64) (*gg.Context, error) {
+	if len(env.Lines) == 0 {
+		return nil, errors.New("maze has no walls")
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	minX, minY, maxX, maxY := wallsBounds(env.Lines)
+	width := int(math.Ceil((maxX - minX + 2*renderMargin) * scale))
+	height := int(math.Ceil((maxY - minY + 2*renderMargin) * scale))
+
+	dc := gg.NewContext(width, height)
+	dc.SetColor(color.White)
+	dc.Clear()
+	dc.Scale(scale, scale)
+	dc.Translate(renderMargin-minX, renderMargin-minY)
+	return dc, nil
+}
+
+// drawEnvironment is to draw the maze walls, obstacles at the current time step, waypoints, and maze exit
+func drawEnvironment(env *Environment, dc *gg.Context) {
+	dc.Push()
+	defer dc.Pop()
+
+	// draw maze walls
+	dc.SetColor(color.RGBA{B: 102, A: 255})
+	dc.SetLineWidth(3.0)
+	dc.SetLineCap(gg.LineCapRound)
+	for _, l := range env.Lines {
+		dc.DrawLine(l.A.X, l.A.Y, l.B.X, l.B.Y)
+		dc.Stroke()
+	}
+
+	// draw obstacles
+	dc.SetColor(color.RGBA{R: 153, G: 102, B: 51, A: 255})
+	for i := range env.Obstacles {
+		o := &env.Obstacles[i]
+		if o.Shape == DiscObstacle {
+			c := o.CenterAt(env.TimeStep)
+			dc.DrawCircle(c.X, c.Y, o.Radius)
+			dc.Fill()
+		} else {
+			l := o.SegmentAt(env.TimeStep)
+			dc.DrawLine(l.A.X, l.A.Y, l.B.X, l.B.Y)
+			dc.Stroke()
+		}
+	}
+
+	// draw waypoints, the visited ones are grayed out
+	dc.SetLineWidth(1.0)
+	for i, w := range env.Waypoints {
+		dc.DrawCircle(w.X, w.Y, 4.0)
+		if env.IsWaypointVisited(i) {
+			dc.SetColor(color.Gray{Y: 200})
+		} else {
+			dc.SetColor(color.RGBA{R: 255, G: 204, A: 255})
+		}
+		dc.Fill()
+	}
+
+	// draw maze exit
+	dc.DrawCircle(env.MazeExit.X, env.MazeExit.Y, 4.0)
+	dc.SetColor(color.RGBA{R: 255, G: 51, A: 255})
+	dc.Fill()
+}
+
+// drawAgent is to draw the agent's body with its heading
+func drawAgent(hero *Agent, dc *gg.Context) {
+	dc.Push()
+	defer dc.Pop()
+
+	dc.SetLineWidth(1.0)
+	dc.DrawCircle(hero.Location.X, hero.Location.Y, hero.Radius)
+	dc.SetColor(color.RGBA{R: 153, G: 255, B: 151, A: 255})
+	dc.FillPreserve()
+	dc.SetColor(color.RGBA{G: 102, A: 255})
+	dc.Stroke()
+	rad := hero.Heading / 180.0 * math.Pi
+	dc.DrawLine(hero.Location.X, hero.Location.Y,
+		hero.Location.X+math.Cos(rad)*hero.Radius, hero.Location.Y+math.Sin(rad)*hero.Radius)
+	dc.Stroke()
+}
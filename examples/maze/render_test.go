@@ -0,0 +1,35 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestRenderEnvironment(t *testing.T) {
+	env := &Environment{
+		Hero:     Agent{Location: Point{X: 20, Y: 20}, Radius: 8},
+		MazeExit: Point{X: 80, Y: 80},
+		Lines:    createBoxLines(100, 50),
+	}
+	img, err := RenderEnvironment(env, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 240, img.Bounds().Dx())
+	assert.Equal(t, 140, img.Bounds().Dy())
+
+	// the agent is drawn at its location
+	r, g, b, _ := img.At(2*(20+renderMargin), 2*(20+renderMargin)).RGBA()
+	assert.NotEqual(t, color.White, color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 255})
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, WriteEnvironmentPNG(buf, env, 1))
+	decoded, err := png.Decode(buf)
+	require.NoError(t, err)
+	assert.Equal(t, 120, decoded.Bounds().Dx())
+
+	_, err = RenderEnvironment(&Environment{}, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,343 @@
+package maze
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/experiment/utils"
+	"github.com/yaricom/goNEAT/v4/neat"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// RolloutSelector is to select the agents evaluated by the maze experiments which rollouts should be recorded. The
+// selector is called with the record of the agent after its evaluation. The rollouts are stored into the "rollouts"
+// directory of the trial output. The rollouts are not recorded if selector is not set.
+var RolloutSelector func(record *AgentRecord) bool
+
+// SolversRolloutSelector selects the agents that found the maze exit
+func SolversRolloutSelector(record *AgentRecord) bool {
+	return record.GotExit
+}
+
+// AgentsRolloutSelector creates the selector of the agents with given IDs
+func AgentsRolloutSelector(ids ...int) func(record *AgentRecord) bool {
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	return func(record *AgentRecord) bool {
+		return selected[record.AgentID]
+	}
+}
+
+// RolloutStep is the state of the agent after one time step of the maze simulation
+type RolloutStep struct {
+	// The simulation time step
+	TimeStep int
+	// The location of the agent
+	Location Point
+	// The heading direction of the agent in degrees
+	Heading float64
+	// The speed of the agent
+	Speed float64
+	// The angular velocity of the agent
+	AngularVelocity float64
+	// The readings of the range finder sensors
+	RangeFinders []float64
+	// The readings of the radar sensors
+	Radar []float64
+	// The readings of the radar sensors pointing to the waypoints, one channel per waypoint
+	WaypointRadar [][]float64
+	// The outputs of the network applied at this step
+	Outputs []float64
+	// The flag to indicate whether agent was in contact with maze walls during this step
+	Collided bool
+}
+
+// Rollout is the per-step record of the agent's simulation within the maze. It holds the genome of the agent and the
+// maze definition to replay the simulation.
+type Rollout struct {
+	// The ID of the agent
+	AgentID int
+	// The ID of the agent's genome
+	GenomeID int
+	// The population generation when agent was evaluated
+	Generation int
+	// The genome of the agent encoded in the plain text format
+	Genome []byte
+	// The definition of the maze where agent was simulated
+	Maze *MazeDefinition
+	// The recorded steps of the simulation
+	Steps []RolloutStep
+	// The flag to indicate whether agent reached maze exit
+	ExitFound bool
+	// The agent fitness at the end of simulation
+	Fitness float64
+}
+
+// RecordRollout runs simulation of the organism within the maze environment and records the state of the agent at each
+// time step. The simulation is the same as the one used by the maze experiments to evaluate the organism.
+func RecordRollout(env *Environment, org *genetics.Organism) (*Rollout, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := org.Genotype.Write(buf); err != nil {
+		return nil, err
+	}
+	def, err := NewMazeDefinition(env)
+	if err != nil {
+		return nil, err
+	}
+	rollout := &Rollout{
+		GenomeID:   org.Genotype.Id,
+		Generation: org.Generation,
+		Genome:     buf.Bytes(),
+		Maze:       def,
+	}
+	if err = rollout.simulate(env, org); err != nil {
+		return nil, err
+	}
+	return rollout, nil
+}
+
+// ReplayRollout re-runs simulation of the genome stored in the rollout within the stored maze and returns the new
+// rollout
+func ReplayRollout(r *Rollout) (*Rollout, error) {
+	reader, err := genetics.NewGenomeReader(bytes.NewReader(r.Genome), genetics.PlainGenomeEncoding)
+	if err != nil {
+		return nil, err
+	}
+	genome, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	org, err := genetics.NewOrganism(0, genome, r.Generation)
+	if err != nil {
+		return nil, err
+	}
+	env, err := r.Maze.Environment()
+	if err != nil {
+		return nil, err
+	}
+	replay := &Rollout{
+		AgentID:    r.AgentID,
+		GenomeID:   r.GenomeID,
+		Generation: r.Generation,
+		Genome:     r.Genome,
+		Maze:       r.Maze,
+	}
+	if err = replay.simulate(env, org); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// VerifyRollout replays the rollout and checks that the replayed trajectory matches the recorded one bit for bit
+func VerifyRollout(r *Rollout) error {
+	replay, err := ReplayRollout(r)
+	if err != nil {
+		return err
+	}
+	return r.Compare(replay)
+}
+
+// Compare is to check that the trajectory of other rollout matches this one bit for bit. Returns error describing the
+// first mismatch found.
+func (r *Rollout) Compare(other *Rollout) error {
+	if r.ExitFound != other.ExitFound {
+		return fmt.Errorf("exit found mismatch: %t != %t", r.ExitFound, other.ExitFound)
+	}
+	if !sameBits(r.Fitness, other.Fitness) {
+		return fmt.Errorf("fitness mismatch: %v != %v", r.Fitness, other.Fitness)
+	}
+	for i := 0; i < len(r.Steps) && i < len(other.Steps); i++ {
+		if field := r.Steps[i].mismatch(&other.Steps[i]); len(field) > 0 {
+			return fmt.Errorf("trajectory diverges at step: %d, time step: %d, field: %s", i, r.Steps[i].TimeStep, field)
+		}
+	}
+	if len(r.Steps) != len(other.Steps) {
+		return fmt.Errorf("number of steps mismatch: %d != %d", len(r.Steps), len(other.Steps))
+	}
+	return nil
+}
+
+// Write is to write rollout into the writer in the compressed binary format
+func (r *Rollout) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(r); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Read is to read rollout from the reader in the compressed binary format
+func (r *Rollout) Read(rd io.Reader) error {
+	zr, err := gzip.NewReader(rd)
+	if err != nil {
+		return err
+	}
+	if err = gob.NewDecoder(zr).Decode(r); err != nil {
+		return err
+	}
+	return zr.Close()
+}
+
+// ReadRolloutFromFile reads rollout from the file
+func ReadRolloutFromFile(path string) (*Rollout, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	r := &Rollout{}
+	if err = r.Read(file); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// simulate is to run simulation of the organism within the maze environment and to record its steps
+func (r *Rollout) simulate(env *Environment, org *genetics.Organism) error {
+	phenotype, err := org.Phenotype()
+	if err != nil {
+		return err
+	}
+	// the same depth estimation as used by maze experiments
+	netDepth, err := phenotype.MaxActivationDepthWithCap(1)
+	if err != nil {
+		neat.DebugLog(fmt.Sprintf(
+			"Failed to estimate maximal depth of the network. Using default depth: %d", netDepth))
+	}
+	orgEnv, err := mazeSimulationInit(*env, phenotype, netDepth)
+	if err != nil {
+		return err
+	}
+	r.Steps = make([]RolloutStep, 0, orgEnv.TimeSteps)
+	for i := 0; i < orgEnv.TimeSteps && !orgEnv.ExitFound; i++ {
+		if err = mazeSimulationStep(orgEnv, phenotype, netDepth); errors.Is(err, ErrOutputIsNaN) {
+			// the simulation of corrupted network is over
+			break
+		} else if err != nil {
+			return err
+		}
+		r.Steps = append(r.Steps, RolloutStep{
+			TimeStep:        orgEnv.TimeStep,
+			Location:        orgEnv.Hero.Location,
+			Heading:         orgEnv.Hero.Heading,
+			Speed:           orgEnv.Hero.Speed,
+			AngularVelocity: orgEnv.Hero.AngularVelocity,
+			RangeFinders:    append([]float64{}, orgEnv.Hero.RangeFinders...),
+			Radar:           append([]float64{}, orgEnv.Hero.Radar...),
+			WaypointRadar:   copyWaypointRadar(orgEnv.Hero.WaypointRadar),
+			Outputs:         []float64{phenotype.Outputs[0].Activation, phenotype.Outputs[1].Activation},
+			Collided:        orgEnv.Hero.Collided,
+		})
+	}
+	r.ExitFound = orgEnv.ExitFound
+	// the same fitness as evaluated by maze experiments
+	if r.Fitness = orgEnv.normalizedFitness(); r.Fitness <= 0 {
+		r.Fitness = 0.01
+	}
+	return nil
+}
+
+// mismatch returns the name of the first field which differs from other step bit for bit, or empty string if steps
+// are the same
+func (s *RolloutStep) mismatch(other *RolloutStep) string {
+	switch {
+	case s.TimeStep != other.TimeStep:
+		return "TimeStep"
+	case !sameBits(s.Location.X, other.Location.X) || !sameBits(s.Location.Y, other.Location.Y):
+		return "Location"
+	case !sameBits(s.Heading, other.Heading):
+		return "Heading"
+	case !sameBits(s.Speed, other.Speed):
+		return "Speed"
+	case !sameBits(s.AngularVelocity, other.AngularVelocity):
+		return "AngularVelocity"
+	case !sameBitsSlice(s.RangeFinders, other.RangeFinders):
+		return "RangeFinders"
+	case !sameBitsSlice(s.Radar, other.Radar):
+		return "Radar"
+	case !sameBitsChannels(s.WaypointRadar, other.WaypointRadar):
+		return "WaypointRadar"
+	case !sameBitsSlice(s.Outputs, other.Outputs):
+		return "Outputs"
+	case s.Collided != other.Collided:
+		return "Collided"
+	}
+	return ""
+}
+
+// storeRollout is to record and to store the rollout of the organism evaluated by the maze experiment if it's
+// selected by RolloutSelector
+func storeRollout(outputPath string, env *Environment, org *genetics.Organism, record *AgentRecord) {
+	if RolloutSelector == nil || !RolloutSelector(record) {
+		return
+	}
+	rollout, err := RecordRollout(env, org)
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to record rollout of agent: %d, reason: %s\n", record.AgentID, err))
+		return
+	}
+	rollout.AgentID = record.AgentID
+	rollout.Generation = record.Generation
+
+	dir := filepath.Join(utils.CreateOutDirForTrial(outputPath, trialSim.trialID), "rollouts")
+	if err = os.MkdirAll(dir, os.ModePerm); err == nil {
+		var file *os.File
+		if file, err = os.Create(filepath.Join(dir, fmt.Sprintf("rollout_%d.dat", record.AgentID))); err == nil {
+			err = rollout.Write(file)
+			_ = file.Close()
+		}
+	}
+	if err != nil {
+		neat.ErrorLog(fmt.Sprintf("Failed to store rollout of agent: %d, reason: %s\n", record.AgentID, err))
+	}
+}
+
+func sameBits(a, b float64) bool {
+	return math.Float64bits(a) == math.Float64bits(b)
+}
+
+func sameBitsSlice(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameBits(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameBitsChannels(a, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameBitsSlice(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// copyWaypointRadar is to make the deep copy of the waypoint radar readings
+func copyWaypointRadar(radar [][]float64) [][]float64 {
+	if radar == nil {
+		return nil
+	}
+	channels := make([][]float64, len(radar))
+	for i, channel := range radar {
+		channels[i] = append([]float64{}, channel...)
+	}
+	return channels
+}
@@ -0,0 +1,159 @@
+package maze
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordRollout(t *testing.T) {
+	env := createRolloutTestEnvironment(t)
+	org := createRolloutTestOrganism(t)
+
+	rollout, err := RecordRollout(env, org)
+	require.NoError(t, err)
+	require.Len(t, rollout.Steps, env.TimeSteps)
+	assert.Equal(t, org.Genotype.Id, rollout.GenomeID)
+	assert.Equal(t, "medium_maze", rollout.Maze.Name)
+
+	// the rollout is the same simulation as used by maze experiments
+	record := AgentRecord{}
+	_, _, err = mazeSimulationEvaluate(env, org, &record, nil)
+	require.NoError(t, err)
+	last := rollout.Steps[len(rollout.Steps)-1]
+	assert.Equal(t, Point{X: record.X, Y: record.Y}, last.Location)
+	assert.Equal(t, record.Fitness, rollout.Fitness)
+	assert.Equal(t, env.TimeSteps+1, last.TimeStep)
+	assert.Len(t, last.RangeFinders, len(env.Hero.RangeFinderAngles))
+	assert.Len(t, last.Radar, len(env.Hero.RadarAngles1))
+	assert.Len(t, last.Outputs, 2)
+}
+
+func TestRollout_WriteRead(t *testing.T) {
+	rollout, err := RecordRollout(createRolloutTestEnvironment(t), createRolloutTestOrganism(t))
+	require.NoError(t, err)
+	rollout.AgentID = 42
+
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, rollout.Write(buf))
+	read := &Rollout{}
+	require.NoError(t, read.Read(buf))
+	assert.Equal(t, rollout, read)
+
+	// the replayed trajectory matches bit for bit
+	assert.NoError(t, VerifyRollout(read))
+}
+
+func TestRollout_Compare(t *testing.T) {
+	rollout, err := RecordRollout(createRolloutTestEnvironment(t), createRolloutTestOrganism(t))
+	require.NoError(t, err)
+	replay, err := ReplayRollout(rollout)
+	require.NoError(t, err)
+	require.NoError(t, rollout.Compare(replay))
+
+	// the least significant bit differs
+	replay.Steps[10].Heading = math.Nextafter(replay.Steps[10].Heading, math.Inf(1))
+	assert.EqualError(t, rollout.Compare(replay), "trajectory diverges at step: 10, time step: 12, field: Heading")
+
+	replay.Steps = replay.Steps[:10]
+	assert.EqualError(t, rollout.Compare(replay), "number of steps mismatch: 50 != 10")
+
+	replay.Fitness = math.Nextafter(rollout.Fitness, 0)
+	assert.EqualError(t, rollout.Compare(replay), fmt.Sprintf("fitness mismatch: %v != %v", rollout.Fitness, replay.Fitness))
+}
+
+func TestRecordRollout_geodesic(t *testing.T) {
+	env := createRolloutTestEnvironment(t)
+	require.NoError(t, env.SetFitnessMetric(GeodesicDistance, 2))
+	rollout, err := RecordRollout(env, createRolloutTestOrganism(t))
+	require.NoError(t, err)
+
+	// the replay uses the same fitness metric
+	replay, err := ReplayRollout(rollout)
+	require.NoError(t, err)
+	assert.Equal(t, rollout.Fitness, replay.Fitness)
+	assert.NoError(t, rollout.Compare(replay))
+}
+
+func TestRecordRollout_waypoints(t *testing.T) {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze_waypoints.yml")
+	require.NoError(t, err)
+	env.TimeSteps = 50
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazewaypointsgenes")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+	org, err := genetics.NewOrganism(0, genome, 1)
+	require.NoError(t, err)
+
+	rollout, err := RecordRollout(env, org)
+	require.NoError(t, err)
+	require.NotEmpty(t, rollout.Steps)
+	assert.Len(t, rollout.Steps[0].WaypointRadar, len(env.Waypoints))
+	assert.NoError(t, VerifyRollout(rollout))
+
+	// the waypoint radar readings are compared bit for bit
+	replay, err := ReplayRollout(rollout)
+	require.NoError(t, err)
+	channel := replay.Steps[5].WaypointRadar[0]
+	channel[0] = math.Nextafter(channel[0], math.Inf(1))
+	assert.EqualError(t, rollout.Compare(replay), fmt.Sprintf(
+		"trajectory diverges at step: 5, time step: %d, field: WaypointRadar", rollout.Steps[5].TimeStep))
+}
+
+func TestStoreRollout(t *testing.T) {
+	defer func() {
+		RolloutSelector = nil
+	}()
+	outDir := t.TempDir()
+	trialSim = mazeSimResults{trialID: 1}
+	env, org := createRolloutTestEnvironment(t), createRolloutTestOrganism(t)
+
+	// not recorded without selector
+	storeRollout(outDir, env, org, &AgentRecord{AgentID: 1})
+	assert.NoDirExists(t, filepath.Join(outDir, "1", "rollouts"))
+
+	RolloutSelector = AgentsRolloutSelector(2, 3)
+	for id := 1; id <= 3; id++ {
+		storeRollout(outDir, env, org, &AgentRecord{AgentID: id, Generation: 7})
+	}
+	assert.NoFileExists(t, filepath.Join(outDir, "1", "rollouts", "rollout_1.dat"))
+	rollout, err := ReadRolloutFromFile(filepath.Join(outDir, "1", "rollouts", "rollout_2.dat"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, rollout.AgentID)
+	assert.Equal(t, 7, rollout.Generation)
+	assert.FileExists(t, filepath.Join(outDir, "1", "rollouts", "rollout_3.dat"))
+
+	assert.True(t, SolversRolloutSelector(&AgentRecord{GotExit: true}))
+	assert.False(t, SolversRolloutSelector(&AgentRecord{}))
+}
+
+func createRolloutTestEnvironment(t *testing.T) *Environment {
+	env, err := ReadEnvironmentFromFile("../../data/medium_maze.txt")
+	require.NoError(t, err)
+	env.TimeSteps = 50
+	env.SampleSize = 10
+	env.ExitFoundRange = 5
+	return env
+}
+
+// createRolloutTestOrganism creates organism with seed genome of maze solver and random connection weights
+func createRolloutTestOrganism(t *testing.T) *genetics.Organism {
+	reader, err := genetics.NewGenomeReaderFromFile("../../data/mazestartgenes.yml")
+	require.NoError(t, err)
+	genome, err := reader.Read()
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(42))
+	for _, gene := range genome.Genes {
+		gene.Link.ConnectionWeight = rng.NormFloat64()
+	}
+	org, err := genetics.NewOrganism(0, genome, 1)
+	require.NoError(t, err)
+	return org
+}
@@ -12,6 +12,8 @@ import (
 	"math/rand"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -23,8 +25,8 @@ func main() {
 	var genomePath = flag.String("genome", "./data/mazestartgenes", "The seed genome to start with.")
 	var safeGenomePath = flag.String("safe_genome", "./data/safeobjfuncstartgenes.yml", "The obj functions seed genome to start with.")
 	var safeContextPath = flag.String("safe_context", "./data/safe.yml", "The SAFE execution context configuration file.")
-	var mazeConfigPath = flag.String("maze", "./data/medium_maze.txt", "The maze environment configuration file.")
-	var experimentName = flag.String("experiment", "MAZENS", "The name of experiment to run. [MAZENS, MAZEOBJ, MAZESAFE]")
+	var mazeConfigPath = flag.String("maze", "./data/medium_maze.txt", "The maze environment configuration file. The format is detected by extension: .yml/.yaml, .json or legacy text.")
+	var experimentName = flag.String("experiment", "MAZENS", "The name of experiment to run. [MAZENS, MAZEOBJ, MAZESAFE, MAZEMULTI, MAZEPOET]")
 	var timeSteps = flag.Int("timesteps", 400, "The number of time steps for maze simulation per organism.")
 	var timeStepsSample = flag.Int("timesteps_sample", 1000, "The sample size to store agent path when doing maze simulation.")
 	var speciesTarget = flag.Int("species_target", 20, "The target number of species to maintain.")
@@ -32,10 +34,31 @@ func main() {
 	var trialsCount = flag.Int("trials", 0, "The number of trials for experiment. Overrides the one set in configuration.")
 	var logLevel = flag.String("log_level", "", "The logger level to be used. Overrides the one set in configuration.")
 	var exitRange = flag.Float64("exit_range", 5.0, "The range around maze exit point to test if agent coordinates is within to be considered as solved successfully")
+	var collisionMode = flag.String("collision", "STOP", "The agent's collision response mode [STOP, SLIDE, BOUNCE].")
+	var motionModel = flag.String("motion", "ACCEL", "The agent's motion model [ACCEL, DIFF_DRIVE, DIRECT].")
+	var maxSpeed = flag.Float64("max_speed", 3.0, "The maximal absolute speed of the agent.")
+	var maxAngularVelocity = flag.Float64("max_angular_velocity", 3.0, "The maximal absolute angular velocity of the agent in degrees per time step.")
+	var wheelBase = flag.Float64("wheel_base", 16.0, "The distance between agent's wheels for differential-drive motion model.")
+	var fitnessMetric = flag.String("fitness", "EUCLIDEAN", "The metric of agent's distance to exit for fitness calculation in MAZEOBJ experiment [EUCLIDEAN, GEODESIC].")
+	var geodesicCellSize = flag.Float64("geodesic_cell", 2.0, "The cell size of the maze grid to calculate GEODESIC distances.")
+	var teamSize = flag.Int("agents", 3, "The number of agents in the team navigating the maze simultaneously in MAZEMULTI experiment.")
+	var curriculumPaths = flag.String("curriculum", "", "The comma separated list of maze config files to be solved in order before the maze set by -maze.")
+	var curriculumCriterion = flag.String("curriculum_criterion", "SOLVER", "The criterion to move the population on to the next maze of the curriculum [SOLVER, PERCENTILE].")
+	var curriculumPercentile = flag.Float64("curriculum_percentile", 0.9, "The percentile of the population fitness to be tested with PERCENTILE curriculum criterion.")
+	var curriculumFitness = flag.Float64("curriculum_fitness", 0.8, "The fitness to be reached by the percentile of the population fitness with PERCENTILE curriculum criterion.")
+	var curriculumCarryArchive = flag.Bool("curriculum_carry_archive", false, "The flag to indicate whether the novelty archive should be carried over to the next maze of the curriculum.")
+	var poetMaxMazes = flag.Int("poet_max_mazes", 8, "The maximal number of active mazes coevolving with solvers in MAZEPOET experiment.")
+	var poetReproductionFreq = flag.Int("poet_reproduction_freq", 10, "The frequency of maze reproduction in generations in MAZEPOET experiment.")
+	var poetTargetPath = flag.String("poet_target", "", "The target maze config file to be solved in MAZEPOET experiment. The trial runs for all generations if not set.")
+	var rollouts = flag.String("rollouts", "", "The agents which per-step rollouts should be recorded: SOLVERS or the comma separated list of agent IDs. Not recorded if not set.")
+	var streamRecords = flag.Bool("stream_records", false, "The flag to indicate whether agents records should be appended to the record stream file after each generation instead of being kept in memory until the end of trial.")
 	var seed = flag.Int64("seed", -1, "The seed for the random number generator [-1 to use current Unix timestamp].")
 
 	flag.Parse()
 
+	// collect flags explicitly set in the command line to override values from the maze configuration file
+	explicitFlags := maze.ExplicitFlags(flag.CommandLine)
+
 	// Seed the random-number generator with current time so that
 	// the numbers will be different every time we run.
 	if *seed < 0 {
@@ -61,23 +84,101 @@ func main() {
 	}
 	fmt.Println(startGenome)
 
+	collision, err := maze.CollisionModeFromString(*collisionMode)
+	if err != nil {
+		log.Fatal("Failed to parse collision mode: ", err)
+	}
+	motion, err := maze.NewMotionModel(*motionModel, *maxSpeed, *maxAngularVelocity, *wheelBase)
+	if err != nil {
+		log.Fatal("Failed to create motion model: ", err)
+	}
+
+	metric, err := maze.DistanceMetricFromString(*fitnessMetric)
+	if err != nil {
+		log.Fatal("Failed to parse fitness distance metric: ", err)
+	}
+
+	// Select agents which rollouts should be recorded
+	if len(*rollouts) > 0 && *experimentName == "MAZEMULTI" {
+		log.Fatalf("The rollouts recording is not supported by %s experiment\n", *experimentName)
+	}
+	if *rollouts == "SOLVERS" {
+		maze.RolloutSelector = maze.SolversRolloutSelector
+	} else if len(*rollouts) > 0 {
+		ids := make([]int, 0)
+		for _, str := range strings.Split(*rollouts, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(str))
+			if err != nil {
+				log.Fatal("Failed to parse agent ID of rollouts to be recorded: ", err)
+			}
+			ids = append(ids, id)
+		}
+		maze.RolloutSelector = maze.AgentsRolloutSelector(ids...)
+	}
+
+	maze.StreamRecords = *streamRecords
+
 	// Load maze environment
-	log.Printf("Reading maze environment: %s\n", *mazeConfigPath)
-	mazeFile, err := os.Open(*mazeConfigPath)
-	var environment *maze.Environment
-	if err == nil {
-		environment, err = maze.ReadEnvironment(mazeFile)
-		if environment != nil {
-			environment.TimeSteps = *timeSteps
-			environment.SampleSize = *timeStepsSample
-			environment.ExitFoundRange = *exitRange
-		}
-		log.Println(environment)
+	loadEnvironment := func(path string) (*maze.Environment, error) {
+		log.Printf("Reading maze environment: %s\n", path)
+		env, err := maze.ReadEnvironmentFromFile(path)
+		if err != nil {
+			return nil, err
+		}
+		// the values from the maze configuration file are used unless explicitly set in the command line
+		if env.TimeSteps == 0 || explicitFlags["timesteps"] {
+			env.TimeSteps = *timeSteps
+		}
+		if env.SampleSize == 0 || explicitFlags["timesteps_sample"] {
+			env.SampleSize = *timeStepsSample
+		}
+		if env.ExitFoundRange == 0 || explicitFlags["exit_range"] {
+			env.ExitFoundRange = *exitRange
+		}
+		if explicitFlags["collision"] {
+			env.Collision = collision
+		}
+		if env.Motion == nil || explicitFlags["motion"] || explicitFlags["max_speed"] ||
+			explicitFlags["max_angular_velocity"] || explicitFlags["wheel_base"] {
+			env.Motion = motion
+		}
+		if env.FitnessMetric == maze.EuclideanDistance || explicitFlags["fitness"] || explicitFlags["geodesic_cell"] {
+			if err = env.SetFitnessMetric(metric, *geodesicCellSize); err != nil {
+				return nil, err
+			}
+		}
+		if env.FitnessMetric != maze.EuclideanDistance && *experimentName != "MAZEOBJ" {
+			return nil, fmt.Errorf("the %s fitness distance metric is supported only by MAZEOBJ experiment", env.FitnessMetric)
+		}
+		log.Println(env)
+		return env, nil
 	}
+	environment, err := loadEnvironment(*mazeConfigPath)
 	if err != nil {
 		log.Fatal("Failed to read maze environment configuration: ", err)
 	}
 
+	// Load curriculum mazes to be solved before the target maze
+	var curriculum []*maze.Environment
+	if len(*curriculumPaths) > 0 {
+		for _, path := range strings.Split(*curriculumPaths, ",") {
+			env, err := loadEnvironment(strings.TrimSpace(path))
+			if err != nil {
+				log.Fatal("Failed to read curriculum maze environment configuration: ", err)
+			}
+			curriculum = append(curriculum, env)
+		}
+		curriculum = append(curriculum, environment)
+	}
+
+	// Load the target maze of coevolution
+	var poetTarget *maze.Environment
+	if len(*poetTargetPath) > 0 {
+		if poetTarget, err = loadEnvironment(*poetTargetPath); err != nil {
+			log.Fatal("Failed to read target maze environment configuration: ", err)
+		}
+	}
+
 	// Check if output dir exists
 	outDir := *outDirPath
 	if _, err = os.Stat(outDir); err == nil {
@@ -111,19 +212,49 @@ func main() {
 		Trials:   make(experiment.Trials, neatOptions.NumRuns),
 		RandSeed: *seed,
 	}
+	createEvaluator := func(environment *maze.Environment) (experiment.GenerationEvaluator, experiment.TrialRunObserver) {
+		switch *experimentName {
+		case "MAZENS":
+			return maze.NewNoveltySearchEvaluator(outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
+		case "MAZEOBJ":
+			return maze.NewMazeObjectiveEvaluator(outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
+		case "MAZESAFE":
+			return createSafeEvaluator(
+				*safeGenomePath, *safeContextPath, outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
+		case "MAZEMULTI":
+			return maze.NewMultiAgentEvaluator(
+				outDir, environment, *teamSize, *speciesTarget, *speciesCompatAdjustFreq)
+		case "MAZEPOET":
+			opts := maze.DefaultPoetOptions()
+			opts.MaxActiveMazes = *poetMaxMazes
+			opts.ReproductionFreq = *poetReproductionFreq
+			opts.Seed = *seed
+			opts.Target = poetTarget
+			return maze.NewMazePoetEvaluator(outDir, environment, opts, *speciesTarget, *speciesCompatAdjustFreq)
+		default:
+			log.Fatalf("Unsupported experiment name requested: %s\n", *experimentName)
+			return nil, nil
+		}
+	}
 	var generationEvaluator experiment.GenerationEvaluator
 	var trialObserver experiment.TrialRunObserver
-	if *experimentName == "MAZENS" {
-		generationEvaluator, trialObserver = maze.NewNoveltySearchEvaluator(
-			outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
-	} else if *experimentName == "MAZEOBJ" {
-		generationEvaluator, trialObserver = maze.NewMazeObjectiveEvaluator(
-			outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
-	} else if *experimentName == "MAZESAFE" {
-		generationEvaluator, trialObserver = createSafeEvaluator(
-			*safeGenomePath, *safeContextPath, outDir, environment, *speciesTarget, *speciesCompatAdjustFreq)
+	if len(curriculum) > 0 {
+		criterion, err := maze.CurriculumCriterionFromString(*curriculumCriterion)
+		if err != nil {
+			log.Fatal("Failed to parse curriculum criterion: ", err)
+		}
+		opts := maze.CurriculumOptions{
+			Criterion:        criterion,
+			Percentile:       *curriculumPercentile,
+			FitnessThreshold: *curriculumFitness,
+			CarryOverArchive: *curriculumCarryArchive,
+		}
+		generationEvaluator, trialObserver, err = maze.NewCurriculumEvaluator(curriculum, opts, createEvaluator)
+		if err != nil {
+			log.Fatal("Failed to create curriculum evaluator: ", err)
+		}
 	} else {
-		log.Fatalf("Unsupported experiment name requested: %s\n", *experimentName)
+		generationEvaluator, trialObserver = createEvaluator(environment)
 	}
 
 	// prepare to execute
@@ -5,18 +5,18 @@ go 1.21
 require (
 	github.com/fogleman/gg v1.3.0
 	github.com/pkg/errors v0.9.1
+	github.com/sbinet/npyio v0.8.0
 	github.com/stretchr/testify v1.10.0
 	github.com/yaricom/goNEAT/v4 v4.2.1
+	golang.org/x/image v0.14.0
+	gonum.org/v1/gonum v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/sbinet/npyio v0.8.0 // indirect
 	github.com/spf13/cast v1.5.1 // indirect
 	golang.org/x/exp v0.0.0-20231110203233-9a3e6036ecaa // indirect
-	golang.org/x/image v0.14.0 // indirect
-	gonum.org/v1/gonum v0.14.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
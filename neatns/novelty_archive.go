@@ -130,6 +130,16 @@ func (a *NoveltyArchive) EndOfGeneration() {
 	a.adjustArchiveSettings()
 }
 
+// Reset is to remove all novel and fittest items from the archive, e.g., when the task changes and collected behaviors
+// become irrelevant. The current novelty threshold and archive options are kept.
+func (a *NoveltyArchive) Reset() {
+	a.NovelItems = make([]*NoveltyItem, 0)
+	a.FittestItems = make([]*NoveltyItem, 0)
+	a.itemsAddedInGeneration = 0
+	a.generationIndex = a.options.ArchiveSeedAmount
+	a.timeOut = 0
+}
+
 // addNoveltyItem adds novelty item to archive
 func (a *NoveltyArchive) addNoveltyItem(i *NoveltyItem) {
 	i.added = true
@@ -27,6 +27,15 @@ func (a *NoveltyArchive) DumpFittest(w io.Writer) error {
 	return printNovelItems(a.FittestItems, w)
 }
 
+// ReadNoveltyItems reads novelty items dumped as JSON by DumpNoveltyPoints or DumpFittest
+func ReadNoveltyItems(r io.Reader) ([]*NoveltyItem, error) {
+	items := make([]*NoveltyItem, 0)
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 func printNovelItems(items []*NoveltyItem, w io.Writer) error {
 	if data, err := json.Marshal(items); err != nil {
 		return err
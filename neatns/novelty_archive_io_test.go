@@ -69,6 +69,24 @@ func TestNoveltyArchive_PrintNoveltyPoints_no_points(t *testing.T) {
 	assert.Error(t, err, ErrNoNovelItems.Error())
 }
 
+func TestReadNoveltyItems(t *testing.T) {
+	pop, err := createRandomPopulation(3, 2, 5, 0.5)
+	require.NoError(t, err, "failed to create population")
+
+	archive := NewNoveltyArchive(0.1, squareMetric, DefaultNoveltyArchiveOptions())
+	archive.EvaluatePopulationNovelty(pop, false)
+	var buf bytes.Buffer
+	err = archive.DumpNoveltyPoints(&buf)
+	require.NoError(t, err)
+
+	items, err := ReadNoveltyItems(&buf)
+	require.NoError(t, err)
+	assertItemsEqual(archive.NovelItems, items, t)
+
+	_, err = ReadNoveltyItems(bytes.NewBufferString("garbage"))
+	assert.Error(t, err)
+}
+
 func assertItemsEqual(expected, actual []*NoveltyItem, t *testing.T) {
 	require.Equal(t, len(expected), len(actual))
 	for i, ni := range expected {
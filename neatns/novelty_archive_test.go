@@ -81,6 +81,28 @@ func TestNoveltyArchive_addNoveltyItem(t *testing.T) {
 	assert.Equal(t, 1, archive.itemsAddedInGeneration, "wrong novelty items number for generation")
 }
 
+func TestNoveltyArchive_Reset(t *testing.T) {
+	opts := DefaultNoveltyArchiveOptions()
+	archive := NewNoveltyArchive(1.0, nil, opts)
+	gen, err := genetics.ReadGenome(strings.NewReader(genomeStr), 1)
+	require.NoError(t, err, "failed to read genome")
+	org, err := genetics.NewOrganism(0.1, gen, 1)
+	require.NoError(t, err, "failed to create new organism")
+	org = fillOrganismData(org, 0.0)
+
+	archive.addNoveltyItem(org.Data.Value.(*NoveltyItem))
+	err = archive.UpdateFittestWithOrganism(org)
+	require.NoError(t, err, "failed to update fittest")
+	archive.EndOfGeneration()
+
+	archive.Reset()
+	assert.Len(t, archive.NovelItems, 0)
+	assert.Len(t, archive.FittestItems, 0)
+	assert.Equal(t, 0, archive.itemsAddedInGeneration)
+	assert.Equal(t, opts.ArchiveSeedAmount, archive.generationIndex)
+	assert.Equal(t, 1, archive.Generation, "generation counter should be kept")
+}
+
 func TestNoveltyArchive_EvaluateIndividual(t *testing.T) {
 	rand.Seed(42)
 	pop, err := createRandomPopulation(3, 2, 5, 0.5)
@@ -99,6 +121,7 @@ func TestNoveltyArchive_EvaluateIndividual(t *testing.T) {
 	// check that data object properly filled
 	item := org.Data.Value.(*NoveltyItem)
 	assert.True(t, item.added)
+	assert.True(t, item.IsArchived())
 	assert.Equal(t, archive.Generation, item.Generation)
 
 	// test evaluate in population as well
@@ -30,6 +30,11 @@ func NewNoveltyItem() *NoveltyItem {
 	return &NoveltyItem{Data: make([]float64, 0)}
 }
 
+// IsArchived returns true if item was added to the novelty archive
+func (ni *NoveltyItem) IsArchived() bool {
+	return ni.added
+}
+
 // Stringer
 func (ni NoveltyItem) String() string {
 	str := fmt.Sprintf("Novelty: %.2f Fitness: %f Generation: %d Individual: %d\n",
@@ -2,10 +2,13 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/fogleman/gg"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
 	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"github.com/yaricom/goNEAT_NS/v4/neatns"
 	"image"
 	"image/color"
 	"io"
@@ -14,6 +17,7 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"strings"
 )
 
 // plotAgentsRecords draws maze agents records
@@ -27,18 +31,7 @@ func plotAgentsRecords(rs *maze.RecordStore, maze *maze.Environment, bestThresho
 
 func plotAgentsRecordsByAge(records *maze.RecordStore, dc *gg.Context) {
 	// find age range
-	maxAge, maxX, maxY := 0, 0.0, 0.0
-	for _, r := range records.Records {
-		if r.SpeciesAge > maxAge {
-			maxAge = r.SpeciesAge
-		}
-		if r.X > maxX {
-			maxX = r.X
-		}
-		if r.Y > maxY {
-			maxY = r.Y
-		}
-	}
+	maxAge := records.Query().Stats().MaxSpeciesAge
 	fmt.Printf("The oldest age: %d\n", maxAge)
 
 	// build color scale
@@ -60,39 +53,27 @@ func plotAgentsRecordsByAge(records *maze.RecordStore, dc *gg.Context) {
 }
 
 func plotAgentsRecordsBySpecies(records *maze.RecordStore, env *maze.Environment, bestThreshold float64, dc *gg.Context) {
-	maxID := 0
-	for _, r := range records.Records {
-		if r.SpeciesID > maxID {
-			maxID = r.SpeciesID
-		}
-	}
-
 	// find the best species threshold
 	distThreshold := env.AgentDistanceToExit() * (1.0 - bestThreshold)
 
-	// generate color palette and find the best species (moved at least 2/3 fom start to exit)
-	numSpecies, numBestSpecies := 0, 0
-	colors := make([]color.Color, maxID+1)
-	spIdx := make([]int, maxID+1)
-	bestSpIdx := make([]int, maxID+1)
+	// generate color palette in order of species appearance and find the best species (moved at least 2/3 fom start
+	// to exit)
+	species := records.Query().BySpecies()
+	colors := make(map[int]color.Color, len(species.Rows))
 	for _, rec := range records.Records {
-		if spIdx[rec.SpeciesID] == 0 {
-			spIdx[rec.SpeciesID] = 1
+		if _, ok := colors[rec.SpeciesID]; !ok {
 			r, g, b := uint8(rand.Float64()*255), uint8(rand.Float64()*255), uint8(rand.Float64()*255)
 			colors[rec.SpeciesID] = color.RGBA{R: r, G: g, B: b, A: 255}
-			numSpecies++
-		}
-		if env.MazeExit.Distance(maze.Point{X: rec.X, Y: rec.Y}) <= distThreshold {
-			bestSpIdx[rec.SpeciesID]++
 		}
 	}
+	bestSpecies := records.Query().Where(func(r *maze.AgentRecord) bool {
+		return env.MazeExit.Distance(maze.Point{X: r.X, Y: r.Y}) <= distThreshold
+	}).BySpecies()
+	numSpecies, numBestSpecies := len(species.Rows), len(bestSpecies.Rows)
 
 	// draw best species
-	for i, v := range bestSpIdx {
-		if v > 0 {
-			numBestSpecies++
-			plotSpecies(records, dc, i, colors)
-		}
+	for _, id := range bestSpecies.Keys() {
+		plotSpecies(records, dc, id, colors)
 	}
 	bounds := drawMaze(env, dc)
 	drawMazeCaption(bounds, numSpecies, numBestSpecies, bestThreshold, true, dc)
@@ -104,9 +85,9 @@ func plotAgentsRecordsBySpecies(records *maze.RecordStore, env *maze.Environment
 	// draw the worst species
 	dc.Push()
 	dc.Translate(0, float64(bounds.Max.Y+10))
-	for i, v := range bestSpIdx {
-		if v == 0 {
-			plotSpecies(records, dc, i, colors)
+	for _, id := range species.Keys() {
+		if bestSpecies.Find(id) == nil {
+			plotSpecies(records, dc, id, colors)
 		}
 	}
 	bounds = drawMaze(env, dc)
@@ -159,6 +140,9 @@ func drawMaze(maze *maze.Environment, dc *gg.Context) image.Rectangle {
 	}
 	dc.Pop()
 
+	// draw obstacles at their initial positions
+	drawObstacles(maze, 0, dc)
+
 	// draw start point
 	dc.Push()
 	dc.SetLineWidth(2.0)
@@ -179,63 +163,569 @@ func drawMaze(maze *maze.Environment, dc *gg.Context) image.Rectangle {
 	return image.Rect(int(minX), int(minY), int(maxX), int(maxY))
 }
 
-func plotSpecies(records *maze.RecordStore, dc *gg.Context, speciesID int, colors []color.Color) {
-	for _, r := range records.Records {
-		if r.SpeciesID == speciesID {
-			dc.DrawCircle(r.X, r.Y, 2.0)
-			dc.SetColor(colors[r.SpeciesID])
+// drawObstacles draws maze obstacles at given simulation time step
+func drawObstacles(env *maze.Environment, step int, dc *gg.Context) {
+	dc.Push()
+	dc.SetColor(color.RGBA{R: 153, G: 102, B: 51, A: 255})
+	dc.SetLineWidth(3.0)
+	dc.SetLineCap(gg.LineCapRound)
+	for i := range env.Obstacles {
+		o := &env.Obstacles[i]
+		if o.Shape == maze.DiscObstacle {
+			c := o.CenterAt(step)
+			dc.DrawCircle(c.X, c.Y, o.Radius)
 			dc.Fill()
+		} else {
+			l := o.SegmentAt(step)
+			dc.DrawLine(l.A.X, l.A.Y, l.B.X, l.B.Y)
+			dc.Stroke()
 		}
+	}
+	dc.Pop()
+}
 
+func plotSpecies(records *maze.RecordStore, dc *gg.Context, speciesID int, colors map[int]color.Color) {
+	for _, r := range records.Query().Species(speciesID).Records() {
+		dc.DrawCircle(r.X, r.Y, 2.0)
+		dc.SetColor(colors[r.SpeciesID])
+		dc.Fill()
 	}
 }
 
-func plotSolverPath(records *maze.RecordStore, dc *gg.Context, color color.Color) {
-	for _, p := range records.SolverPathPoints {
+func plotSolverPath(path []maze.Point, dc *gg.Context, color color.Color) {
+	for _, p := range path {
 		dc.DrawCircle(p.X, p.Y, 2.0)
 		dc.SetColor(color)
 		dc.Fill()
 	}
 }
 
-func drawMazeWithRecords(rec io.Reader, mr io.Reader, bestThreshold float64, byAge bool, dc *gg.Context) error {
-	env, err := maze.ReadEnvironment(mr)
+func drawMazeWithRecords(rec io.Reader, env *maze.Environment, bestThreshold float64, byAge bool, dc *gg.Context) error {
+	rs, err := maze.ReadRecordStore(rec)
 	if err != nil {
 		return err
 	}
-	rs := maze.RecordStore{}
-	err = rs.Read(rec)
+
+	plotAgentsRecords(rs, env, bestThreshold, byAge, dc)
+
+	return nil
+}
+
+// drawMazeWithPath draws the path of the solver agent with given ID, or paths of all solvers if agent ID is negative
+func drawMazeWithPath(rec io.Reader, env *maze.Environment, solverID int, dc *gg.Context) error {
+	rs, err := maze.ReadRecordStore(rec)
 	if err != nil {
 		return err
 	}
 
-	plotAgentsRecords(&rs, env, bestThreshold, byAge, dc)
+	if len(rs.Solvers) == 0 {
+		// the records stored before the solvers list was introduced hold only the path of the last solver
+		if solverID >= 0 {
+			return errors.New("the records hold no solvers list to select from")
+		}
+		plotSolverPath(rs.SolverPathPoints, dc, color.RGBA{B: 251, A: 255})
+		drawMaze(env, dc)
+		fmt.Printf("Path rendered for %d points\n", len(rs.SolverPathPoints))
+		return nil
+	}
+
+	solvers := rs.Solvers
+	if solverID >= 0 {
+		solver := rs.FindSolver(solverID)
+		if solver == nil {
+			return fmt.Errorf("solver agent not found: %d", solverID)
+		}
+		solvers = []maze.SolverRecord{*solver}
+	}
+
+	// draw the agents paths, each one with its own color
+	for _, s := range solvers {
+		var c color.Color = color.RGBA{B: 251, A: 255}
+		if len(solvers) > 1 {
+			c = color.RGBA{R: uint8(rand.Float64() * 255), G: uint8(rand.Float64() * 255), B: uint8(rand.Float64() * 255), A: 255}
+		}
+		plotSolverPath(s.Path, dc, c)
+		fmt.Printf("Solver agent: %d, genome: %d, generation: %d, species: %d, steps to exit: %d\n",
+			s.AgentID, s.GenomeID, s.Generation, s.SpeciesID, s.StepsToExit)
+	}
+
+	// draw maze
+	drawMaze(env, dc)
+
+	fmt.Printf("Paths rendered for %d of %d solvers\n", len(solvers), len(rs.Solvers))
 
 	return nil
 }
 
-func drawMazeWithPath(rec io.Reader, mr io.Reader, dc *gg.Context) error {
-	env, err := maze.ReadEnvironment(mr)
+// drawMazeWithArchive draws the final locations of agents from the novelty archive items colored by the value of the
+// selected item property [generation, novelty, fitness]. The sampled trajectories of agents are drawn if requested. The
+// fittest items are outlined if the path to their file provided.
+func drawMazeWithArchive(archivePath, fittestPath, colorBy string, trajectories bool, env *maze.Environment, dc *gg.Context) error {
+	value, err := archiveItemValue(colorBy)
 	if err != nil {
 		return err
 	}
-	rs := maze.RecordStore{}
-	err = rs.Read(rec)
+	items, err := readNoveltyItems(archivePath)
 	if err != nil {
 		return err
 	}
+	fittest := make([]*neatns.NoveltyItem, 0)
+	if len(fittestPath) > 0 {
+		if fittest, err = readNoveltyItems(fittestPath); err != nil {
+			return err
+		}
+	}
 
-	// draw the agents path
-	plotSolverPath(&rs, dc, color.RGBA{B: 251, A: 255})
+	// find values range and build color scale
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, item := range append(append([]*neatns.NoveltyItem{}, items...), fittest...) {
+		minValue = math.Min(minValue, value(item))
+		maxValue = math.Max(maxValue, value(item))
+	}
+	if maxValue <= minValue {
+		maxValue = minValue + 1
+	}
+	low, high := color.RGBA{R: 51, G: 153, B: 255, A: 255}, color.RGBA{R: 255, G: 51, A: 255}
+	colorAt := func(v float64) color.Color {
+		t := (v - minValue) / (maxValue - minValue)
+		return color.RGBA{
+			R: uint8(float64(low.R) + t*(float64(high.R)-float64(low.R))),
+			G: uint8(float64(low.G) + t*(float64(high.G)-float64(low.G))),
+			B: uint8(float64(low.B) + t*(float64(high.B)-float64(low.B))),
+			A: 255,
+		}
+	}
+
+	// draw items
+	for i, group := range [][]*neatns.NoveltyItem{items, fittest} {
+		for _, item := range group {
+			path, err := maze.NoveltyItemPath(item, env)
+			if err != nil {
+				return err
+			}
+			c := colorAt(value(item))
+			if trajectories && len(path) > 1 {
+				plotTrajectory(append([]maze.Point{env.Hero.Location}, path...), dc, c)
+			}
+			end := path[len(path)-1]
+			dc.DrawCircle(end.X, end.Y, 3.0)
+			dc.SetColor(c)
+			if i == 0 {
+				dc.Fill()
+			} else {
+				// outline the fittest
+				dc.FillPreserve()
+				dc.SetColor(color.Black)
+				dc.Stroke()
+			}
+		}
+	}
 
 	// draw maze
 	drawMaze(env, dc)
 
-	fmt.Printf("Path rendered for %d points", len(rs.SolverPathPoints))
+	// draw color scale
+	dc.Push()
+	barScale := gg.NewLinearGradient(5, 0, float64(dc.Width()-5), 0)
+	barScale.AddColorStop(0, low)
+	barScale.AddColorStop(1, high)
+	dc.SetFillStyle(barScale)
+	dc.DrawRectangle(5, float64(dc.Height()-10), float64(dc.Width()-10), 5)
+	dc.Fill()
+	dc.SetColor(color.RGBA{B: 102, A: 255})
+	dc.DrawStringAnchored(fmt.Sprintf("%s: %.2f", colorBy, minValue), 5, float64(dc.Height()-12), 0, 0)
+	dc.DrawStringAnchored(fmt.Sprintf("%.2f", maxValue), float64(dc.Width()-5), float64(dc.Height()-12), 1, 0)
+	dc.Pop()
+
+	fmt.Printf("Rendered %d archived and %d fittest items colored by %s in range: [%.2f, %.2f]\n",
+		len(items), len(fittest), colorBy, minValue, maxValue)
+
+	return nil
+}
+
+// archiveItemValue returns the function to get the value of the novelty item property with given name
+func archiveItemValue(name string) (func(item *neatns.NoveltyItem) float64, error) {
+	switch name {
+	case "generation":
+		return func(item *neatns.NoveltyItem) float64 {
+			return float64(item.Generation)
+		}, nil
+	case "novelty":
+		return func(item *neatns.NoveltyItem) float64 {
+			return item.Novelty
+		}, nil
+	case "fitness":
+		return func(item *neatns.NoveltyItem) float64 {
+			return item.Fitness
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported novelty item property to color by: %s", name)
+	}
+}
+
+// plotTrajectory draws the agent trajectory as polyline
+func plotTrajectory(path []maze.Point, dc *gg.Context, c color.Color) {
+	dc.Push()
+	r, g, b, _ := c.RGBA()
+	dc.SetColor(color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 96})
+	dc.SetLineWidth(1.0)
+	dc.MoveTo(path[0].X, path[0].Y)
+	for _, p := range path[1:] {
+		dc.LineTo(p.X, p.Y)
+	}
+	dc.Stroke()
+	dc.Pop()
+}
+
+// readNoveltyItems reads novelty items dumped from the novelty archive
+func readNoveltyItems(itemsPath string) ([]*neatns.NoveltyItem, error) {
+	if len(itemsPath) == 0 {
+		return nil, errors.New("the novelty archive points path not specified")
+	}
+	file, err := os.Open(itemsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return neatns.ReadNoveltyItems(file)
+}
+
+// drawMazeHeatmap draws the visitation heatmaps of agents records from the files over the maze side by side with the
+// common color scale and prints the coverage of the maze by each of them
+func drawMazeHeatmap(recPaths []string, env *maze.Environment, cellSize float64, logScale, trajectories bool, dc *gg.Context) error {
+	heatmaps := make([]*maze.VisitationHeatmap, len(recPaths))
+	maxCount := 0
+	for i, recPath := range recPaths {
+		rs, err := readRecords(recPath)
+		if err != nil {
+			return err
+		}
+		if heatmaps[i], err = maze.NewVisitationHeatmap(env, cellSize); err != nil {
+			return err
+		}
+		heatmaps[i].AddRecords(rs, trajectories)
+		maxCount = max(maxCount, heatmaps[i].MaxCount)
+	}
+
+	offset := 0.0
+	for i, h := range heatmaps {
+		dc.Push()
+		dc.Translate(offset, 0)
+		plotHeatmap(h, maxCount, logScale, dc)
+		bounds := drawMaze(env, dc)
+
+		label := fmt.Sprintf("%s/%s", path.Base(path.Dir(recPaths[i])), path.Base(recPaths[i]))
+		dc.SetColor(color.RGBA{B: 102, A: 255})
+		dc.DrawStringAnchored(label, float64(bounds.Min.X), float64(bounds.Max.Y+5), 0, 1)
+		dc.DrawStringAnchored(fmt.Sprintf("coverage: %.1f%%", h.Coverage()*100), float64(bounds.Min.X),
+			float64(bounds.Max.Y+20), 0, 1)
+		dc.Pop()
+		offset += float64(bounds.Max.X + 10)
+
+		fmt.Printf("%s: coverage: %.2f%% of %d reachable cells, max visits per cell: %d\n",
+			recPaths[i], h.Coverage()*100, h.ReachableCells(), h.MaxCount)
+	}
+
+	// draw color scale
+	dc.Push()
+	barWidth := float64(dc.Width() - 10)
+	for x := 0.0; x < barWidth; x++ {
+		dc.SetColor(heatColor(x / barWidth))
+		dc.DrawRectangle(5+x, float64(dc.Height()-10), 1, 5)
+		dc.Fill()
+	}
+	scale := "visits"
+	if logScale {
+		scale = "visits (log)"
+	}
+	dc.SetColor(color.RGBA{B: 102, A: 255})
+	dc.DrawStringAnchored(fmt.Sprintf("%s: 0", scale), 5, float64(dc.Height()-12), 0, 0)
+	dc.DrawStringAnchored(fmt.Sprintf("%d", maxCount), float64(dc.Width()-5), float64(dc.Height()-12), 1, 0)
+	dc.Pop()
+
+	return nil
+}
+
+// heatmapCanvasSize returns the canvas size enough to fit the given number of heatmap panels with their labels and
+// the color scale, but not less than the requested size
+func heatmapCanvasSize(env *maze.Environment, panels, width, height int) (int, int) {
+	maxX, maxY := 0.0, 0.0
+	for _, l := range env.Lines {
+		maxX = math.Max(maxX, math.Max(l.A.X, l.B.X))
+		maxY = math.Max(maxY, math.Max(l.A.Y, l.B.Y))
+	}
+	// the panels are drawn with offset of the maze width plus margin, the labels and color scale are drawn below
+	width = max(width, panels*int(math.Ceil(maxX+10)))
+	height = max(height, int(math.Ceil(maxY+50)))
+	return width, height
+}
+
+// plotHeatmap draws the cells of visitation heatmap colored by the number of visits, the reachable cells which were
+// not visited are drawn gray
+func plotHeatmap(h *maze.VisitationHeatmap, maxCount int, logScale bool, dc *gg.Context) {
+	f := h.Field
+	for row := 0; row < f.Rows; row++ {
+		for col := 0; col < f.Cols; col++ {
+			if h.Count(col, row) > 0 {
+				dc.SetColor(heatColor(h.Intensity(col, row, maxCount, logScale)))
+			} else if !math.IsInf(f.CellDistance(col, row), 1) {
+				dc.SetColor(color.Gray{Y: 235})
+			} else {
+				continue
+			}
+			center := f.CellCenter(col, row)
+			dc.DrawRectangle(center.X-f.CellSize/2, center.Y-f.CellSize/2, f.CellSize, f.CellSize)
+			dc.Fill()
+		}
+	}
+}
+
+// heatColor returns the color of the heat scale for the value in [0, 1] range, from light yellow to dark red
+func heatColor(v float64) color.Color {
+	stops := []color.RGBA{{R: 255, G: 255, B: 178, A: 255}, {R: 253, G: 141, B: 60, A: 255}, {R: 189, B: 38, A: 255}}
+	v = math.Max(0, math.Min(v, 1)) * float64(len(stops)-1)
+	i := min(int(v), len(stops)-2)
+	t := v - float64(i)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(float64(a) + t*(float64(b)-float64(a)))
+	}
+	return color.RGBA{
+		R: lerp(stops[i].R, stops[i+1].R), G: lerp(stops[i].G, stops[i+1].G), B: lerp(stops[i].B, stops[i+1].B), A: 255,
+	}
+}
+
+// exportRecords exports agents records from the file into the files named after the output path without extension
+func exportRecords(recPath, formatName, outPath string) error {
+	format, err := maze.ExportFormatFromString(formatName)
+	if err != nil {
+		return err
+	}
+	rs, err := readRecords(recPath)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+	paths, err := maze.ExportRecordStore(rs, format, strings.TrimSuffix(outPath, path.Ext(outPath)))
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		log.Printf("Exported %d records and %d solvers to: %s\n", len(rs.Records), len(rs.Solvers), p)
+	}
+	return nil
+}
+
+// writeRecordsStats writes statistics of agents records per generation and per species as CSV tables into the files
+// named after the output path without extension
+func writeRecordsStats(recPath, outPath string) error {
+	rs, err := readRecords(recPath)
+	if err != nil {
+		return err
+	}
+	stats := rs.Query().Stats()
+	fmt.Printf("records: %d, solvers: %d, generations: %d-%d, species: %d, fitness: %.3f/%.3f [mean/max], novelty: %.3f/%.3f [mean/max]\n",
+		stats.Count, stats.Solvers, stats.FirstGeneration, stats.LastGeneration, stats.Species, stats.Fitness.Mean,
+		stats.Fitness.Max, stats.Novelty.Mean, stats.Novelty.Max)
 
+	if err = os.MkdirAll(path.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(outPath, path.Ext(outPath))
+	for name, table := range map[string]*maze.RecordStatsTable{
+		"generations": rs.Query().ByGeneration(),
+		"species":     rs.Query().BySpecies(),
+	} {
+		statsPath := fmt.Sprintf("%s_%s.csv", prefix, name)
+		file, err := os.Create(statsPath)
+		if err != nil {
+			return err
+		}
+		err = table.WriteCSV(file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+		log.Printf("Statistics of %d %s written to: %s\n", len(table.Rows), name, statsPath)
+	}
 	return nil
 }
 
+// writeLineage writes the ancestry of the solver agent with given ID, or of all solvers if ID is negative, as JSON and
+// GraphML into the files named after the output path without extension, and prints the inferred stepping stones of
+// solvers
+func writeLineage(linPath string, solverID int, outPath string) error {
+	if len(linPath) == 0 {
+		return errors.New("the lineage path not specified")
+	}
+	linFile, err := os.Open(linPath)
+	if err != nil {
+		return err
+	}
+	lineage, err := maze.ReadLineage(linFile)
+	_ = linFile.Close()
+	if err != nil {
+		return err
+	}
+
+	solverIDs := make([]int, 0)
+	if solverID >= 0 {
+		solverIDs = append(solverIDs, solverID)
+	} else {
+		for _, s := range lineage.Solvers() {
+			solverIDs = append(solverIDs, s.AgentID)
+		}
+	}
+	if len(solverIDs) == 0 {
+		return errors.New("no solvers found in the lineage")
+	}
+	for _, id := range solverIDs {
+		stones, err := lineage.SteppingStones(id)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("solver: %d, inferred stepping stones: %d\n", id, len(stones))
+		for _, n := range stones {
+			fmt.Printf("\tagent: %d, generation: %d, species: %d, fitness: %.3f, novelty: %.3f, parents confidence: %.2f\n",
+				n.AgentID, n.Generation, n.SpeciesID, n.Fitness, n.Novelty, n.ParentsConfidence)
+		}
+	}
+	ancestry, err := lineage.Ancestry(solverIDs...)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(path.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(outPath, path.Ext(outPath))
+	for ext, write := range map[string]func(io.Writer) error{
+		"json":    ancestry.WriteJSON,
+		"graphml": ancestry.WriteGraphML,
+	} {
+		ancestryPath := fmt.Sprintf("%s_lineage.%s", prefix, ext)
+		file, err := os.Create(ancestryPath)
+		if err != nil {
+			return err
+		}
+		err = write(file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+		log.Printf("Lineage of %d agents written to: %s\n", len(ancestry.Nodes), ancestryPath)
+	}
+	return nil
+}
+
+// writeAnimation writes the animated GIF of the agent's run replayed from the recorded rollout, or simulated for the
+// genome within the maze if rollout path not specified. The time steps and exit range are used if not set by the maze
+// config or if explicitly set in the command line.
+func writeAnimation(rolloutPath, genomePath, mazePath, outPath string, timeSteps int, exitRange float64,
+	explicitFlags map[string]bool, opts maze.AnimationOptions) error {
+	var rollout *maze.Rollout
+	var err error
+	if len(rolloutPath) > 0 {
+		if rollout, err = maze.ReadRolloutFromFile(rolloutPath); err != nil {
+			return err
+		}
+	} else {
+		if len(genomePath) == 0 || len(mazePath) == 0 {
+			return errors.New("either the rollout path or the genome and maze paths must be specified")
+		}
+		env, err := maze.ReadEnvironmentFromFile(mazePath)
+		if err != nil {
+			return err
+		}
+		if env.TimeSteps == 0 || explicitFlags["timesteps"] {
+			env.TimeSteps = timeSteps
+		}
+		if env.ExitFoundRange == 0 || explicitFlags["exit_range"] {
+			env.ExitFoundRange = exitRange
+		}
+		reader, err := genetics.NewGenomeReaderFromFile(genomePath)
+		if err != nil {
+			return err
+		}
+		genome, err := reader.Read()
+		if err != nil {
+			return err
+		}
+		org, err := genetics.NewOrganism(0, genome, 0)
+		if err != nil {
+			return err
+		}
+		if rollout, err = maze.RecordRollout(env, org); err != nil {
+			return err
+		}
+	}
+
+	if len(rollout.Steps) == 0 {
+		return errors.New("the agent's run has no steps to animate")
+	}
+	if err = os.MkdirAll(path.Dir(outPath), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	err = maze.WriteRolloutGIF(file, rollout, opts)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Animation of %d steps, exit found: %t, fitness: %f written to: %s\n", len(rollout.Steps),
+		rollout.ExitFound, rollout.Fitness, outPath)
+	return nil
+}
+
+// readRecords reads agents records from the file
+func readRecords(recPath string) (*maze.RecordStore, error) {
+	if len(recPath) == 0 {
+		return nil, errors.New("the records path not specified")
+	}
+	recFile, err := os.Open(recPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = recFile.Close()
+	}()
+	return maze.ReadRecordStore(recFile)
+}
+
+// importMazeImage imports maze from the image and saves it into the maze config file
+func importMazeImage(imagePath, mazePath string, opts maze.ImageImportOptions) (*maze.Environment, error) {
+	if len(imagePath) == 0 {
+		return nil, errors.New("the image path not specified")
+	}
+	imageFile, err := os.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = imageFile.Close()
+	}()
+	env, err := maze.ImportMazeImage(imageFile, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(path.Dir(mazePath), os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err = maze.WriteEnvironmentToFile(mazePath, env); err != nil {
+		return nil, err
+	}
+	log.Printf("Maze with %d walls imported and saved to: %s\n", len(env.Lines), mazePath)
+	return env, nil
+}
+
 func main() {
 	var outFilePath = flag.String("out", "./out/out.png", "The PNG file to save visualization results.")
 	var width = flag.Int("width", 400, "The canvas width for visualization")
@@ -243,30 +733,110 @@ func main() {
 	var recPath = flag.String("records", "", "The path to the file with agents recorded data")
 	var mazePath = flag.String("maze", "", "The path to the maze environment config file")
 	var bestThreshold = flag.Float64("b_thresh", 0.8, "The minimal fitness of maze solving agent's species to be considered as the best ones.")
-	var operation = flag.String("operation", "draw_agents", "The name of operation to apply to the records [draw_agents, draw_path].")
+	var operation = flag.String("operation", "draw_agents", "The name of operation to apply [draw_agents, draw_path, draw_archive, heatmap, animate, import_image, export, stats, lineage].")
 	var groupByAge = flag.Bool("group_by_age", false, "The flag to indicate whether agent records should be grouped by age of species")
 	var scale = flag.Float64("scale", 1.0, "The scale factor for produced graphics")
+	var imagePath = flag.String("image", "", "The path to the PNG or BMP image to import maze from. The imported maze is saved into the maze config file.")
+	var imageScale = flag.Float64("image_scale", 1.0, "The scale factor to convert image pixels into maze coordinates.")
+	var tolerance = flag.Float64("tolerance", 1.0, "The tolerance in pixels to simplify traced wall contours into line segments.")
+	var wallThreshold = flag.Float64("wall_threshold", 0.5, "The luminance threshold [0, 1], image pixels darker than it are considered as walls.")
+	var heading = flag.Float64("heading", 0, "The initial heading of the agent in the imported maze.")
+	var exportFormat = flag.String("format", "CSV", "The format to export agents records into [CSV, JSONL, NPZ]. The exported files are named after the output file without extension.")
+	var solverID = flag.Int("solver", -1, "The ID of the solver agent which path to draw or lineage to write. All solvers are used if negative.")
+	var linPath = flag.String("lineage", "", "The path to the file with lineage graph of agents")
+	var archivePath = flag.String("archive", "", "The path to the file with novelty archive points to draw.")
+	var fittestPath = flag.String("fittest", "", "The path to the file with the fittest novelty archive points to draw outlined.")
+	var colorBy = flag.String("color_by", "generation", "The property to color novelty archive points by [generation, novelty, fitness].")
+	var trajectories = flag.Bool("trajectories", false, "The flag to indicate whether sampled trajectories of novelty archive points should be drawn, or paths of solvers should be added to the heatmap.")
+	var comparePath = flag.String("compare", "", "The path to the second file with agents recorded data to draw its heatmap side by side for comparison.")
+	var cellSize = flag.Float64("cell_size", 5.0, "The size of the heatmap grid cell.")
+	var logScale = flag.Bool("log_scale", false, "The flag to indicate whether the logarithmic scale should be applied to the heatmap visits.")
+	var rolloutPath = flag.String("rollout", "", "The path to the recorded rollout of the agent to animate.")
+	var genomePath = flag.String("genome", "", "The path to the genome of the agent to animate within the maze if rollout not specified.")
+	var frameStride = flag.Int("stride", 1, "The number of simulation time steps per animation frame.")
+	var frameDelay = flag.Int("delay", 4, "The delay between animation frames in 100ths of a second.")
+	var timeSteps = flag.Int("timesteps", 400, "The number of time steps for simulation of the animated genome. Used if not set by maze config.")
+	var exitRange = flag.Float64("exit_range", 5.0, "The range around maze exit point to consider it as reached. Used if not set by maze config.")
 
 	flag.Parse()
 
-	rand.Seed(int64(1042))
+	// collect flags explicitly set in the command line to override values from the maze configuration file
+	explicitFlags := maze.ExplicitFlags(flag.CommandLine)
 
-	log.Printf("Loading records from: %s\n", *recPath)
+	rand.Seed(int64(1042))
 
-	if len(*recPath) == 0 {
-		log.Fatal("The records path not specified")
-	}
-	recFile, err := os.Open(*recPath)
-	if err != nil {
-		log.Fatalf("Failed to open agents records file: %s\n", *recPath)
+	if *operation == "export" {
+		// the export operation requires no maze
+		if err := exportRecords(*recPath, *exportFormat, *outFilePath); err != nil {
+			log.Fatalf("Failed to export agents records, reason: %s\n", err)
+		}
+		return
+	} else if *operation == "stats" {
+		// the statistics operation requires no maze
+		if err := writeRecordsStats(*recPath, *outFilePath); err != nil {
+			log.Fatalf("Failed to write agents records statistics, reason: %s\n", err)
+		}
+		return
+	} else if *operation == "animate" {
+		// the animation is rendered with the maze of the rollout, or the maze file is read if genome is simulated
+		opts := maze.DefaultAnimationOptions()
+		opts.FrameStride = *frameStride
+		opts.FrameDelay = *frameDelay
+		opts.Scale = *scale
+		if err := writeAnimation(*rolloutPath, *genomePath, *mazePath, *outFilePath, *timeSteps, *exitRange,
+			explicitFlags, opts); err != nil {
+			log.Fatalf("Failed to write animation of the agent's run, reason: %s\n", err)
+		}
+		return
+	} else if *operation == "lineage" {
+		// the lineage operation requires no maze
+		if err := writeLineage(*linPath, *solverID, *outFilePath); err != nil {
+			log.Fatalf("Failed to write lineage of solvers, reason: %s\n", err)
+		}
+		return
 	}
 
 	if len(*mazePath) == 0 {
 		log.Fatal("The maze config file not set")
 	}
-	mazeFile, err := os.Open(*mazePath)
-	if err != nil {
-		log.Fatalf("Failed to open maze config file: %s\n", *mazePath)
+
+	var env *maze.Environment
+	var recFile *os.File
+	var err error
+	if *operation == "import_image" {
+		opts := maze.DefaultImageImportOptions()
+		opts.Scale = *imageScale
+		opts.Tolerance = *tolerance
+		opts.WallThreshold = *wallThreshold
+		opts.StartHeading = *heading
+		if env, err = importMazeImage(*imagePath, *mazePath, opts); err != nil {
+			log.Fatalf("Failed to import maze from image: %s, reason: %s\n", *imagePath, err)
+		}
+	} else {
+		if *operation != "draw_archive" && *operation != "heatmap" {
+			// the drawing operations require agents records
+			log.Printf("Loading records from: %s\n", *recPath)
+
+			if len(*recPath) == 0 {
+				log.Fatal("The records path not specified")
+			}
+			if recFile, err = os.Open(*recPath); err != nil {
+				log.Fatalf("Failed to open agents records file: %s\n", *recPath)
+			}
+		}
+
+		if env, err = maze.ReadEnvironmentFromFile(*mazePath); err != nil {
+			log.Fatalf("Failed to read maze config file: %s, reason: %s\n", *mazePath, err)
+		}
+	}
+
+	if *operation == "heatmap" {
+		// the canvas should fit heatmaps of all compared records side by side
+		panels := 1
+		if len(*comparePath) > 0 {
+			panels = 2
+		}
+		*width, *height = heatmapCanvasSize(env, panels, *width, *height)
 	}
 
 	contextWidth := float64(*width) * *scale
@@ -284,9 +854,20 @@ func main() {
 
 	switch *operation {
 	case "draw_agents":
-		err = drawMazeWithRecords(recFile, mazeFile, *bestThreshold, *groupByAge, dc)
+		err = drawMazeWithRecords(recFile, env, *bestThreshold, *groupByAge, dc)
 	case "draw_path":
-		err = drawMazeWithPath(recFile, mazeFile, dc)
+		err = drawMazeWithPath(recFile, env, *solverID, dc)
+	case "draw_archive":
+		err = drawMazeWithArchive(*archivePath, *fittestPath, *colorBy, *trajectories, env, dc)
+	case "heatmap":
+		recPaths := []string{*recPath}
+		if len(*comparePath) > 0 {
+			recPaths = append(recPaths, *comparePath)
+		}
+		err = drawMazeHeatmap(recPaths, env, *cellSize, *logScale, *trajectories, dc)
+	case "import_image":
+		// render preview of the imported maze
+		drawMaze(env, dc)
 	default:
 		log.Fatalf("Usupported drawing operation requested: %s", *operation)
 
@@ -0,0 +1,103 @@
+// The command to generate maze environments procedurally and to save them in the format supported by maze
+// solving experiments. The format is selected by the output file extension: .yml/.yaml, .json or the legacy text.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	defaults := maze.DefaultGeneratorOptions()
+	var outPath = flag.String("out", "./out/maze.txt", "The file to save generated maze into.")
+	var suiteDir = flag.String("suite", "", "The directory to save benchmark suite of mazes with graded difficulty. If set all other maze options are ignored.")
+	var suiteFormat = flag.String("suite_ext", ".txt", "The file extension of benchmark suite mazes [.txt, .yml, .json].")
+	var cols = flag.Int("cols", defaults.Cols, "The number of columns of the maze grid.")
+	var rows = flag.Int("rows", defaults.Rows, "The number of rows of the maze grid.")
+	var corridorWidth = flag.Float64("corridor", defaults.CorridorWidth, "The width of maze corridors.")
+	var algorithm = flag.String("algorithm", defaults.Algorithm.String(), "The maze generation algorithm [BACKTRACKER, KRUSKAL].")
+	var loopFactor = flag.Float64("loops", defaults.LoopFactor, "The fraction of internal walls to remove to create loops [0, 1].")
+	var seed = flag.Int64("seed", defaults.Seed, "The seed for the random number generator.")
+	var start = flag.String("start", "0,0", "The grid cell to place the agent as 'col,row'.")
+	var exit = flag.String("exit", "farthest", "The grid cell to place the maze exit as 'col,row' or 'farthest' to use the cell with the longest path from the start.")
+	var radius = flag.Float64("radius", defaults.Radius, "The radius of agent's body to check maze solvability.")
+
+	flag.Parse()
+
+	if len(*suiteDir) > 0 {
+		if err := os.MkdirAll(*suiteDir, os.ModePerm); err != nil {
+			log.Fatal("Failed to create output directory: ", err)
+		}
+		for _, m := range maze.BenchmarkMazes(*seed) {
+			m.Options.Radius = *radius
+			if err := generate(m.Options, filepath.Join(*suiteDir, m.Name+*suiteFormat)); err != nil {
+				log.Fatalf("Failed to generate benchmark maze: %s, reason: %s", m.Name, err)
+			}
+		}
+		return
+	}
+
+	opts := defaults
+	opts.Cols, opts.Rows = *cols, *rows
+	opts.CorridorWidth = *corridorWidth
+	opts.LoopFactor = *loopFactor
+	opts.Seed = *seed
+	opts.Radius = *radius
+
+	var err error
+	if opts.Algorithm, err = maze.MazeAlgorithmFromString(*algorithm); err != nil {
+		log.Fatal(err)
+	}
+	if opts.StartCell, err = parseGridCell(*start); err != nil {
+		log.Fatal("Failed to parse start cell: ", err)
+	}
+	opts.FarthestExit = *exit == "farthest"
+	if !opts.FarthestExit {
+		if opts.ExitCell, err = parseGridCell(*exit); err != nil {
+			log.Fatal("Failed to parse exit cell: ", err)
+		}
+	}
+
+	if err = os.MkdirAll(filepath.Dir(*outPath), os.ModePerm); err != nil {
+		log.Fatal("Failed to create output directory: ", err)
+	}
+	if err = generate(opts, *outPath); err != nil {
+		log.Fatal("Failed to generate maze: ", err)
+	}
+}
+
+// generate is to generate maze with given options and to save it into the file
+func generate(opts maze.GeneratorOptions, outPath string) error {
+	env, err := maze.GenerateMaze(opts)
+	if err != nil {
+		return err
+	}
+	if err = maze.WriteEnvironmentToFile(outPath, env); err != nil {
+		return err
+	}
+	log.Printf("Maze with %d walls saved to: %s\n", len(env.Lines), outPath)
+	return nil
+}
+
+// parseGridCell parses grid cell from the string formatted as 'col,row'
+func parseGridCell(str string) (maze.GridCell, error) {
+	parts := strings.Split(str, ",")
+	if len(parts) != 2 {
+		return maze.GridCell{}, fmt.Errorf("grid cell must be formatted as 'col,row', but was: %s", str)
+	}
+	col, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return maze.GridCell{}, err
+	}
+	row, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return maze.GridCell{}, err
+	}
+	return maze.GridCell{Col: col, Row: row}, nil
+}
@@ -0,0 +1,94 @@
+// The command to test generalization of the evolved maze solving controller across mazes, start poses and noise seeds.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/yaricom/goNEAT/v4/neat/genetics"
+	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	var genomePath = flag.String("genome", "", "The path to the genome file of the controller to be tested.")
+	var mazePaths = flag.String("mazes", "", "The comma separated list of maze environment config files.")
+	var randomStarts = flag.Int("starts", 10, "The number of random start poses per maze and seed in addition to the start pose of the maze.")
+	var startRadius = flag.Float64("start_radius", 0, "The maximal distance of random start locations from the start of the maze [0 to sample over the whole maze].")
+	var randomHeading = flag.Bool("random_heading", true, "The flag to indicate whether random start poses should have random headings.")
+	var seeds = flag.String("seeds", "1", "The comma separated list of seeds for the random number generator.")
+	var sensorNoise = flag.Float64("sensor_noise", 0, "The standard deviation of the Gaussian noise added to the sensor inputs.")
+	var actuatorNoise = flag.Float64("actuator_noise", 0, "The standard deviation of the Gaussian noise added to the controller outputs.")
+	var cellSize = flag.Float64("cell", 2.0, "The cell size of the grid to find start locations from which the exit is reachable.")
+	var timeSteps = flag.Int("timesteps", 400, "The number of time steps for maze simulation. Used if not set by maze config.")
+	var exitRange = flag.Float64("exit_range", 5.0, "The range around maze exit point to consider it as reached. Used if not set by maze config.")
+	var asJSON = flag.Bool("json", false, "The flag to indicate whether report should be printed as JSON.")
+
+	flag.Parse()
+
+	// collect flags explicitly set in the command line to override values from the maze configuration file
+	explicitFlags := maze.ExplicitFlags(flag.CommandLine)
+
+	if len(*genomePath) == 0 {
+		log.Fatal("The genome file not set")
+	}
+	if len(*mazePaths) == 0 {
+		log.Fatal("The maze config files not set")
+	}
+
+	reader, err := genetics.NewGenomeReaderFromFile(*genomePath)
+	if err != nil {
+		log.Fatal("Failed to open genome file: ", err)
+	}
+	genome, err := reader.Read()
+	if err != nil {
+		log.Fatal("Failed to read genome: ", err)
+	}
+
+	mazes := make([]*maze.Environment, 0)
+	for _, path := range strings.Split(*mazePaths, ",") {
+		env, err := maze.ReadEnvironmentFromFile(strings.TrimSpace(path))
+		if err != nil {
+			log.Fatalf("Failed to read maze environment configuration from: %s, reason: %s", path, err)
+		}
+		if env.TimeSteps == 0 || explicitFlags["timesteps"] {
+			env.TimeSteps = *timeSteps
+		}
+		if env.ExitFoundRange == 0 || explicitFlags["exit_range"] {
+			env.ExitFoundRange = *exitRange
+		}
+		mazes = append(mazes, env)
+	}
+
+	opts := maze.GeneralizationOptions{
+		RandomStarts:  *randomStarts,
+		StartRadius:   *startRadius,
+		RandomHeading: *randomHeading,
+		SensorNoise:   *sensorNoise,
+		ActuatorNoise: *actuatorNoise,
+		CellSize:      *cellSize,
+	}
+	for _, s := range strings.Split(*seeds, ",") {
+		seed, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			log.Fatal("Failed to parse seed: ", err)
+		}
+		opts.Seeds = append(opts.Seeds, seed)
+	}
+
+	report, err := maze.EvaluateGenomeGeneralization(genome, mazes, opts)
+	if err != nil {
+		log.Fatal("Failed to test generalization: ", err)
+	}
+
+	if *asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		_, err = fmt.Print(report)
+	}
+	if err != nil {
+		log.Fatal("Failed to print generalization report: ", err)
+	}
+}
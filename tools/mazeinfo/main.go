@@ -0,0 +1,50 @@
+// The command to analyze maze solvability and deceptiveness before running experiments with it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"log"
+	"os"
+)
+
+func main() {
+	var mazePath = flag.String("maze", "", "The path to the maze environment config file.")
+	var cellSize = flag.Float64("cell", 2.0, "The cell size of the configuration space grid.")
+	var exitRange = flag.Float64("exit_range", 5.0, "The range around maze exit point to consider it as reached.")
+	var asJSON = flag.Bool("json", false, "The flag to indicate whether report should be printed as JSON.")
+
+	flag.Parse()
+
+	// collect flags explicitly set in the command line to override values from the maze configuration file
+	explicitFlags := maze.ExplicitFlags(flag.CommandLine)
+
+	if len(*mazePath) == 0 {
+		log.Fatal("The maze config file not set")
+	}
+	env, err := maze.ReadEnvironmentFromFile(*mazePath)
+	if err != nil {
+		log.Fatal("Failed to read maze environment configuration: ", err)
+	}
+	if env.ExitFoundRange == 0 || explicitFlags["exit_range"] {
+		env.ExitFoundRange = *exitRange
+	}
+
+	report, err := maze.AnalyzeMaze(env, *cellSize)
+	if err != nil {
+		log.Fatal("Failed to analyze maze: ", err)
+	}
+
+	if *asJSON {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		_, err = fmt.Print(report)
+	}
+	if err != nil {
+		log.Fatal("Failed to print maze report: ", err)
+	}
+	if !report.Reachable {
+		os.Exit(1)
+	}
+}
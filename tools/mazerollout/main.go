@@ -0,0 +1,65 @@
+// The command to inspect the per-step rollout of the maze agent recorded by maze experiments and to verify that the
+// replayed simulation of the agent matches the recorded one bit for bit.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"log"
+	"os"
+	"strings"
+)
+
+func main() {
+	var rolloutPath = flag.String("rollout", "", "The path to the recorded rollout file.")
+	var verify = flag.Bool("verify", true, "The flag to indicate whether rollout should be replayed and verified.")
+	var printSteps = flag.Bool("steps", false, "The flag to indicate whether recorded steps should be printed as CSV table.")
+
+	flag.Parse()
+
+	if len(*rolloutPath) == 0 {
+		log.Fatal("The rollout file not set")
+	}
+	rollout, err := maze.ReadRolloutFromFile(*rolloutPath)
+	if err != nil {
+		log.Fatal("Failed to read rollout: ", err)
+	}
+	log.Printf("Agent: %d, genome: %d, generation: %d, maze: %s, steps: %d, exit found: %t, fitness: %f\n",
+		rollout.AgentID, rollout.GenomeID, rollout.Generation, rollout.Maze.Name, len(rollout.Steps),
+		rollout.ExitFound, rollout.Fitness)
+
+	if *verify {
+		if err = maze.VerifyRollout(rollout); err != nil {
+			log.Fatal("Replayed rollout doesn't match the recorded one: ", err)
+		}
+		log.Println("Replayed rollout matches the recorded one")
+	}
+
+	if *printSteps {
+		w := bufio.NewWriter(os.Stdout)
+		_, _ = fmt.Fprintln(w, "time_step,x,y,heading,speed,angular_velocity,range_finders,radar,waypoint_radar,outputs,collided")
+		for _, s := range rollout.Steps {
+			channels := make([]string, len(s.WaypointRadar))
+			for i, channel := range s.WaypointRadar {
+				channels[i] = joinValues(channel)
+			}
+			_, _ = fmt.Fprintf(w, "%d,%g,%g,%g,%g,%g,%s,%s,%s,%s,%t\n", s.TimeStep, s.Location.X, s.Location.Y,
+				s.Heading, s.Speed, s.AngularVelocity, joinValues(s.RangeFinders), joinValues(s.Radar),
+				strings.Join(channels, "|"), joinValues(s.Outputs), s.Collided)
+		}
+		if err = w.Flush(); err != nil {
+			log.Fatal("Failed to print steps: ", err)
+		}
+	}
+}
+
+// joinValues is to join values with space separator
+func joinValues(values []float64) string {
+	str := make([]string, len(values))
+	for i, v := range values {
+		str[i] = fmt.Sprintf("%g", v)
+	}
+	return strings.Join(str, " ")
+}
@@ -0,0 +1,44 @@
+// The command to serve the maze environment over the line-delimited JSON protocol on the standard input and output
+// or on the local TCP socket, allowing controllers written in other languages to run episodes within the maze.
+package main
+
+import (
+	"flag"
+	"github.com/yaricom/goNEAT_NS/v4/examples/maze"
+	"log"
+	"net"
+	"os"
+)
+
+func main() {
+	var addr = flag.String("addr", "", "The TCP address to listen at, e.g. 127.0.0.1:7070. If not set the standard input and output are used.")
+	var mazeDir = flag.String("maze_dir", "./data", "The directory to load maze config files requested by clients from.")
+	var defaultMaze = flag.String("maze", "medium_maze.txt", "The maze config file within maze directory to be used if not requested by the client.")
+	var timeSteps = flag.Int("timesteps", 400, "The number of time steps per episode. Used if not set by maze config.")
+	var exitRange = flag.Float64("exit_range", 5.0, "The range around maze exit point to consider it as reached. Used if not set by maze config.")
+
+	flag.Parse()
+
+	server := maze.NewServer(maze.ServerOptions{
+		MazeDir:        *mazeDir,
+		DefaultMaze:    *defaultMaze,
+		TimeSteps:      *timeSteps,
+		ExitFoundRange: *exitRange,
+	})
+
+	if len(*addr) == 0 {
+		if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatal("Failed to serve standard input: ", err)
+		}
+		return
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal("Failed to listen: ", err)
+	}
+	log.Printf("Serving maze environment at: %s\n", listener.Addr())
+	if err = server.ServeListener(listener); err != nil {
+		log.Fatal("Failed to serve: ", err)
+	}
+}